@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tylerolson/capstone-backend/services/achievements"
+)
+
+// GET /api/achievements
+func (s *Server) handleListAchievements() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := s.AchievementsService.ListAchievements()
+		if err != nil {
+			s.logger.Error("Failed to list achievements", "error", err)
+			http.Error(w, "Failed to list achievements", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			s.logger.Error("Failed to encode achievements response", "error", err)
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// GET /api/users/me/achievements
+func (s *Server) handleGetUserAchievements() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		earned, err := s.AchievementsService.GetUserAchievements(userID)
+		if err != nil {
+			s.logger.Error("Failed to get user achievements", "error", err)
+			http.Error(w, "Failed to get achievements", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(earned); err != nil {
+			s.logger.Error("Failed to encode user achievements response", "error", err)
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// evaluateAchievements awards any achievements the triggers unlocked. Failures are only
+// logged since achievements should never fail the request that earned them.
+func (s *Server) evaluateAchievements(userID int, triggers ...achievements.Trigger) []*achievements.UserAchievement {
+	awarded, err := s.AchievementsService.Evaluate(userID, triggers...)
+	if err != nil {
+		s.logger.Error("Failed to evaluate achievements", "userID", userID, "error", err)
+		return nil
+	}
+
+	for _, a := range awarded {
+		s.logger.Debug("Awarded achievement", "userID", userID, "achievement", a.Name)
+	}
+
+	return awarded
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+type SetRoleRequest struct {
+	Role user.Role `json:"role"`
+}
+
+type AdjustPointsRequest struct {
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
+
+type ReloadCoursesResponse struct {
+	Courses  int              `json:"courses"`
+	Problems []course.Problem `json:"problems"`
+}
+
+// handleAdminDeleteUser deletes a user account along with all of their data
+func (s *Server) handleAdminDeleteUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := r.PathValue("username")
+
+		if current, _ := s.GetUsername(r.Context()); current == username {
+			http.Error(w, "Admins can't delete their own account", http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := s.UserService.Get(username)
+		if err == nil {
+			err = s.UserService.DeleteUser(username)
+		}
+		if err != nil {
+			if errors.Is(err, user.ErrNoUser) {
+				http.Error(w, "User not found", http.StatusNotFound)
+				return
+			}
+			s.logger.Error("Failed to delete user", "username", username, "error", err)
+			http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+			return
+		}
+		s.identities.removeUser(deleted.ID)
+
+		s.logger.Info("Admin deleted user", "username", username)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleAdminSetRole changes a user's role
+func (s *Server) handleAdminSetRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := r.PathValue("username")
+
+		var req SetRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.UserService.SetRole(username, req.Role); err != nil {
+			switch {
+			case errors.Is(err, user.ErrInvalidRole):
+				http.Error(w, "Invalid role", http.StatusBadRequest)
+			case errors.Is(err, user.ErrNoUser):
+				http.Error(w, "User not found", http.StatusNotFound)
+			default:
+				s.logger.Error("Failed to set role", "username", username, "error", err)
+				http.Error(w, "Failed to set role", http.StatusInternalServerError)
+			}
+			return
+		}
+		if changed, err := s.UserService.Get(username); err == nil {
+			s.identities.removeUser(changed.ID)
+		} else {
+			s.identities.clear()
+		}
+
+		s.logger.Info("Admin changed user role", "username", username, "role", req.Role)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleAdminResetStreaks clears a user's daily and lesson streaks
+func (s *Server) handleAdminResetStreaks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target, ok := s.pathUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.PointsService.ResetStreaks(target.ID); err != nil {
+			s.logger.Error("Failed to reset streaks", "username", target.Username, "error", err)
+			http.Error(w, "Failed to reset streaks", http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.Info("Admin reset user streaks", "username", target.Username)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleAdminAdjustPoints adds or removes points from a user and records the reason
+func (s *Server) handleAdminAdjustPoints() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target, ok := s.pathUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req AdjustPointsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Points == 0 || req.Reason == "" {
+			http.Error(w, "Points and reason are required", http.StatusBadRequest)
+			return
+		}
+
+		transaction, err := s.PointsService.AdjustPoints(target.ID, req.Points, req.Reason)
+		if err != nil {
+			if errors.Is(err, points.ErrInsufficientPoints) {
+				http.Error(w, "User doesn't have enough points", http.StatusConflict)
+				return
+			}
+			s.logger.Error("Failed to adjust points", "username", target.Username, "error", err)
+			http.Error(w, "Failed to adjust points", http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.Info("Admin adjusted user points", "username", target.Username, "points", req.Points, "reason", req.Reason)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(transaction); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+// handleAdminReloadCourses reloads the course content from its source. Courses that fail
+// to load keep serving their last good version and the errors are returned.
+func (s *Server) handleAdminReloadCourses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reloadErr := s.CourseService.LoadCourseDir()
+
+		courses, err := s.CourseService.ListCourses()
+		if err != nil {
+			s.logger.Error("Failed to list courses", "error", err)
+			http.Error(w, "Failed to list courses", http.StatusInternalServerError)
+			return
+		}
+
+		response := ReloadCoursesResponse{Courses: len(courses), Problems: make([]course.Problem, 0)}
+		status := http.StatusOK
+		if reloadErr != nil {
+			s.logger.Error("Rejected course changes, serving the last good version", "error", reloadErr)
+			response.Problems = course.Problems(reloadErr)
+			status = http.StatusUnprocessableEntity
+		} else {
+			s.logger.Info("Admin reloaded courses", "courses", response.Courses)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+// handleAdminReconcilePoints recomputes the point totals from the transaction ledger and returns the ones
+// that drifted. With ?dryRun=true nothing is changed.
+func (s *Server) handleAdminReconcilePoints() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun := r.URL.Query().Get("dryRun") == "true"
+
+		report, err := s.PointsService.Reconcile(dryRun)
+		if err != nil {
+			s.logger.Error("Failed to reconcile points", "error", err)
+			http.Error(w, "Failed to reconcile points", http.StatusInternalServerError)
+			return
+		}
+
+		if !dryRun && len(report.Drift) > 0 {
+			s.logger.Info("Admin reconciled points", "drift", len(report.Drift))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+// pathUser looks up the user named in the path, writing the error response if there is none
+func (s *Server) pathUser(w http.ResponseWriter, r *http.Request) (*user.User, bool) {
+	target, err := s.UserService.Get(r.PathValue("username"))
+	if err != nil {
+		if errors.Is(err, user.ErrNoUser) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return nil, false
+		}
+		s.logger.Error("Failed to get user", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return target, true
+}
@@ -0,0 +1,169 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+// signInAs creates a user with the given role and returns a session token for them
+func signInAs(t *testing.T, server *api.Server, username string, role user.Role) string {
+	t.Helper()
+
+	u, err := server.UserService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", username, err)
+	}
+
+	if err := server.UserService.SetRole(username, role); err != nil {
+		t.Fatalf("Failed to set role: %v", err)
+	}
+
+	created, err := server.SessionService.CreateSession(u.ID, session.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	return created.Token
+}
+
+func serve(server *api.Server, method, path, token string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdminRoutes(t *testing.T) {
+	server := setupTestServer(t)
+
+	adminToken := signInAs(t, server, "admin", user.RoleAdmin)
+	studentToken := signInAs(t, server, "student", user.RoleStudent)
+
+	t.Run("Students Are Forbidden", func(t *testing.T) {
+		for _, path := range []string{"/api/users", "/api/admin/users"} {
+			if rr := serve(server, http.MethodGet, path, studentToken, nil); rr.Code != http.StatusForbidden {
+				t.Errorf("GET %s: got status %v, want %v", path, rr.Code, http.StatusForbidden)
+			}
+		}
+
+		rr := serve(server, http.MethodDelete, "/api/admin/users/admin", studentToken, nil)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("Anonymous Is Unauthorized", func(t *testing.T) {
+		if rr := serve(server, http.MethodGet, "/api/admin/users", "", nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("List Users", func(t *testing.T) {
+		rr := serve(server, http.MethodGet, "/api/admin/users", adminToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var users []*user.User
+		if err := json.Unmarshal(rr.Body.Bytes(), &users); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(users) != 2 {
+			t.Errorf("expected 2 users, got %d", len(users))
+		}
+	})
+
+	t.Run("Adjust Points", func(t *testing.T) {
+		rr := serve(server, http.MethodPost, "/api/admin/users/student/points", adminToken, api.AdjustPointsRequest{Points: 25, Reason: "Bug bounty"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		rr = serve(server, http.MethodPost, "/api/admin/users/student/points", adminToken, api.AdjustPointsRequest{Points: -100, Reason: "Too many"})
+		if rr.Code != http.StatusConflict {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("Reconcile Points", func(t *testing.T) {
+		rr := serve(server, http.MethodPost, "/api/admin/points/reconcile?dryRun=true", adminToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var report points.ReconcileReport
+		if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if !report.DryRun || len(report.Drift) != 0 {
+			t.Errorf("unexpected reconcile report: %+v", report)
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/admin/points/reconcile", studentToken, nil); rr.Code != http.StatusForbidden {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("Reset Streaks", func(t *testing.T) {
+		if rr := serve(server, http.MethodPost, "/api/admin/users/student/streaks/reset", adminToken, nil); rr.Code != http.StatusNoContent {
+			t.Errorf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/admin/users/nobody/streaks/reset", adminToken, nil); rr.Code != http.StatusNotFound {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Reload Courses", func(t *testing.T) {
+		rr := serve(server, http.MethodPost, "/api/admin/courses/reload", adminToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var response api.ReloadCoursesResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Courses != 3 || len(response.Problems) != 0 {
+			t.Errorf("unexpected reload response: %+v", response)
+		}
+	})
+
+	t.Run("Promote And Delete", func(t *testing.T) {
+		rr := serve(server, http.MethodPut, "/api/admin/users/student/role", adminToken, api.SetRoleRequest{Role: user.RoleInstructor})
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		rr = serve(server, http.MethodPut, "/api/admin/users/student/role", adminToken, api.SetRoleRequest{Role: "superuser"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+
+		if rr := serve(server, http.MethodDelete, "/api/admin/users/student", adminToken, nil); rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		if _, err := server.UserService.Get("student"); err != user.ErrNoUser {
+			t.Errorf("expected student to be deleted, got %v", err)
+		}
+	})
+}
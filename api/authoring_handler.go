@@ -0,0 +1,291 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/tylerolson/capstone-backend/course"
+)
+
+type ReorderRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// registerAuthoringRoutes mounts the course authoring API when the course store can be edited
+func (s *Server) registerAuthoringRoutes(protect func(http.Handler) http.Handler) {
+	editor, ok := s.CourseService.(course.Editor)
+	if !ok {
+		s.logger.Info("Course store is read only, authoring routes are disabled")
+		return
+	}
+
+	s.Mux.Handle("GET /api/authoring/courses", protect(s.handleAuthoringListCourses(editor)))
+	s.Mux.Handle("POST /api/authoring/courses", protect(s.handleAuthoringCreateCourse(editor)))
+	s.Mux.Handle("PUT /api/authoring/course-order", protect(s.handleAuthoringReorderCourses(editor)))
+	s.Mux.Handle("GET /api/authoring/courses/{courseID}", protect(s.handleAuthoringGetCourse(editor)))
+	s.Mux.Handle("PUT /api/authoring/courses/{courseID}", protect(s.handleAuthoringUpdateCourse(editor)))
+	s.Mux.Handle("DELETE /api/authoring/courses/{courseID}", protect(s.handleAuthoringDeleteCourse(editor)))
+
+	s.Mux.Handle("POST /api/authoring/courses/{courseID}/lessons", protect(s.handleAuthoringCreateLesson(editor)))
+	s.Mux.Handle("PUT /api/authoring/courses/{courseID}/lesson-order", protect(s.handleAuthoringReorderLessons(editor)))
+	s.Mux.Handle("PUT /api/authoring/courses/{courseID}/lessons/{lessonID}", protect(s.handleAuthoringUpdateLesson(editor)))
+	s.Mux.Handle("DELETE /api/authoring/courses/{courseID}/lessons/{lessonID}", protect(s.handleAuthoringDeleteLesson(editor)))
+
+	s.Mux.Handle("POST /api/authoring/courses/{courseID}/lessons/{lessonID}/exercises", protect(s.handleAuthoringCreateExercise(editor)))
+	s.Mux.Handle("PUT /api/authoring/courses/{courseID}/lessons/{lessonID}/exercise-order", protect(s.handleAuthoringReorderExercises(editor)))
+	s.Mux.Handle("PUT /api/authoring/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}", protect(s.handleAuthoringUpdateExercise(editor)))
+	s.Mux.Handle("DELETE /api/authoring/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}", protect(s.handleAuthoringDeleteExercise(editor)))
+}
+
+// GET /api/authoring/courses
+func (s *Server) handleAuthoringListCourses(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courses, err := editor.ListCoursesForEditing()
+		if err != nil {
+			s.writeCourseError(w, "Failed to list courses", err)
+			return
+		}
+
+		s.writeAuthoringResponse(w, http.StatusOK, courses)
+	}
+}
+
+// GET /api/authoring/courses/{courseID}
+func (s *Server) handleAuthoringGetCourse(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := editor.GetCourseForEditing(r.PathValue("courseID"))
+		if err != nil {
+			s.writeCourseError(w, "Failed to get course", err)
+			return
+		}
+
+		s.writeAuthoringResponse(w, http.StatusOK, c)
+	}
+}
+
+// POST /api/authoring/courses
+func (s *Server) handleAuthoringCreateCourse(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var c course.Course
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.CreateCourse(&c); err != nil {
+			s.writeCourseError(w, "Failed to create course", err)
+			return
+		}
+
+		s.logger.Info("Created course", "courseID", c.ID)
+		s.writeAuthoringResponse(w, http.StatusCreated, c)
+	}
+}
+
+// PUT /api/authoring/courses/{courseID}
+func (s *Server) handleAuthoringUpdateCourse(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var c course.Course
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.UpdateCourse(r.PathValue("courseID"), &c); err != nil {
+			s.writeCourseError(w, "Failed to update course", err)
+			return
+		}
+
+		s.writeAuthoringResponse(w, http.StatusOK, c)
+	}
+}
+
+// DELETE /api/authoring/courses/{courseID}
+func (s *Server) handleAuthoringDeleteCourse(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseID := r.PathValue("courseID")
+		if err := editor.DeleteCourse(courseID); err != nil {
+			s.writeCourseError(w, "Failed to delete course", err)
+			return
+		}
+
+		s.logger.Info("Deleted course", "courseID", courseID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PUT /api/authoring/course-order
+func (s *Server) handleAuthoringReorderCourses(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReorderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.ReorderCourses(req.IDs); err != nil {
+			s.writeCourseError(w, "Failed to reorder courses", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// POST /api/authoring/courses/{courseID}/lessons
+func (s *Server) handleAuthoringCreateLesson(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var lesson course.Lesson
+		if err := json.NewDecoder(r.Body).Decode(&lesson); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.CreateLesson(r.PathValue("courseID"), &lesson); err != nil {
+			s.writeCourseError(w, "Failed to create lesson", err)
+			return
+		}
+
+		s.writeAuthoringResponse(w, http.StatusCreated, lesson)
+	}
+}
+
+// PUT /api/authoring/courses/{courseID}/lessons/{lessonID}
+func (s *Server) handleAuthoringUpdateLesson(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var lesson course.Lesson
+		if err := json.NewDecoder(r.Body).Decode(&lesson); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.UpdateLesson(r.PathValue("courseID"), r.PathValue("lessonID"), &lesson); err != nil {
+			s.writeCourseError(w, "Failed to update lesson", err)
+			return
+		}
+
+		s.writeAuthoringResponse(w, http.StatusOK, lesson)
+	}
+}
+
+// DELETE /api/authoring/courses/{courseID}/lessons/{lessonID}
+func (s *Server) handleAuthoringDeleteLesson(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := editor.DeleteLesson(r.PathValue("courseID"), r.PathValue("lessonID")); err != nil {
+			s.writeCourseError(w, "Failed to delete lesson", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PUT /api/authoring/courses/{courseID}/lesson-order
+func (s *Server) handleAuthoringReorderLessons(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReorderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.ReorderLessons(r.PathValue("courseID"), req.IDs); err != nil {
+			s.writeCourseError(w, "Failed to reorder lessons", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// POST /api/authoring/courses/{courseID}/lessons/{lessonID}/exercises
+func (s *Server) handleAuthoringCreateExercise(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var exercise course.Exercise
+		if err := json.NewDecoder(r.Body).Decode(&exercise); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.CreateExercise(r.PathValue("courseID"), r.PathValue("lessonID"), &exercise); err != nil {
+			s.writeCourseError(w, "Failed to create exercise", err)
+			return
+		}
+
+		s.writeAuthoringResponse(w, http.StatusCreated, exercise)
+	}
+}
+
+// PUT /api/authoring/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}
+func (s *Server) handleAuthoringUpdateExercise(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var exercise course.Exercise
+		if err := json.NewDecoder(r.Body).Decode(&exercise); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err := editor.UpdateExercise(r.PathValue("courseID"), r.PathValue("lessonID"), r.PathValue("exerciseID"), &exercise)
+		if err != nil {
+			s.writeCourseError(w, "Failed to update exercise", err)
+			return
+		}
+
+		s.writeAuthoringResponse(w, http.StatusOK, exercise)
+	}
+}
+
+// DELETE /api/authoring/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}
+func (s *Server) handleAuthoringDeleteExercise(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := editor.DeleteExercise(r.PathValue("courseID"), r.PathValue("lessonID"), r.PathValue("exerciseID"))
+		if err != nil {
+			s.writeCourseError(w, "Failed to delete exercise", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PUT /api/authoring/courses/{courseID}/lessons/{lessonID}/exercise-order
+func (s *Server) handleAuthoringReorderExercises(editor course.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReorderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := editor.ReorderExercises(r.PathValue("courseID"), r.PathValue("lessonID"), req.IDs); err != nil {
+			s.writeCourseError(w, "Failed to reorder exercises", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeCourseError maps course store errors onto status codes, logging anything unexpected
+func (s *Server) writeCourseError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, course.ErrCourseNotFound), errors.Is(err, course.ErrLessonNotFound), errors.Is(err, course.ErrExerciseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, course.ErrDuplicateID):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, course.ErrInvalidContent), errors.Is(err, course.ErrInvalidOrder):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) writeAuthoringResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error("Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,506 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+// memoryEditor is a course store that can be edited, keeping everything in memory like the database store would
+type memoryEditor struct {
+	course.Service
+	mu      sync.Mutex
+	courses []*course.Course
+}
+
+func (e *memoryEditor) ListCoursesForEditing() ([]*course.Course, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return slices.Clone(e.courses), nil
+}
+
+func (e *memoryEditor) GetCourseForEditing(courseID string) (*course.Course, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.findCourse(courseID)
+}
+
+func (e *memoryEditor) CreateCourse(c *course.Course) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if problems := course.ValidateCourse(c); len(problems) > 0 {
+		return &course.ValidationError{Problems: problems}
+	}
+	if _, err := e.findCourse(c.ID); err == nil {
+		return fmt.Errorf("%w: course %s", course.ErrDuplicateID, c.ID)
+	}
+
+	if c.Status == "" {
+		c.Status = course.StatusDraft
+	}
+	created := *c
+	e.courses = append(e.courses, &created)
+	return nil
+}
+
+func (e *memoryEditor) UpdateCourse(courseID string, c *course.Course) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c.ID = courseID
+	if problems := course.ValidateCourse(c); len(problems) > 0 {
+		return &course.ValidationError{Problems: problems}
+	}
+	existing, err := e.findCourse(courseID)
+	if err != nil {
+		return err
+	}
+
+	existing.Name, existing.Description = c.Name, c.Description
+	if c.Status != "" {
+		existing.Status = c.Status
+	}
+	c.Status = existing.Status
+	return nil
+}
+
+func (e *memoryEditor) DeleteCourse(courseID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, c := range e.courses {
+		if c.ID == courseID {
+			e.courses = slices.Delete(e.courses, i, i+1)
+			return nil
+		}
+	}
+
+	return course.ErrCourseNotFound
+}
+
+func (e *memoryEditor) ReorderCourses(courseIDs []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	reordered, err := reorderByID(e.courses, courseIDs, func(c *course.Course) string { return c.ID })
+	if err != nil {
+		return err
+	}
+
+	e.courses = reordered
+	return nil
+}
+
+func (e *memoryEditor) CreateLesson(courseID string, lesson *course.Lesson) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if problems := course.ValidateLesson(lesson); len(problems) > 0 {
+		return &course.ValidationError{Problems: problems}
+	}
+	c, err := e.findCourse(courseID)
+	if err != nil {
+		return err
+	}
+	if _, err := findLesson(c, lesson.ID); err == nil {
+		return fmt.Errorf("%w: lesson %s", course.ErrDuplicateID, lesson.ID)
+	}
+
+	if lesson.Status == "" {
+		lesson.Status = course.StatusDraft
+	}
+	c.Lessons = append(c.Lessons, *lesson)
+	return nil
+}
+
+func (e *memoryEditor) UpdateLesson(courseID, lessonID string, lesson *course.Lesson) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	lesson.ID = lessonID
+	if problems := course.ValidateLesson(lesson); len(problems) > 0 {
+		return &course.ValidationError{Problems: problems}
+	}
+	existing, err := e.findLesson(courseID, lessonID)
+	if err != nil {
+		return err
+	}
+
+	existing.Title, existing.Description = lesson.Title, lesson.Description
+	if lesson.Status != "" {
+		existing.Status = lesson.Status
+	}
+	lesson.Status = existing.Status
+	return nil
+}
+
+func (e *memoryEditor) DeleteLesson(courseID, lessonID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, err := e.findCourse(courseID)
+	if err != nil {
+		return err
+	}
+	for i := range c.Lessons {
+		if c.Lessons[i].ID == lessonID {
+			c.Lessons = slices.Delete(c.Lessons, i, i+1)
+			return nil
+		}
+	}
+
+	return course.ErrLessonNotFound
+}
+
+func (e *memoryEditor) ReorderLessons(courseID string, lessonIDs []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, err := e.findCourse(courseID)
+	if err != nil {
+		return err
+	}
+	reordered, err := reorderByID(c.Lessons, lessonIDs, func(l course.Lesson) string { return l.ID })
+	if err != nil {
+		return err
+	}
+
+	c.Lessons = reordered
+	return nil
+}
+
+func (e *memoryEditor) CreateExercise(courseID, lessonID string, exercise *course.Exercise) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if problems := course.ValidateExercise(exercise); len(problems) > 0 {
+		return &course.ValidationError{Problems: []course.Problem{{Exercise: exercise.ID, Message: problems[0]}}}
+	}
+	lesson, err := e.findLesson(courseID, lessonID)
+	if err != nil {
+		return err
+	}
+	if _, err := findExercise(lesson, exercise.ID); err == nil {
+		return fmt.Errorf("%w: exercise %s", course.ErrDuplicateID, exercise.ID)
+	}
+
+	lesson.Exercises = append(lesson.Exercises, *exercise)
+	return nil
+}
+
+func (e *memoryEditor) UpdateExercise(courseID, lessonID, exerciseID string, exercise *course.Exercise) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	exercise.ID = exerciseID
+	if problems := course.ValidateExercise(exercise); len(problems) > 0 {
+		return &course.ValidationError{Problems: []course.Problem{{Exercise: exercise.ID, Message: problems[0]}}}
+	}
+	lesson, err := e.findLesson(courseID, lessonID)
+	if err != nil {
+		return err
+	}
+	existing, err := findExercise(lesson, exerciseID)
+	if err != nil {
+		return err
+	}
+
+	*existing = *exercise
+	return nil
+}
+
+func (e *memoryEditor) DeleteExercise(courseID, lessonID, exerciseID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	lesson, err := e.findLesson(courseID, lessonID)
+	if err != nil {
+		return err
+	}
+	for i := range lesson.Exercises {
+		if lesson.Exercises[i].ID == exerciseID {
+			lesson.Exercises = slices.Delete(lesson.Exercises, i, i+1)
+			return nil
+		}
+	}
+
+	return course.ErrExerciseNotFound
+}
+
+func (e *memoryEditor) ReorderExercises(courseID, lessonID string, exerciseIDs []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	lesson, err := e.findLesson(courseID, lessonID)
+	if err != nil {
+		return err
+	}
+	reordered, err := reorderByID(lesson.Exercises, exerciseIDs, func(ex course.Exercise) string { return ex.ID })
+	if err != nil {
+		return err
+	}
+
+	lesson.Exercises = reordered
+	return nil
+}
+
+func (e *memoryEditor) ImportCourse(c *course.Course, status course.Status) error {
+	return fmt.Errorf("importing isn't supported by the test editor")
+}
+
+func (e *memoryEditor) findCourse(courseID string) (*course.Course, error) {
+	for _, c := range e.courses {
+		if c.ID == courseID {
+			return c, nil
+		}
+	}
+
+	return nil, course.ErrCourseNotFound
+}
+
+func (e *memoryEditor) findLesson(courseID, lessonID string) (*course.Lesson, error) {
+	c, err := e.findCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return findLesson(c, lessonID)
+}
+
+func findLesson(c *course.Course, lessonID string) (*course.Lesson, error) {
+	for i := range c.Lessons {
+		if c.Lessons[i].ID == lessonID {
+			return &c.Lessons[i], nil
+		}
+	}
+
+	return nil, course.ErrLessonNotFound
+}
+
+func findExercise(lesson *course.Lesson, exerciseID string) (*course.Exercise, error) {
+	for i := range lesson.Exercises {
+		if lesson.Exercises[i].ID == exerciseID {
+			return &lesson.Exercises[i], nil
+		}
+	}
+
+	return nil, course.ErrExerciseNotFound
+}
+
+// reorderByID puts items in the order of ids, which must list every item exactly once
+func reorderByID[T any](items []T, ids []string, id func(T) string) ([]T, error) {
+	if len(ids) != len(items) {
+		return nil, course.ErrInvalidOrder
+	}
+
+	byID := make(map[string]T, len(items))
+	for _, item := range items {
+		byID[id(item)] = item
+	}
+
+	reordered := make([]T, 0, len(items))
+	for _, itemID := range ids {
+		item, ok := byID[itemID]
+		if !ok {
+			return nil, course.ErrInvalidOrder
+		}
+		delete(byID, itemID)
+		reordered = append(reordered, item)
+	}
+
+	return reordered, nil
+}
+
+func TestAuthoring(t *testing.T) {
+	jsonStore := course.NewJSONStore("../data")
+	if err := jsonStore.LoadCourseDir(); err != nil {
+		t.Fatalf("Failed to load courses: %v", err)
+	}
+	editor := &memoryEditor{Service: jsonStore}
+	server := setupTestServerWithCourses(editor)
+
+	instructorToken := signInAs(t, server, "instructor", user.RoleInstructor)
+	studentToken := signInAs(t, server, "student", user.RoleStudent)
+
+	getCourse := func(t *testing.T, courseID string) course.Course {
+		t.Helper()
+
+		rr := serve(server, http.MethodGet, "/api/authoring/courses/"+courseID, instructorToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		var c course.Course
+		if err := json.Unmarshal(rr.Body.Bytes(), &c); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return c
+	}
+
+	t.Run("Students Are Forbidden", func(t *testing.T) {
+		requests := []struct{ method, path string }{
+			{http.MethodGet, "/api/authoring/courses"},
+			{http.MethodPost, "/api/authoring/courses"},
+			{http.MethodPut, "/api/authoring/courses/go/lessons/intro"},
+			{http.MethodDelete, "/api/authoring/courses/go/lessons/intro/exercises/q1"},
+		}
+		for _, req := range requests {
+			if rr := serve(server, req.method, req.path, studentToken, nil); rr.Code != http.StatusForbidden {
+				t.Errorf("%s %s: got status %v, want %v", req.method, req.path, rr.Code, http.StatusForbidden)
+			}
+		}
+	})
+
+	t.Run("Create Course", func(t *testing.T) {
+		rr := serve(server, http.MethodPost, "/api/authoring/courses", instructorToken, course.Course{ID: "go", Name: "Go"})
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		var created course.Course
+		if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if created.Status != course.StatusDraft {
+			t.Errorf("expected new courses to be drafts, got %q", created.Status)
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/authoring/courses", instructorToken, course.Course{ID: "go", Name: "Go again"}); rr.Code != http.StatusConflict {
+			t.Errorf("expected a duplicate ID to conflict, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPost, "/api/authoring/courses", instructorToken, course.Course{ID: "nameless"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected invalid content to be rejected, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPost, "/api/authoring/courses", instructorToken, course.Course{ID: "rust", Name: "Rust"}); rr.Code != http.StatusCreated {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Update Course", func(t *testing.T) {
+		rr := serve(server, http.MethodPut, "/api/authoring/courses/go", instructorToken, course.Course{Name: "Go", Status: course.StatusPublished})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		rr = serve(server, http.MethodPut, "/api/authoring/courses/go", instructorToken, course.Course{Name: "Go", Description: "Fixed a typo"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if c := getCourse(t, "go"); c.Status != course.StatusPublished || c.Description != "Fixed a typo" {
+			t.Errorf("expected the description to change and the status to stay, got %+v", c)
+		}
+
+		if rr := serve(server, http.MethodPut, "/api/authoring/courses/missing", instructorToken, course.Course{Name: "Missing"}); rr.Code != http.StatusNotFound {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Reorder Courses", func(t *testing.T) {
+		if rr := serve(server, http.MethodPut, "/api/authoring/course-order", instructorToken, api.ReorderRequest{IDs: []string{"rust"}}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected an incomplete order to be rejected, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPut, "/api/authoring/course-order", instructorToken, api.ReorderRequest{IDs: []string{"rust", "go"}}); rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		rr := serve(server, http.MethodGet, "/api/authoring/courses", instructorToken, nil)
+		var courses []course.Course
+		if err := json.Unmarshal(rr.Body.Bytes(), &courses); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(courses) != 2 || courses[0].ID != "rust" || courses[1].ID != "go" {
+			t.Errorf("unexpected course order: %+v", courses)
+		}
+	})
+
+	t.Run("Lessons", func(t *testing.T) {
+		for _, lessonID := range []string{"intro", "loops"} {
+			rr := serve(server, http.MethodPost, "/api/authoring/courses/go/lessons", instructorToken, course.Lesson{ID: lessonID, Title: lessonID})
+			if rr.Code != http.StatusCreated {
+				t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+			}
+		}
+		if rr := serve(server, http.MethodPost, "/api/authoring/courses/go/lessons", instructorToken, course.Lesson{ID: "untitled"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected invalid content to be rejected, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPost, "/api/authoring/courses/missing/lessons", instructorToken, course.Lesson{ID: "intro", Title: "Intro"}); rr.Code != http.StatusNotFound {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusNotFound)
+		}
+
+		rr := serve(server, http.MethodPut, "/api/authoring/courses/go/lessons/intro", instructorToken, course.Lesson{Title: "Introduction"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		if rr := serve(server, http.MethodPut, "/api/authoring/courses/go/lesson-order", instructorToken, api.ReorderRequest{IDs: []string{"loops", "loops"}}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected a repeated ID to be rejected, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPut, "/api/authoring/courses/go/lesson-order", instructorToken, api.ReorderRequest{IDs: []string{"loops", "intro"}}); rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		lessons := getCourse(t, "go").Lessons
+		if len(lessons) != 2 || lessons[0].ID != "loops" || lessons[1].Title != "Introduction" {
+			t.Errorf("unexpected lessons: %+v", lessons)
+		}
+	})
+
+	t.Run("Exercises", func(t *testing.T) {
+		const path = "/api/authoring/courses/go/lessons/intro/exercises"
+		exercises := []course.Exercise{
+			{ID: "q1", Type: course.ExerciseTypeTrueFalse, Question: "True?", CorrectAnswer: true},
+			{ID: "q2", Type: course.ExerciseTypeFillBlank, Question: "Say hi", CorrectAnswer: "hi"},
+		}
+		for _, exercise := range exercises {
+			if rr := serve(server, http.MethodPost, path, instructorToken, exercise); rr.Code != http.StatusCreated {
+				t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+			}
+		}
+		if rr := serve(server, http.MethodPost, path, instructorToken, exercises[0]); rr.Code != http.StatusConflict {
+			t.Errorf("expected a duplicate ID to conflict, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPost, path, instructorToken, course.Exercise{ID: "q3", Type: course.ExerciseTypeTrueFalse}); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected invalid content to be rejected, got status %v", rr.Code)
+		}
+
+		rr := serve(server, http.MethodPut, path+"/q1", instructorToken, course.Exercise{Type: course.ExerciseTypeTrueFalse, Question: "False?", CorrectAnswer: false})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodPut, path+"/missing", instructorToken, exercises[0]); rr.Code != http.StatusNotFound {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusNotFound)
+		}
+
+		if rr := serve(server, http.MethodPut, "/api/authoring/courses/go/lessons/intro/exercise-order", instructorToken, api.ReorderRequest{IDs: []string{"q2", "q1"}}); rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		lesson := getCourse(t, "go").Lessons[1]
+		if len(lesson.Exercises) != 2 || lesson.Exercises[0].ID != "q2" || lesson.Exercises[1].Question != "False?" {
+			t.Errorf("unexpected exercises: %+v", lesson.Exercises)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		deletes := []string{
+			"/api/authoring/courses/go/lessons/intro/exercises/q1",
+			"/api/authoring/courses/go/lessons/intro",
+			"/api/authoring/courses/go",
+		}
+		for _, path := range deletes {
+			if rr := serve(server, http.MethodDelete, path, instructorToken, nil); rr.Code != http.StatusNoContent {
+				t.Fatalf("DELETE %s: got status %v, response: %s", path, rr.Code, rr.Body.String())
+			}
+			if rr := serve(server, http.MethodDelete, path, instructorToken, nil); rr.Code != http.StatusNotFound {
+				t.Errorf("DELETE %s again: got status %v, want %v", path, rr.Code, http.StatusNotFound)
+			}
+		}
+	})
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+const (
+	DefaultIdentityCacheSize = 10000
+	DefaultIdentityCacheTTL  = 30 * time.Second
+)
+
+// identity is who a session token belongs to, as DbAuthMiddleware puts it in the request context
+type identity struct {
+	session  session.Session
+	username string
+	role     user.Role
+	location *time.Location
+}
+
+type identityEntry struct {
+	token     string
+	identity  identity
+	expiresAt time.Time
+}
+
+// identityLookup is handed out on a cache miss and passed back to add once the identity is loaded,
+// so an identity read before an invalidation isn't cached after it
+type identityLookup struct {
+	generation uint64
+	startedAt  time.Time
+}
+
+// invalidation records when a token or user was last invalidated
+type invalidation struct {
+	token      string
+	userID     int
+	generation uint64
+	at         time.Time
+}
+
+// identityCache remembers which user a session token belongs to, so authenticated requests don't each
+// read the session and user from the database. It holds at most maxEntries tokens, dropping the least
+// recently used, and forgets each one after ttl so changes made by other instances are picked up.
+// A nil cache is disabled.
+type identityCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element // keyed by token
+	byUser     map[int]map[string]struct{}
+	order      *list.List // most recently used first
+
+	// generation counts invalidations. Each token and user keeps the generation it was last invalidated
+	// at, so only lookups of that token or user that started earlier are kept out of the cache. Lookups
+	// older than ttl are never cached, so invalidations are forgotten after that long.
+	generation       uint64
+	tokenGenerations map[string]uint64
+	userGenerations  map[int]uint64
+	clearedAt        uint64
+	invalidations    *list.List // oldest first
+}
+
+func newIdentityCache(maxEntries int, ttl time.Duration) *identityCache {
+	if maxEntries <= 0 || ttl <= 0 {
+		return nil
+	}
+
+	return &identityCache{
+		ttl:              ttl,
+		maxEntries:       maxEntries,
+		entries:          make(map[string]*list.Element),
+		byUser:           make(map[int]map[string]struct{}),
+		order:            list.New(),
+		tokenGenerations: make(map[string]uint64),
+		userGenerations:  make(map[int]uint64),
+		invalidations:    list.New(),
+	}
+}
+
+// get returns the identity cached for a token. On a miss the lookup is for passing to add.
+func (c *identityCache) get(token string) (identity, identityLookup, bool) {
+	if c == nil {
+		return identity{}, identityLookup{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	lookup := identityLookup{generation: c.generation, startedAt: now}
+
+	element, ok := c.entries[token]
+	if !ok {
+		return identity{}, lookup, false
+	}
+
+	entry := element.Value.(*identityEntry)
+	if !now.Before(entry.expiresAt) {
+		c.remove(element)
+		return identity{}, lookup, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.identity, lookup, true
+}
+
+// add caches a token's identity, unless the token or its user was invalidated since the lookup started
+func (c *identityCache) add(token string, id identity, lookup identityLookup) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.forgetInvalidations(now)
+	if now.Sub(lookup.startedAt) >= c.ttl || c.clearedAt > lookup.generation ||
+		c.tokenGenerations[token] > lookup.generation || c.userGenerations[id.session.UserID] > lookup.generation {
+		return
+	}
+
+	if element, ok := c.entries[token]; ok {
+		c.remove(element)
+	}
+
+	entry := &identityEntry{token: token, identity: id, expiresAt: now.Add(c.ttl)}
+	c.entries[token] = c.order.PushFront(entry)
+	if c.byUser[id.session.UserID] == nil {
+		c.byUser[id.session.UserID] = make(map[string]struct{})
+	}
+	c.byUser[id.session.UserID][token] = struct{}{}
+
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// updateSession changes the cached copy of a session after it was written, such as a new last seen time
+func (c *identityCache) updateSession(token string, update func(*session.Session)) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[token]; ok {
+		update(&element.Value.(*identityEntry).identity.session)
+	}
+}
+
+// removeToken forgets one session, for when it is signed out
+func (c *identityCache) removeToken(token string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(invalidation{token: token})
+	if element, ok := c.entries[token]; ok {
+		c.remove(element)
+	}
+}
+
+// removeUser forgets every session of a user, for when their sessions are ended or the user changes
+func (c *identityCache) removeUser(userID int) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(invalidation{userID: userID})
+	for token := range c.byUser[userID] {
+		c.remove(c.entries[token])
+	}
+}
+
+// clear forgets everything, for when sessions were revoked without knowing whose they were
+func (c *identityCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.clearedAt = c.generation
+	c.entries = make(map[string]*list.Element)
+	c.byUser = make(map[int]map[string]struct{})
+	c.order.Init()
+}
+
+// invalidate records that a token or user changed. The caller holds the lock.
+func (c *identityCache) invalidate(inv invalidation) {
+	now := time.Now()
+	c.forgetInvalidations(now)
+
+	c.generation++
+	inv.generation = c.generation
+	inv.at = now
+	if inv.token != "" {
+		c.tokenGenerations[inv.token] = inv.generation
+	} else {
+		c.userGenerations[inv.userID] = inv.generation
+	}
+	c.invalidations.PushBack(inv)
+}
+
+// forgetInvalidations drops invalidations older than any lookup that could still be cached. The caller holds the lock.
+func (c *identityCache) forgetInvalidations(now time.Time) {
+	for front := c.invalidations.Front(); front != nil; front = c.invalidations.Front() {
+		inv := front.Value.(invalidation)
+		if now.Sub(inv.at) < c.ttl {
+			return
+		}
+
+		c.invalidations.Remove(front)
+		if inv.token != "" {
+			if c.tokenGenerations[inv.token] == inv.generation {
+				delete(c.tokenGenerations, inv.token)
+			}
+		} else if c.userGenerations[inv.userID] == inv.generation {
+			delete(c.userGenerations, inv.userID)
+		}
+	}
+}
+
+// remove drops an entry. The caller holds the lock.
+func (c *identityCache) remove(element *list.Element) {
+	entry := element.Value.(*identityEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.token)
+
+	userID := entry.identity.session.UserID
+	delete(c.byUser[userID], entry.token)
+	if len(c.byUser[userID]) == 0 {
+		delete(c.byUser, userID)
+	}
+}
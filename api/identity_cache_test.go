@@ -0,0 +1,115 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/session"
+)
+
+func testIdentity(userID int) identity {
+	return identity{session: session.Session{UserID: userID}, location: time.UTC}
+}
+
+func TestIdentityCacheInvalidation(t *testing.T) {
+	cache := newIdentityCache(10, time.Minute)
+
+	t.Run("Other Tokens Stay Cacheable", func(t *testing.T) {
+		_, lookup, _ := cache.get("a")
+		cache.removeToken("b")
+		cache.removeUser(2)
+		cache.add("a", testIdentity(1), lookup)
+
+		if _, _, ok := cache.get("a"); !ok {
+			t.Error("expected invalidating other tokens and users not to stop a lookup being cached")
+		}
+	})
+
+	t.Run("Stale Lookups Are Dropped", func(t *testing.T) {
+		_, lookup, _ := cache.get("c")
+		cache.removeToken("c")
+		cache.add("c", testIdentity(3), lookup)
+		if _, _, ok := cache.get("c"); ok {
+			t.Error("expected a lookup that raced a logout not to be cached")
+		}
+
+		_, lookup, _ = cache.get("d")
+		cache.removeUser(4)
+		cache.add("d", testIdentity(4), lookup)
+		if _, _, ok := cache.get("d"); ok {
+			t.Error("expected a lookup that raced a user change not to be cached")
+		}
+
+		_, lookup, _ = cache.get("d")
+		cache.add("d", testIdentity(4), lookup)
+		if _, _, ok := cache.get("d"); !ok {
+			t.Error("expected a lookup after the invalidation to be cached")
+		}
+	})
+
+	t.Run("Update Session", func(t *testing.T) {
+		lastSeenAt := time.Now()
+		cache.updateSession("a", func(cached *session.Session) { cached.LastSeenAt = lastSeenAt })
+
+		id, _, ok := cache.get("a")
+		if !ok || !id.session.LastSeenAt.Equal(lastSeenAt) {
+			t.Errorf("expected the cached session to be updated in place, got %+v", id.session)
+		}
+	})
+
+	t.Run("Remove User", func(t *testing.T) {
+		_, lookup, _ := cache.get("e")
+		cache.add("e", testIdentity(1), lookup)
+		cache.removeUser(1)
+
+		for _, token := range []string{"a", "e"} {
+			if _, _, ok := cache.get(token); ok {
+				t.Errorf("expected %s to be removed with its user", token)
+			}
+		}
+	})
+}
+
+func TestIdentityCacheBounds(t *testing.T) {
+	t.Run("Least Recently Used Is Dropped", func(t *testing.T) {
+		cache := newIdentityCache(2, time.Minute)
+		for i, token := range []string{"a", "b"} {
+			_, lookup, _ := cache.get(token)
+			cache.add(token, testIdentity(i), lookup)
+		}
+		cache.get("a")
+
+		_, lookup, _ := cache.get("c")
+		cache.add("c", testIdentity(3), lookup)
+
+		if _, _, ok := cache.get("b"); ok {
+			t.Error("expected the least recently used token to be dropped")
+		}
+		if _, _, ok := cache.get("a"); !ok {
+			t.Error("expected a recently used token to stay")
+		}
+	})
+
+	t.Run("Entries Expire", func(t *testing.T) {
+		cache := newIdentityCache(2, 20*time.Millisecond)
+		_, lookup, _ := cache.get("a")
+		cache.add("a", testIdentity(1), lookup)
+
+		time.Sleep(30 * time.Millisecond)
+		if _, _, ok := cache.get("a"); ok {
+			t.Error("expected the entry to expire after its TTL")
+		}
+		if len(cache.entries) != 0 || len(cache.byUser) != 0 {
+			t.Errorf("expected the expired entry to be removed, got %d entries", len(cache.entries))
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		cache := newIdentityCache(0, time.Minute)
+		_, lookup, _ := cache.get("a")
+		cache.add("a", testIdentity(1), lookup)
+		if _, _, ok := cache.get("a"); ok {
+			t.Error("expected a disabled cache to keep nothing")
+		}
+	})
+}
@@ -3,17 +3,42 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/services/progress"
 )
 
 type CourseInfo struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	Description  string `json:"description"`
-	LessonAmount int    `json:"lessonAmount"`
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	LessonAmount     int               `json:"lessonAmount"`
+	Difficulty       course.Difficulty `json:"difficulty,omitempty"`
+	EstimatedMinutes int               `json:"estimatedMinutes,omitempty"`
+	Tags             []string          `json:"tags"`
+	CoverImage       string            `json:"coverImage,omitempty"`
 }
 
 type ListCoursesResponse []CourseInfo
 
+type RevealAnswerResponse struct {
+	Answer interface{} `json:"answer"`
+}
+
+type HintResponse struct {
+	Hint       string `json:"hint"`
+	HintTokens int    `json:"hintTokens"` // How many the user has left
+}
+
+// LockedResponse is returned with 403 when a lesson's prerequisites haven't been completed,
+// or when a course is completed before its prerequisites and lessons are
+type LockedResponse struct {
+	Error    string         `json:"error"`
+	CourseID string         `json:"courseId"`
+	LessonID string         `json:"lessonId,omitempty"`
+	Missing  course.Missing `json:"missing"`
+}
+
 // GET /api/courses
 func (s *Server) handleListCourses() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -29,11 +54,20 @@ func (s *Server) handleListCourses() http.HandlerFunc {
 		response := make([]CourseInfo, 0)
 
 		for _, course := range courses {
+			tags := course.Tags
+			if tags == nil {
+				tags = make([]string, 0)
+			}
+
 			response = append(response, CourseInfo{
-				ID:           course.ID,
-				Name:         course.Name,
-				Description:  course.Description,
-				LessonAmount: len(course.Lessons),
+				ID:               course.ID,
+				Name:             course.Name,
+				Description:      course.Description,
+				LessonAmount:     len(course.Lessons),
+				Difficulty:       course.Difficulty,
+				EstimatedMinutes: course.TotalMinutes(),
+				Tags:             tags,
+				CoverImage:       course.CoverImage,
 			})
 		}
 
@@ -56,16 +90,29 @@ func (s *Server) handleGetCourse() http.HandlerFunc {
 			return
 		}
 
-		course, err := s.CourseService.GetCourseByID(courseID)
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		c, err := s.CourseService.GetCourseByID(courseID)
 		if err != nil {
 			s.logger.Warn("Error fetching course by courseID", "courseID", courseID, "error", err)
 			http.Error(w, "Failed to get course", http.StatusNotFound)
 			return
 		}
 
-		s.logger.Debug("Course found", "course", course.Name)
+		done, err := s.completion(userID)
+		if err != nil {
+			s.logger.Error("Failed to get completed lessons", "error", err)
+			http.Error(w, "Failed to get course", http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.Debug("Course found", "course", c.Name)
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(course); err != nil {
+		if err := json.NewEncoder(w).Encode(course.NewCourseView(userID, c, done)); err != nil {
 			s.logger.Error("Error encoding response for course", "courseID", courseID, "error", err)
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
@@ -86,6 +133,12 @@ func (s *Server) handleGetLesson() http.HandlerFunc {
 			return
 		}
 
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
 		lesson, err := s.CourseService.GetLessonByID(courseID, lessonID)
 		if err != nil {
 			s.logger.Debug("Lesson not found", "courseID", courseID, "lessonID", lessonID)
@@ -93,11 +146,222 @@ func (s *Server) handleGetLesson() http.HandlerFunc {
 			return
 		}
 
+		if !s.requireUnlocked(w, userID, courseID, lessonID) {
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(lesson); err != nil {
+		if err := json.NewEncoder(w).Encode(course.NewLessonView(userID, courseID, lesson)); err != nil {
 			s.logger.Error("Failed to encode get lesson response", "error", err)
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
 	}
 }
+
+// POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/reveal
+func (s *Server) handleRevealAnswer() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseID := r.PathValue("courseID")
+		lessonID := r.PathValue("lessonID")
+		exerciseID := r.PathValue("exerciseID")
+
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		if !s.requireUnlocked(w, userID, courseID, lessonID) {
+			return
+		}
+
+		exercise, err := s.CourseService.GetExerciseByID(courseID, lessonID, exerciseID)
+		if err != nil {
+			http.Error(w, "Exercise not found", http.StatusNotFound)
+			return
+		}
+
+		// Remember the reveal first so the answer can't be seen without it counting
+		if err := s.PointsService.MarkAnswerRevealed(userID, courseID, lessonID, exerciseID); err != nil {
+			s.logger.Error("Failed to record revealed answer", "error", err)
+			http.Error(w, "Failed to reveal answer", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(RevealAnswerResponse{Answer: course.RevealedAnswer(exercise)}); err != nil {
+			s.logger.Error("Failed to encode reveal response", "error", err)
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/hint
+func (s *Server) handleUseHint() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseID := r.PathValue("courseID")
+		lessonID := r.PathValue("lessonID")
+		exerciseID := r.PathValue("exerciseID")
+
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		if !s.requireUnlocked(w, userID, courseID, lessonID) {
+			return
+		}
+
+		exercise, err := s.CourseService.GetExerciseByID(courseID, lessonID, exerciseID)
+		if err != nil {
+			http.Error(w, "Exercise not found", http.StatusNotFound)
+			return
+		}
+
+		// Checked before spending a token so one isn't wasted on an exercise without a hint
+		hint, ok := course.Hint(exercise)
+		if !ok {
+			http.Error(w, "Exercise has no hint", http.StatusBadRequest)
+			return
+		}
+
+		// Unlike a reveal, a hint gives away only part of the answer, so it still earns points
+		left, err := s.ShopService.UseHintToken(userID)
+		if err != nil {
+			s.writeShopError(w, "Failed to use hint token", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(HintResponse{Hint: hint, HintTokens: left}); err != nil {
+			s.logger.Error("Failed to encode hint response", "error", err)
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// translateAnswer maps an answer given against the shuffled lesson view back onto the answer key
+func (s *Server) translateAnswer(userID int, courseID, lessonID, exerciseID string, answer interface{}) (interface{}, error) {
+	exercise, err := s.CourseService.GetExerciseByID(courseID, lessonID, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	shuffle := course.NewShuffle(userID, courseID, lessonID, exercise)
+	return shuffle.TranslateAnswer(exercise, answer)
+}
+
+// GET /api/courses/{courseID}/map
+func (s *Server) handleGetCourseMap() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courseID := r.PathValue("courseID")
+
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		c, err := s.CourseService.GetCourseByID(courseID)
+		if err != nil {
+			http.Error(w, "Course not found", http.StatusNotFound)
+			return
+		}
+
+		done, err := s.completion(userID)
+		if err != nil {
+			s.logger.Error("Failed to get completed lessons", "error", err)
+			http.Error(w, "Failed to get course map", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(course.NewCourseMap(c, done)); err != nil {
+			s.logger.Error("Failed to encode course map response", "error", err)
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// completion collects the courses and lessons a user has completed
+func (s *Server) completion(userID int) (*course.Completion, error) {
+	done := course.NewCompletion()
+
+	courses, err := s.ProgressService.ListCourseProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range courses {
+		if p.Status == progress.StatusCompleted {
+			done.AddCourse(p.CourseID)
+		}
+	}
+
+	lessons, err := s.ProgressService.ListLessonProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range lessons {
+		if p.Status == progress.StatusCompleted {
+			done.AddLesson(p.CourseID, p.LessonID)
+		}
+	}
+
+	return done, nil
+}
+
+// requireUnlocked checks that the user has completed everything a lesson requires. Otherwise it writes
+// a locked (or not found) response and returns false.
+func (s *Server) requireUnlocked(w http.ResponseWriter, userID int, courseID, lessonID string) bool {
+	c, err := s.CourseService.GetCourseByID(courseID)
+	if err != nil {
+		http.Error(w, "Course not found", http.StatusNotFound)
+		return false
+	}
+
+	lesson, err := s.CourseService.GetLessonByID(courseID, lessonID)
+	if err != nil {
+		http.Error(w, "Lesson not found", http.StatusNotFound)
+		return false
+	}
+
+	// Skip the progress lookup for lessons without prerequisites
+	if len(c.Prerequisites) == 0 && len(lesson.Prerequisites) == 0 {
+		return true
+	}
+
+	done, err := s.completion(userID)
+	if err != nil {
+		s.logger.Error("Failed to get completed lessons", "error", err)
+		http.Error(w, "Failed to check prerequisites", http.StatusInternalServerError)
+		return false
+	}
+
+	missing := course.MissingForLesson(c, lesson, done)
+	if missing.Empty() {
+		return true
+	}
+
+	s.logger.Debug("Lesson is locked", "userID", userID, "courseID", courseID, "lessonID", lessonID)
+	s.writeLocked(w, courseID, lessonID, missing)
+	return false
+}
+
+// writeLocked responds with 403 and what still has to be completed
+func (s *Server) writeLocked(w http.ResponseWriter, courseID, lessonID string, missing course.Missing) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	if err := json.NewEncoder(w).Encode(LockedResponse{
+		Error:    "locked",
+		CourseID: courseID,
+		LessonID: lessonID,
+		Missing:  missing,
+	}); err != nil {
+		s.logger.Error("Failed to encode locked response", "error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestLessonPrerequisites(t *testing.T) {
+	server := setupTestServer(t)
+	token := signInAs(t, server, "student", user.RoleStudent)
+
+	const lockedPath = "/api/courses/algorithms/lessons/dynamic-programming"
+
+	t.Run("Locked Until Prerequisites Are Completed", func(t *testing.T) {
+		for _, path := range []string{lockedPath, lockedPath + "/complete", lockedPath + "/exercises/dp_1/attempt"} {
+			method := http.MethodPost
+			if path == lockedPath {
+				method = http.MethodGet
+			}
+
+			rr := serve(server, method, path, token, map[string]any{"answer": 0})
+			if rr.Code != http.StatusForbidden {
+				t.Fatalf("%s %s: got status %v, want %v", method, path, rr.Code, http.StatusForbidden)
+			}
+
+			var response api.LockedResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Error != "locked" || !slices.Equal(response.Missing.Lessons, []string{"recursion"}) {
+				t.Errorf("unexpected locked response: %+v", response)
+			}
+		}
+	})
+
+	t.Run("Course Leaves Out Locked Exercises", func(t *testing.T) {
+		rr := serve(server, http.MethodGet, "/api/courses/algorithms", token, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var view course.CourseView
+		if err := json.NewDecoder(rr.Body).Decode(&view); err != nil {
+			t.Fatal(err)
+		}
+		for _, lesson := range view.Lessons {
+			if lesson.ID == "dynamic-programming" && (!lesson.Locked || len(lesson.Exercises) > 0) {
+				t.Errorf("expected the locked lesson without exercises, got %+v", lesson)
+			}
+		}
+	})
+
+	t.Run("Course Map", func(t *testing.T) {
+		if rr := serve(server, http.MethodPost, "/api/courses/algorithms/lessons/recursion/complete", token, nil); rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		rr := serve(server, http.MethodGet, "/api/courses/algorithms/map", token, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var courseMap course.CourseMap
+		if err := json.NewDecoder(rr.Body).Decode(&courseMap); err != nil {
+			t.Fatal(err)
+		}
+
+		states := make(map[string]course.LessonState)
+		for _, lesson := range courseMap.Lessons {
+			states[lesson.ID] = lesson.State
+		}
+		if states["introduction"] != course.LessonAvailable || states["recursion"] != course.LessonCompleted || states["dynamic-programming"] != course.LessonAvailable {
+			t.Errorf("unexpected lesson states: %v", states)
+		}
+
+		if rr := serve(server, http.MethodGet, lockedPath, token, nil); rr.Code != http.StatusOK {
+			t.Errorf("expected unlocked lesson, got status %v", rr.Code)
+		}
+	})
+
+	t.Run("Course Completion Requires Every Lesson", func(t *testing.T) {
+		const completePath = "/api/courses/algorithms/complete"
+
+		rr := serve(server, http.MethodPost, completePath, token, nil)
+		if rr.Code != http.StatusForbidden {
+			t.Fatalf("got status %v, want %v", rr.Code, http.StatusForbidden)
+		}
+		var response api.LockedResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(response.Missing.Lessons, "introduction") || slices.Contains(response.Missing.Lessons, "recursion") {
+			t.Errorf("expected only the incomplete lessons to be missing, got %+v", response.Missing)
+		}
+
+		for _, lessonID := range response.Missing.Lessons {
+			if rr := serve(server, http.MethodPost, "/api/courses/algorithms/lessons/"+lessonID+"/complete", token, nil); rr.Code != http.StatusOK {
+				t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+			}
+		}
+		if rr := serve(server, http.MethodPost, completePath, token, nil); rr.Code != http.StatusOK {
+			t.Errorf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+	})
+}
@@ -1,11 +1,18 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/tylerolson/capstone-backend/services/idempotency"
+	"github.com/tylerolson/capstone-backend/services/session"
 	"github.com/tylerolson/capstone-backend/services/user"
 )
 
@@ -15,6 +22,8 @@ const (
 	userIDKey   contextKey = "userID"
 	tokenKey    contextKey = "token"
 	usernameKey contextKey = "username" // Add username key for profile pic handlers
+	roleKey     contextKey = "role"
+	locationKey contextKey = "location"
 )
 
 type Middleware func(http.Handler) http.Handler
@@ -30,53 +39,214 @@ func (s *Server) DbAuthMiddleware() Middleware {
 				return
 			}
 
-			session, err := s.SessionService.GetSessionByToken(token)
-			if err != nil || session == nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return
+			id, lookup, cached := s.identities.get(token)
+			if !cached {
+				var err error
+				id, err = s.loadIdentity(token)
+				if errors.Is(err, session.ErrSessionNotFound) || errors.Is(err, user.ErrNoUser) {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				if err != nil {
+					s.logger.Error("Failed to load session identity", "error", err)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				s.identities.add(token, id, lookup)
 			}
+			userSession := &id.session
 
 			// Check if session is expired
-			if time.Now().After(session.ExpiresAt) {
+			if time.Now().After(userSession.ExpiresAt) {
 				http.Error(w, "Session expired", http.StatusUnauthorized)
 				return
 			}
 
-			// Get the username for the user ID
-			// We need this for profile picture handlers
-			user, err := s.getUsernameByID(session.UserID)
-			if err != nil {
-				s.logger.Error("Failed to get username for user ID", "userID", session.UserID, "error", err)
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
+			// With sliding sessions, a session past half its lifetime gets a full one again. Waiting until
+			// then saves a write on every request. The cached copy is kept in step with the database.
+			if s.SlidingSessions && time.Until(userSession.ExpiresAt) < session.AccessTokenLifetime/2 {
+				expiresAt := time.Now().Add(session.AccessTokenLifetime)
+				if err := s.SessionService.ExtendSession(token, expiresAt); err != nil {
+					s.logger.Error("Failed to extend session", "userID", userSession.UserID, "error", err)
+				} else {
+					s.identities.updateSession(token, func(cached *session.Session) { cached.ExpiresAt = expiresAt })
+				}
+			}
+
+			// Throttled like sliding expiry, so requests don't each write to the session
+			if time.Since(userSession.LastSeenAt) > session.LastSeenInterval {
+				if err := s.SessionService.TouchSession(token); err != nil {
+					s.logger.Error("Failed to update session last seen", "userID", userSession.UserID, "error", err)
+				} else {
+					lastSeenAt := time.Now()
+					s.identities.updateSession(token, func(cached *session.Session) { cached.LastSeenAt = lastSeenAt })
+				}
 			}
 
 			// Add user ID and username to request context
-			ctx := context.WithValue(r.Context(), userIDKey, session.UserID)
+			ctx := context.WithValue(r.Context(), userIDKey, userSession.UserID)
 			ctx = context.WithValue(ctx, tokenKey, token)
-			ctx = context.WithValue(ctx, usernameKey, user.Username)
+			ctx = context.WithValue(ctx, usernameKey, id.username)
+			ctx = context.WithValue(ctx, roleKey, id.role)
+			ctx = context.WithValue(ctx, locationKey, id.location)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// Helper function to get user by ID and return username
-func (s *Server) getUsernameByID(userID int) (*user.User, error) {
-	// Get all users (this could be optimized with a GetByID method)
-	users, err := s.UserService.List()
+// RequireRole only lets through users with one of the given roles.
+// It must be wrapped by DbAuthMiddleware, which puts the role in the context.
+func (s *Server) RequireRole(roles ...user.Role) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := s.GetRole(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			s.logger.Debug("Role not allowed", "role", role, "path", r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
+
+// IdempotencyMiddleware replays the stored response when a mutating request is retried with the same
+// Idempotency-Key header, instead of running the handler again. Keys are scoped to the user, so it
+// must be wrapped by DbAuthMiddleware. Reusing a key for a different request is a conflict.
+func (s *Server) IdempotencyMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > idempotency.MaxKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			userID, ok := s.GetUserID(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+			hash.Write(body)
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+
+			record, err := s.IdempotencyService.Begin(userID, key, requestHash)
+			if err != nil {
+				s.logger.Error("Failed to claim idempotency key", "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			if record != nil {
+				switch {
+				case record.RequestHash != requestHash:
+					http.Error(w, "Idempotency-Key was already used for a different request", http.StatusConflict)
+				case !record.Completed():
+					http.Error(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+				default:
+					s.logger.Debug("Replaying idempotent response", "userID", userID, "path", r.URL.Path)
+					if record.ContentType != "" {
+						w.Header().Set("Content-Type", record.ContentType)
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(record.StatusCode)
+					w.Write(record.Body)
+				}
+				return
+			}
+
+			// A handler that panics leaves no response to keep, so free the key for a retry
+			defer func() {
+				if p := recover(); p != nil {
+					if err := s.IdempotencyService.Release(userID, key); err != nil {
+						s.logger.Error("Failed to release idempotency key", "error", err)
+					}
+					panic(p)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// Server errors aren't kept, so the client can retry them with the same key
+			if recorder.statusCode >= http.StatusInternalServerError {
+				if err := s.IdempotencyService.Release(userID, key); err != nil {
+					s.logger.Error("Failed to release idempotency key", "error", err)
+				}
+				return
+			}
+
+			if err := s.IdempotencyService.Complete(userID, key, recorder.statusCode, w.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+				s.logger.Error("Failed to store idempotent response", "error", err)
+			}
+		})
+	}
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// loadIdentity reads who a session token belongs to from the session and user services
+func (s *Server) loadIdentity(token string) (identity, error) {
+	userSession, err := s.SessionService.GetSessionByToken(token)
 	if err != nil {
-		return nil, err
+		return identity{}, err
 	}
 
-	// Find the user with the given ID
-	for _, u := range users {
-		if u.ID == userID {
-			return u, nil
-		}
+	u, err := s.UserService.GetByID(userSession.UserID)
+	if err != nil {
+		return identity{}, err
 	}
 
-	return nil, user.ErrNoUser
+	return identity{
+		session:  *userSession,
+		username: u.Username,
+		role:     u.Role,
+		location: u.Location(),
+	}, nil
 }
 
 // GetUserID retrieves user ID from context
@@ -96,3 +266,18 @@ func (s *Server) GetUsername(ctx context.Context) (string, bool) {
 	username, ok := ctx.Value(usernameKey).(string)
 	return username, ok
 }
+
+// GetRole retrieves the user's role from context
+func (s *Server) GetRole(ctx context.Context) (user.Role, bool) {
+	role, ok := ctx.Value(roleKey).(user.Role)
+	return role, ok
+}
+
+// GetLocation retrieves the user's timezone from context, UTC if there is none
+func (s *Server) GetLocation(ctx context.Context) *time.Location {
+	if location, ok := ctx.Value(locationKey).(*time.Location); ok {
+		return location
+	}
+
+	return time.UTC
+}
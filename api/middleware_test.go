@@ -0,0 +1,231 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/user"
+	"github.com/tylerolson/capstone-backend/tokenhash"
+)
+
+func serveWithKey(server *api.Server, path, token, key string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(body)
+
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	server := setupTestServer(t)
+	token := signInAs(t, server, "student", user.RoleStudent)
+	otherToken := signInAs(t, server, "other", user.RoleStudent)
+
+	const path = "/api/courses/algorithms/lessons/introduction/exercises/algo_intro_1/attempt"
+	answer := map[string]any{"answer": 0}
+
+	attempt := func(t *testing.T, token, key string, body any) (*httptest.ResponseRecorder, api.ExerciseAttemptResponse) {
+		t.Helper()
+
+		rr := serveWithKey(server, path, token, key, body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var response api.ExerciseAttemptResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return rr, response
+	}
+
+	t.Run("Retries Are Replayed", func(t *testing.T) {
+		first, firstResponse := attempt(t, token, "retry-1", answer)
+		retry, retryResponse := attempt(t, token, "retry-1", answer)
+
+		if retry.Body.String() != first.Body.String() {
+			t.Errorf("expected the stored response, got %s want %s", retry.Body.String(), first.Body.String())
+		}
+		if retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("expected the retry to be marked as replayed")
+		}
+		if firstResponse.AttemptNumber != 1 || retryResponse.AttemptNumber != 1 {
+			t.Errorf("expected a single attempt, got %d and %d", firstResponse.AttemptNumber, retryResponse.AttemptNumber)
+		}
+	})
+
+	t.Run("New Key Is A New Attempt", func(t *testing.T) {
+		_, response := attempt(t, token, "retry-2", answer)
+		if response.AttemptNumber != 2 {
+			t.Errorf("expected attempt 2, got %d", response.AttemptNumber)
+		}
+
+		_, response = attempt(t, token, "", answer)
+		if response.AttemptNumber != 3 {
+			t.Errorf("expected attempt 3 without a key, got %d", response.AttemptNumber)
+		}
+	})
+
+	t.Run("Reused Key With Different Body", func(t *testing.T) {
+		rr := serveWithKey(server, path, token, "retry-1", map[string]any{"answer": 1})
+		if rr.Code != http.StatusConflict {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("Keys Are Per User", func(t *testing.T) {
+		_, response := attempt(t, otherToken, "retry-1", answer)
+		if response.AttemptNumber != 1 {
+			t.Errorf("expected the other user's first attempt, got %d", response.AttemptNumber)
+		}
+	})
+
+	t.Run("Panic Releases The Key", func(t *testing.T) {
+		panicking := server.DbAuthMiddleware()(server.IdempotencyMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("handler failed")
+		})))
+
+		var buf bytes.Buffer
+		json.NewEncoder(&buf).Encode(answer)
+		req := httptest.NewRequest(http.MethodPost, path, &buf)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Idempotency-Key", "panic-1")
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected the panic to reach the server")
+				}
+			}()
+			panicking.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		// The retry runs instead of waiting out a claim nobody will complete
+		attempt(t, token, "panic-1", answer)
+	})
+}
+
+func TestIdentityCache(t *testing.T) {
+	server := setupTestServer(t)
+	adminToken := signInAs(t, server, "admin", user.RoleAdmin)
+	studentToken := signInAs(t, server, "student", user.RoleStudent)
+
+	t.Run("Logout", func(t *testing.T) {
+		token := mustToken(t, server, "student")
+		if rr := serve(server, http.MethodGet, "/api/sessions", token, nil); rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/logout", token, nil); rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodGet, "/api/sessions", token, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected a cached session to end on logout, got status %v", rr.Code)
+		}
+	})
+
+	t.Run("Role Change", func(t *testing.T) {
+		if rr := serve(server, http.MethodGet, "/api/admin/users", studentToken, nil); rr.Code != http.StatusForbidden {
+			t.Fatalf("got status %v, want %v", rr.Code, http.StatusForbidden)
+		}
+
+		rr := serve(server, http.MethodPut, "/api/admin/users/student/role", adminToken, api.SetRoleRequest{Role: user.RoleAdmin})
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodGet, "/api/admin/users", studentToken, nil); rr.Code != http.StatusOK {
+			t.Errorf("expected the new role to be used, got status %v", rr.Code)
+		}
+	})
+
+	t.Run("Deleted User", func(t *testing.T) {
+		if rr := serve(server, http.MethodDelete, "/api/admin/users/student", adminToken, nil); rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodGet, "/api/sessions", studentToken, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected a deleted user's session to end, got status %v", rr.Code)
+		}
+	})
+}
+
+// BenchmarkDbAuthMiddleware authenticates one request at a time against the Postgres session and user
+// services while the users table grows. The time per request should stay about the same whatever the
+// number of users, with or without the identity cache. It is skipped without a database.
+func BenchmarkDbAuthMiddleware(b *testing.B) {
+	database := dbtest.Open(b)
+	tokenhash.SetKey([]byte("test token key"))
+
+	// Users are inserted directly, since hashing thousands of passwords would take minutes
+	prefix := dbtest.UniqueName("authbench")
+	b.Cleanup(func() {
+		database.Exec(`DELETE FROM users WHERE username LIKE $1 || '\_%'`, prefix)
+	})
+
+	userService := user.NewService(database)
+	sessionService := session.NewService(database)
+	server := api.NewServer(userService, nil, nil, sessionService, nil, nil, nil, nil, nil, nil, database, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := server.DbAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	inserted := 0
+	for _, users := range []int{100, 1000, 10000} {
+		_, err := database.Exec(`
+			INSERT INTO users (username, email, password_hash)
+			SELECT $1::text || '_' || n, $1::text || '_' || n || '@example.com', 'benchmark'
+			FROM generate_series($2::int, $3::int) AS n`,
+			prefix, inserted+1, users)
+		if err != nil {
+			b.Fatal(err)
+		}
+		inserted = users
+
+		// Sign in as the newest user, so the lookup isn't always of the first rows
+		signedIn, err := userService.Get(fmt.Sprintf("%s_%d", prefix, users))
+		if err != nil {
+			b.Fatal(err)
+		}
+		created, err := sessionService.CreateSession(signedIn.ID, session.ClientInfo{})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, cached := range []bool{true, false} {
+			name := fmt.Sprintf("users=%d/cached", users)
+			if cached {
+				server.SetIdentityCache(api.DefaultIdentityCacheSize, api.DefaultIdentityCacheTTL)
+			} else {
+				name = fmt.Sprintf("users=%d/uncached", users)
+				server.SetIdentityCache(0, 0)
+			}
+
+			b.Run(name, func(b *testing.B) {
+				req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+				req.Header.Set("Authorization", "Bearer "+created.Token)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					rr := httptest.NewRecorder()
+					handler.ServeHTTP(rr, req)
+					if rr.Code != http.StatusOK {
+						b.Fatalf("got status %v", rr.Code)
+					}
+				}
+			})
+		}
+	}
+}
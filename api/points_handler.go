@@ -1,13 +1,18 @@
 package api
 
 import (
-	"database/sql"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/services/achievements"
 	"github.com/tylerolson/capstone-backend/services/points"
 	"github.com/tylerolson/capstone-backend/services/progress"
+	"github.com/tylerolson/capstone-backend/services/user"
 )
 
 type PointsSummaryResponse struct {
@@ -17,6 +22,38 @@ type PointsSummaryResponse struct {
 	Transactions  []*points.PointTransaction `json:"recentTransactions,omitempty"`
 }
 
+// Caps on how many entries a leaderboard or activity request can ask for
+const (
+	maxLeaderboardLimit   = 100
+	maxActivityLimit      = 100
+	maxDailyActivityLimit = 366 // A year of days
+)
+
+// ActivityEntry is a point transaction along with who earned it
+type ActivityEntry struct {
+	Username       string `json:"username"`
+	ProfilePicture string `json:"profilePicture,omitempty"`
+	*points.PointTransaction
+}
+
+type LeaderboardEntry struct {
+	Rank           int    `json:"rank"`
+	UserID         int    `json:"userId"`
+	Username       string `json:"username"`
+	ProfilePicture string `json:"profilePicture,omitempty"`
+	Points         int    `json:"points"`
+}
+
+type LeaderboardResponse struct {
+	Scope    string                   `json:"scope"`
+	Period   points.LeaderboardPeriod `json:"period"`
+	CourseID string                   `json:"courseId,omitempty"`
+	Since    *time.Time               `json:"since,omitempty"`
+	Entries  []LeaderboardEntry       `json:"entries"`
+	// Me is the caller's own entry, even when they aren't in the top entries
+	Me *LeaderboardEntry `json:"me,omitempty"`
+}
+
 type LessonPointsResponse struct {
 	CourseID      string `json:"courseId"`
 	LessonID      string `json:"lessonId"`
@@ -128,12 +165,24 @@ func (s *Server) handleCompleteCourse() http.HandlerFunc {
 			return
 		}
 
-		_, err := s.CourseService.GetCourseByID(courseID)
+		c, err := s.CourseService.GetCourseByID(courseID)
 		if err != nil {
 			http.Error(w, "Course not found", http.StatusNotFound)
 			return
 		}
 
+		done, err := s.completion(userID)
+		if err != nil {
+			s.logger.Error("Failed to get completed lessons", "error", err)
+			http.Error(w, "Failed to check prerequisites", http.StatusInternalServerError)
+			return
+		}
+		if missing := course.MissingToComplete(c, done); !missing.Empty() {
+			s.logger.Debug("Course isn't ready to complete", "userID", userID, "courseID", courseID)
+			s.writeLocked(w, courseID, "", missing)
+			return
+		}
+
 		err = s.ProgressService.UpdateCourseProgress(userID, courseID, progress.StatusCompleted)
 		if err != nil {
 			s.logger.Error("Failed to update course progress", "error", err)
@@ -148,6 +197,8 @@ func (s *Server) handleCompleteCourse() http.HandlerFunc {
 			return
 		}
 
+		s.evaluateAchievements(userID, achievements.TriggerCourseCompleted, achievements.TriggerPoints)
+
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(transaction); err != nil {
 			s.logger.Error("Failed to encode transaction response", "error", err)
@@ -174,6 +225,10 @@ func (s *Server) handleCompleteLesson() http.HandlerFunc {
 			return
 		}
 
+		if !s.requireUnlocked(w, userID, courseID, lessonID) {
+			return
+		}
+
 		// First update the lesson status to completed
 		err := s.ProgressService.UpdateLessonProgress(userID, courseID, lessonID, progress.StatusCompleted)
 		if err != nil {
@@ -197,6 +252,8 @@ func (s *Server) handleCompleteLesson() http.HandlerFunc {
 			// We don't want to fail the whole request just because of streak reset failure
 		}
 
+		s.evaluateAchievements(userID, achievements.TriggerLessonCompleted, achievements.TriggerPoints)
+
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(transaction); err != nil {
 			s.logger.Error("Failed to encode transaction response", "error", err)
@@ -206,302 +263,187 @@ func (s *Server) handleCompleteLesson() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleExerciseAttemptPoints() http.HandlerFunc {
+// GET /api/leaderboard?period=weekly&courseId=...&scope=friends
+// GET /api/courses/{courseID}/leaderboard?period=weekly&scope=friends
+func (s *Server) handleGetLeaderboard() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req ExerciseAttemptRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			s.logger.Warn("Error decoding request body", "error", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		courseID := r.PathValue("courseID")
-		lessonID := r.PathValue("lessonID")
-		exerciseID := r.PathValue("exerciseID")
-
-		s.logger.Debug("Received exercise attempt with points",
-			"courseID", courseID,
-			"lessonID", lessonID,
-			"exerciseID", exerciseID,
-			"answer", req.Answer)
-
-		if courseID == "" || lessonID == "" || exerciseID == "" {
-			http.Error(w, "Course ID, lesson ID, and exercise ID are required", http.StatusBadRequest)
-			return
-		}
-
 		userID, ok := s.GetUserID(r.Context())
 		if !ok {
 			http.Error(w, "User not found in context", http.StatusUnauthorized)
 			return
 		}
 
-		// Verify the answer
-		isCorrect, err := s.CourseService.VerifyExerciseAnswer(courseID, lessonID, exerciseID, req.Answer)
-		if err != nil {
-			http.Error(w, "Failed to verify exercise answer", http.StatusInternalServerError)
-			return
+		query := points.LeaderboardQuery{
+			Period:   points.LeaderboardPeriod(r.URL.Query().Get("period")),
+			CourseID: r.PathValue("courseID"),
+			Limit:    10, // Default limit
+			UserID:   userID,
 		}
-
-		// Record attempt in the database
-		answerJSON, err := json.Marshal(req.Answer)
-		if err != nil {
-			http.Error(w, "Failed to process answer data", http.StatusInternalServerError)
-			return
+		if query.CourseID == "" {
+			query.CourseID = r.URL.Query().Get("courseId")
 		}
 
-		attempt := &progress.ExerciseAttempt{
-			UserID:     userID,
-			CourseID:   courseID,
-			LessonID:   lessonID,
-			ExerciseID: exerciseID,
-			Answer:     string(answerJSON),
-			IsCorrect:  isCorrect,
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err == nil && parsedLimit > 0 {
+				query.Limit = min(parsedLimit, maxLeaderboardLimit)
+			}
 		}
 
-		if err := s.ProgressService.RecordExerciseAttempt(attempt); err != nil {
-			http.Error(w, "Failed to record exercise attempt", http.StatusInternalServerError)
+		scope := r.URL.Query().Get("scope")
+		userIDs, err := s.scopeUserIDs(userID, scope)
+		if err != nil {
+			if errors.Is(err, errInvalidScope) {
+				http.Error(w, "Scope must be global or friends", http.StatusBadRequest)
+				return
+			}
+			s.logger.Error("Failed to get friends for leaderboard", "error", err)
+			http.Error(w, "Failed to get leaderboard", http.StatusInternalServerError)
 			return
 		}
+		query.UserIDs = userIDs
 
-		// Update accuracy statistics
-		if err := s.PointsService.UpdateAccuracyStats(userID, isCorrect); err != nil {
-			s.logger.Error("Failed to update accuracy stats", "error", err)
-		}
-
-		// Process points based on the answer correctness
-		var transaction *points.PointTransaction
-		if isCorrect {
-			transaction, err = s.PointsService.AwardPointsForCorrectAnswer(userID, courseID, lessonID, exerciseID, true)
-			if err != nil {
-				s.logger.Error("Failed to award points for correct answer", "error", err)
-			}
-		} else {
-			// For incorrect answers, update the streak (reset to 0)
-			_, err = s.PointsService.AwardPointsForCorrectAnswer(userID, courseID, lessonID, exerciseID, false)
-			if err != nil {
-				s.logger.Error("Failed to update streak for incorrect answer", "error", err)
+		if query.CourseID != "" {
+			if _, err := s.CourseService.GetCourseByID(query.CourseID); err != nil {
+				http.Error(w, "Course not found", http.StatusNotFound)
+				return
 			}
 		}
 
-		// Get updated lesson points information
-		lessonPoints, err := s.PointsService.GetLessonPoints(userID, courseID, lessonID)
+		leaderboard, err := s.PointsService.GetLeaderboard(query)
 		if err != nil {
-			s.logger.Error("Failed to get updated lesson points", "error", err)
+			if errors.Is(err, points.ErrInvalidPeriod) {
+				http.Error(w, "Period must be daily, weekly, monthly or all_time", http.StatusBadRequest)
+				return
+			}
+			s.logger.Error("Failed to get leaderboard", "error", err)
+			http.Error(w, "Failed to get leaderboard", http.StatusInternalServerError)
+			return
 		}
 
-		// Get updated accuracy statistics
-		accuracyStats, err := s.PointsService.GetAccuracyStats(userID)
+		// Only the ranked users are looked up, not the whole users table
+		rankedIDs := make([]int, 0, len(leaderboard.Entries)+1)
+		for _, entry := range leaderboard.Entries {
+			rankedIDs = append(rankedIDs, entry.UserID)
+		}
+		if leaderboard.Me != nil {
+			rankedIDs = append(rankedIDs, leaderboard.Me.UserID)
+		}
+		users, err := s.UserService.GetByIDs(rankedIDs)
 		if err != nil {
-			s.logger.Error("Failed to get updated accuracy stats", "error", err)
-			// Create a default value if we couldn't retrieve the stats
-			accuracyStats = &points.AccuracyStats{
-				UserID:        userID,
-				TotalAttempts: 0,
-				AccuracyRate:  0,
-			}
+			s.logger.Error("Failed to get users for leaderboard", "error", err)
+			http.Error(w, "Failed to get leaderboard", http.StatusInternalServerError)
+			return
 		}
 
-		response := struct {
-			IsCorrect       bool                     `json:"isCorrect"`
-			Points          int                      `json:"points,omitempty"`
-			Transaction     *points.PointTransaction `json:"transaction,omitempty"`
-			CurrentStreak   int                      `json:"currentStreak"`
-			MaxStreak       int                      `json:"maxStreak"`
-			AccuracyRate    float64                  `json:"accuracyRate"`
-			TotalAttempts   int                      `json:"totalAttempts"`
-			CorrectAttempts int                      `json:"correctAttempts"`
-		}{
-			IsCorrect:       isCorrect,
-			AccuracyRate:    accuracyStats.AccuracyRate,
-			TotalAttempts:   accuracyStats.TotalAttempts,
-			CorrectAttempts: accuracyStats.CorrectAttempts,
+		response := LeaderboardResponse{
+			Scope:    cmp.Or(scope, ScopeGlobal),
+			Period:   leaderboard.Period,
+			CourseID: leaderboard.CourseID,
+			Since:    leaderboard.Since,
+			Entries:  make([]LeaderboardEntry, 0, len(leaderboard.Entries)),
 		}
-
-		if transaction != nil {
-			response.Points = transaction.Points
-			response.Transaction = transaction
+		for _, entry := range leaderboard.Entries {
+			response.Entries = append(response.Entries, newLeaderboardEntry(entry, users))
 		}
-
-		if lessonPoints != nil {
-			response.CurrentStreak = lessonPoints.CurrentStreak
-			response.MaxStreak = lessonPoints.MaxStreak
+		if leaderboard.Me != nil {
+			me := newLeaderboardEntry(*leaderboard.Me, users)
+			response.Me = &me
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
-			s.logger.Error("Failed to encode response", "error", err)
+			s.logger.Error("Failed to encode leaderboard response", "error", err)
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
 	}
 }
 
-// This modifies the existing function in progress_handler.go
-func (s *Server) handleExerciseAttemptWithPoints() http.HandlerFunc {
+// GET /api/activity?scope=friends&limit=20 lists the points users earned recently, newest first.
+// The global scope leaves out users the caller has blocked or been blocked by.
+func (s *Server) handleGetActivity() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req ExerciseAttemptRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			s.logger.Warn("Error decoding request body", "error", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		courseID := r.PathValue("courseID")
-		lessonID := r.PathValue("lessonID")
-		exerciseID := r.PathValue("exerciseID")
-
-		s.logger.Debug("Received exercise attempt", "courseID", courseID, "lessonID", lessonID, "exerciseID", exerciseID, "answer", req.Answer)
-
-		if courseID == "" || lessonID == "" || exerciseID == "" {
-			http.Error(w, "Course ID, lesson ID, and exercise ID are required", http.StatusBadRequest)
-			return
-		}
-
 		userID, ok := s.GetUserID(r.Context())
 		if !ok {
 			http.Error(w, "User not found in context", http.StatusUnauthorized)
 			return
 		}
 
-		answerJSON, err := json.Marshal(req.Answer)
-		if err != nil {
-			http.Error(w, "Failed to process answer data", http.StatusInternalServerError)
-			return
-		}
-
-		attempt := &progress.ExerciseAttempt{
-			UserID:     userID,
-			CourseID:   courseID,
-			LessonID:   lessonID,
-			ExerciseID: exerciseID,
-			Answer:     string(answerJSON),
+		query := points.ActivityQuery{Limit: 20}
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err == nil && parsedLimit > 0 {
+				query.Limit = min(parsedLimit, maxActivityLimit)
+			}
 		}
 
-		isCorrect, err := s.CourseService.VerifyExerciseAnswer(courseID, lessonID, exerciseID, req.Answer)
+		userIDs, err := s.scopeUserIDs(userID, r.URL.Query().Get("scope"))
 		if err != nil {
-			http.Error(w, "Failed to verify exercise answer", http.StatusInternalServerError)
-			return
-		}
-
-		attempt.IsCorrect = isCorrect
-
-		if err := s.ProgressService.RecordExerciseAttempt(attempt); err != nil {
-			http.Error(w, "Failed to record exercise attempt", http.StatusInternalServerError)
+			if errors.Is(err, errInvalidScope) {
+				http.Error(w, "Scope must be global or friends", http.StatusBadRequest)
+				return
+			}
+			s.logger.Error("Failed to get friends for activity", "error", err)
+			http.Error(w, "Failed to get activity", http.StatusInternalServerError)
 			return
 		}
+		query.UserIDs = userIDs
 
-		var transaction *points.PointTransaction
-		if isCorrect {
-			transaction, err = s.PointsService.AwardPointsForCorrectAnswer(userID, courseID, lessonID, exerciseID, true)
-			if err != nil {
-				s.logger.Error("Failed to award points for correct answer", "error", err)
-			}
-		} else {
-			_, err = s.PointsService.AwardPointsForCorrectAnswer(userID, courseID, lessonID, exerciseID, false)
+		if userIDs == nil {
+			query.ExcludeUserIDs, err = s.SocialService.BlockedIDs(userID)
 			if err != nil {
-				s.logger.Error("Failed to update streak for incorrect answer", "error", err)
+				s.logger.Error("Failed to get blocked users for activity", "error", err)
+				http.Error(w, "Failed to get activity", http.StatusInternalServerError)
+				return
 			}
 		}
 
-		lessonPoints, err := s.PointsService.GetLessonPoints(userID, courseID, lessonID)
+		transactions, err := s.PointsService.GetActivity(query)
 		if err != nil {
-			s.logger.Error("Failed to get updated lesson points", "error", err)
-		}
-
-		response := struct {
-			IsCorrect     bool                     `json:"isCorrect"`
-			Points        int                      `json:"points,omitempty"`
-			Transaction   *points.PointTransaction `json:"transaction,omitempty"`
-			CurrentStreak int                      `json:"currentStreak"`
-			MaxStreak     int                      `json:"maxStreak"`
-		}{
-			IsCorrect: isCorrect,
-		}
-
-		if transaction != nil {
-			response.Points = transaction.Points
-			response.Transaction = transaction
-		}
-
-		if lessonPoints != nil {
-			response.CurrentStreak = lessonPoints.CurrentStreak
-			response.MaxStreak = lessonPoints.MaxStreak
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			s.logger.Error("Failed to encode response", "error", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			s.logger.Error("Failed to get activity", "error", err)
+			http.Error(w, "Failed to get activity", http.StatusInternalServerError)
 			return
 		}
-	}
-}
 
-// GET /api/leaderboard
-func (s *Server) handleGetLeaderboard() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		limitStr := r.URL.Query().Get("limit")
-		limit := 10 // Default limit
-		if limitStr != "" {
-			parsedLimit, err := strconv.Atoi(limitStr)
-			if err == nil && parsedLimit > 0 {
-				limit = parsedLimit
-			}
+		actorIDs := make([]int, 0, len(transactions))
+		for _, transaction := range transactions {
+			actorIDs = append(actorIDs, transaction.UserID)
 		}
-
-		rows, err := s.db.Query(`
-            SELECT id, username, total_points, profile_pic_id
-            FROM users
-            ORDER BY total_points DESC
-            LIMIT $1`, limit)
+		users, err := s.UserService.GetByIDs(actorIDs)
 		if err != nil {
-			s.logger.Error("Failed to query leaderboard", "error", err)
-			http.Error(w, "Failed to get leaderboard", http.StatusInternalServerError)
+			s.logger.Error("Failed to get users for activity", "error", err)
+			http.Error(w, "Failed to get activity", http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
-
-		type LeaderboardEntry struct {
-			UserID         int    `json:"userId"`
-			Username       string `json:"username"`
-			TotalPoints    int    `json:"totalPoints"`
-			ProfilePicture string `json:"profilePicture,omitempty"`
-			Rank           int    `json:"rank"`
-		}
-
-		var leaderboard []LeaderboardEntry
-		rank := 1
 
-		for rows.Next() {
-			var entry LeaderboardEntry
-			var profilePicID sql.NullString
-
-			if err := rows.Scan(&entry.UserID, &entry.Username, &entry.TotalPoints, &profilePicID); err != nil {
-				s.logger.Error("Failed to scan leaderboard row", "error", err)
-				continue
-			}
-
-			if profilePicID.Valid {
-				entry.ProfilePicture = profilePicID.String
+		activity := make([]ActivityEntry, 0, len(transactions))
+		for _, transaction := range transactions {
+			entry := ActivityEntry{PointTransaction: transaction}
+			if u, ok := users[transaction.UserID]; ok {
+				entry.Username = u.Username
+				entry.ProfilePicture = u.ProfilePicID
 			}
-
-			entry.Rank = rank
-			leaderboard = append(leaderboard, entry)
-			rank++
-		}
-
-		if err := rows.Err(); err != nil {
-			s.logger.Error("Error iterating leaderboard rows", "error", err)
-			http.Error(w, "Failed to process leaderboard data", http.StatusInternalServerError)
-			return
+			activity = append(activity, entry)
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(leaderboard); err != nil {
-			s.logger.Error("Failed to encode leaderboard response", "error", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-			return
+		if err := json.NewEncoder(w).Encode(activity); err != nil {
+			s.logger.Error("Failed to encode activity response", "error", err)
 		}
 	}
 }
+
+func newLeaderboardEntry(entry points.LeaderboardEntry, users map[int]*user.User) LeaderboardEntry {
+	response := LeaderboardEntry{
+		Rank:   entry.Rank,
+		UserID: entry.UserID,
+		Points: entry.Points,
+	}
+	if u, ok := users[entry.UserID]; ok {
+		response.Username = u.Username
+		response.ProfilePicture = u.ProfilePicID
+	}
+
+	return response
+}
@@ -0,0 +1,67 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestLeaderboard(t *testing.T) {
+	server := setupTestServer(t)
+	token := signInAs(t, server, "student", user.RoleStudent)
+	signInAs(t, server, "rival", user.RoleStudent)
+
+	rival, err := server.UserService.Get("rival")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.PointsService.AdjustPoints(rival.ID, 40, "seed"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Weekly", func(t *testing.T) {
+		rr := serve(server, http.MethodGet, "/api/leaderboard?period=weekly", token, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var leaderboard api.LeaderboardResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &leaderboard); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(leaderboard.Entries) != 1 || leaderboard.Entries[0].Username != "rival" || leaderboard.Entries[0].Points != 40 {
+			t.Errorf("unexpected entries: %+v", leaderboard.Entries)
+		}
+		if leaderboard.Me == nil || leaderboard.Me.Username != "student" || leaderboard.Me.Rank != 2 {
+			t.Errorf("expected the caller's own rank, got %+v", leaderboard.Me)
+		}
+	})
+
+	t.Run("Per Course", func(t *testing.T) {
+		rr := serve(server, http.MethodGet, "/api/courses/algorithms/leaderboard", token, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var leaderboard api.LeaderboardResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &leaderboard); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if leaderboard.CourseID != "algorithms" || len(leaderboard.Entries) != 0 {
+			t.Errorf("expected an empty course leaderboard, got %+v", leaderboard)
+		}
+
+		if rr := serve(server, http.MethodGet, "/api/courses/nope/leaderboard", token, nil); rr.Code != http.StatusNotFound {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Invalid Period", func(t *testing.T) {
+		if rr := serve(server, http.MethodGet, "/api/leaderboard?period=yearly", token, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
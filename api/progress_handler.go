@@ -3,9 +3,10 @@ package api
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 
+	"github.com/tylerolson/capstone-backend/services/achievements"
+	"github.com/tylerolson/capstone-backend/services/points"
 	"github.com/tylerolson/capstone-backend/services/progress"
 )
 
@@ -17,6 +18,24 @@ type ExerciseAttemptRequest struct {
 	Answer interface{} `json:"answer"`
 }
 
+type ExerciseAttemptResponse struct {
+	IsCorrect       bool                     `json:"isCorrect"`
+	AttemptNumber   int                      `json:"attemptNumber"`
+	Points          int                      `json:"points,omitempty"`
+	Transaction     *points.PointTransaction `json:"transaction,omitempty"`
+	CurrentStreak   int                      `json:"currentStreak"`
+	MaxStreak       int                      `json:"maxStreak"`
+	AccuracyRate    float64                  `json:"accuracyRate"`
+	TotalAttempts   int                      `json:"totalAttempts"`
+	CorrectAttempts int                      `json:"correctAttempts"`
+	// DailyActivity is today's progress toward the daily streak, and DailyStreakBonus is set
+	// when this attempt counted the day and earned a bonus. StreakFreezesUsed covers days missed before it.
+	DailyActivity     *points.DailyActivity           `json:"dailyActivity"`
+	DailyStreakBonus  *points.PointTransaction        `json:"dailyStreakBonus,omitempty"`
+	StreakFreezesUsed int                             `json:"streakFreezesUsed,omitempty"`
+	NewAchievements   []*achievements.UserAchievement `json:"newAchievements,omitempty"`
+}
+
 func (s *Server) handleGetCourseProgress() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		s.logger.Debug("Recieved GET CourseProgress")
@@ -144,6 +163,10 @@ func (s *Server) handleUpdateLessonProgress() http.HandlerFunc {
 			return
 		}
 
+		if !s.requireUnlocked(w, userID, courseID, lessonID) {
+			return
+		}
+
 		if err := s.ProgressService.UpdateLessonProgress(userID, courseID, lessonID, req.Status); err != nil {
 			s.logger.Error("Failed to update lesson progress", "error", err)
 			http.Error(w, fmt.Sprintf("Failed to update lesson progress: %v", err), http.StatusInternalServerError)
@@ -154,6 +177,7 @@ func (s *Server) handleUpdateLessonProgress() http.HandlerFunc {
 	}
 }
 
+// POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/attempt
 func (s *Server) handleExerciseAttempt() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req ExerciseAttemptRequest
@@ -180,41 +204,70 @@ func (s *Server) handleExerciseAttempt() http.HandlerFunc {
 			return
 		}
 
-		// Convert answer to JSON string
-		answerJSON, err := json.Marshal(req.Answer)
+		if !s.requireUnlocked(w, userID, courseID, lessonID) {
+			return
+		}
+
+		// Answers reference the shuffled lesson view, map them back onto the answer key
+		answer, err := s.translateAnswer(userID, courseID, lessonID, exerciseID, req.Answer)
+		if err != nil {
+			s.logger.Debug("Could not translate exercise answer", "exerciseID", exerciseID, "error", err)
+			http.Error(w, "Invalid answer", http.StatusBadRequest)
+			return
+		}
+
+		isCorrect, err := s.CourseService.VerifyExerciseAnswer(courseID, lessonID, exerciseID, answer)
+		if err != nil {
+			http.Error(w, "Failed to verify exercise answer", http.StatusInternalServerError)
+			return
+		}
+
+		answerJSON, err := json.Marshal(answer)
 		if err != nil {
 			http.Error(w, "Failed to process answer data", http.StatusInternalServerError)
 			return
 		}
 
-		attempt := &progress.ExerciseAttempt{
+		result, err := s.AttemptService.Submit(&progress.ExerciseAttempt{
 			UserID:     userID,
 			CourseID:   courseID,
 			LessonID:   lessonID,
 			ExerciseID: exerciseID,
 			Answer:     string(answerJSON),
-		}
-
-		// Verify answer and record attempt
-		isCorrect, err := s.CourseService.VerifyExerciseAnswer(courseID, lessonID, exerciseID, req.Answer)
+			IsCorrect:  isCorrect,
+		}, s.GetLocation(r.Context()))
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to verify exercise answer: %v", err), http.StatusInternalServerError)
+			s.logger.Error("Failed to process exercise attempt", "error", err)
+			http.Error(w, "Failed to record exercise attempt", http.StatusInternalServerError)
 			return
 		}
 
-		log.Printf("Exercise verification result - isCorrect: %v", isCorrect)
-		attempt.IsCorrect = isCorrect
+		response := ExerciseAttemptResponse{
+			IsCorrect:         isCorrect,
+			AttemptNumber:     result.Attempt.AttemptNumber,
+			Transaction:       result.Transaction,
+			CurrentStreak:     result.LessonPoints.CurrentStreak,
+			MaxStreak:         result.LessonPoints.MaxStreak,
+			AccuracyRate:      result.Accuracy.AccuracyRate,
+			TotalAttempts:     result.Accuracy.TotalAttempts,
+			CorrectAttempts:   result.Accuracy.CorrectAttempts,
+			DailyActivity:     result.Activity.Activity,
+			DailyStreakBonus:  result.Activity.StreakBonus,
+			StreakFreezesUsed: result.Activity.FreezesUsed,
+		}
 
-		// Record the attempt using the DB field
-		if err := s.ProgressService.RecordExerciseAttempt(attempt); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to record exercise attempt: %v", err), http.StatusInternalServerError)
-			return
+		triggers := []achievements.Trigger{achievements.TriggerAttempt, achievements.TriggerPoints}
+		if result.Activity.DayCounted {
+			triggers = append(triggers, achievements.TriggerDailyStreak)
+		}
+		response.NewAchievements = s.evaluateAchievements(userID, triggers...)
+		if result.Transaction != nil {
+			response.Points = result.Transaction.Points
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"isCorrect": isCorrect,
-		}); err != nil {
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 			return
 		}
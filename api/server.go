@@ -8,35 +8,55 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/services/achievements"
+	"github.com/tylerolson/capstone-backend/services/attempt"
+	"github.com/tylerolson/capstone-backend/services/idempotency"
 	"github.com/tylerolson/capstone-backend/services/points"
 	"github.com/tylerolson/capstone-backend/services/progress"
 	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/shop"
+	"github.com/tylerolson/capstone-backend/services/social"
 	"github.com/tylerolson/capstone-backend/services/user"
 )
 
 type Server struct {
-	Mux             *http.ServeMux
-	UserService     user.Service
-	CourseService   course.Service
-	ProgressService progress.Service
-	SessionService  session.Service
-	PointsService   points.Service
+	Mux                 *http.ServeMux
+	UserService         user.Service
+	CourseService       course.Service
+	ProgressService     progress.Service
+	SessionService      session.Service
+	PointsService       points.Service
+	AchievementsService achievements.Service
+	AttemptService      attempt.Service
+	IdempotencyService  idempotency.Service
+	SocialService       social.Service
+	ShopService         shop.Service
+	// SlidingSessions keeps sessions in use from expiring, pushing their expiry forward as requests come in
+	SlidingSessions bool
+	identities      *identityCache
 	logger          *slog.Logger
 	db              *sql.DB
 }
 
-func NewServer(userService user.Service, courseService course.Service, progressService progress.Service, sessionService session.Service, pointsService points.Service, database *sql.DB, logger *slog.Logger) *Server {
+func NewServer(userService user.Service, courseService course.Service, progressService progress.Service, sessionService session.Service, pointsService points.Service, achievementsService achievements.Service, attemptService attempt.Service, idempotencyService idempotency.Service, socialService social.Service, shopService shop.Service, database *sql.DB, logger *slog.Logger) *Server {
 	s := &Server{
-		UserService:     userService,
-		CourseService:   courseService,
-		ProgressService: progressService,
-		SessionService:  sessionService,
-		PointsService:   pointsService,
-		Mux:             http.NewServeMux(),
-		logger:          logger,
-		db:              database,
+		UserService:         userService,
+		CourseService:       courseService,
+		ProgressService:     progressService,
+		SessionService:      sessionService,
+		PointsService:       pointsService,
+		AchievementsService: achievementsService,
+		AttemptService:      attemptService,
+		IdempotencyService:  idempotencyService,
+		SocialService:       socialService,
+		ShopService:         shopService,
+		Mux:                 http.NewServeMux(),
+		identities:          newIdentityCache(DefaultIdentityCacheSize, DefaultIdentityCacheTTL),
+		logger:              logger,
+		db:                  database,
 	}
 
 	s.Mux.HandleFunc("GET /api", func(w http.ResponseWriter, r *http.Request) {
@@ -46,20 +66,36 @@ func NewServer(userService user.Service, courseService course.Service, progressS
 	// Public routes
 	s.Mux.Handle("POST /api/signin", s.handleSignIn())
 	s.Mux.Handle("POST /api/register", s.handleCreateUser())
+	s.Mux.Handle("POST /api/token/refresh", s.handleRefreshToken())
 
-	// Protected routes (require authentication)
-	dbAuth := s.DbAuthMiddleware()
+	// Protected routes (require authentication). Mutating requests can be retried safely with an Idempotency-Key.
+	auth := s.DbAuthMiddleware()
+	idempotent := s.IdempotencyMiddleware()
+	dbAuth := func(h http.Handler) http.Handler {
+		return auth(idempotent(h))
+	}
+	adminOnly := func(h http.Handler) http.Handler {
+		return dbAuth(s.RequireRole(user.RoleAdmin)(h))
+	}
+	instructorOnly := func(h http.Handler) http.Handler {
+		return dbAuth(s.RequireRole(user.RoleInstructor, user.RoleAdmin)(h))
+	}
 
 	// User profile endpoints
 	s.Mux.Handle("GET /api/users/profilepic", dbAuth(s.handleGetProfilePic()))
 	s.Mux.Handle("PUT /api/users/profilepic", dbAuth(s.handleUpdateProfilePic()))
 	s.Mux.Handle("POST /api/users/profilepic/upload", dbAuth(s.handleUploadProfilePic()))
+	s.Mux.Handle("PUT /api/users/timezone", dbAuth(s.handleUpdateTimezone()))
 
 	// Other protected routes
 	s.Mux.Handle("POST /api/logout", dbAuth(s.handleLogout()))
-	s.Mux.Handle("GET /api/users", dbAuth(s.handleListUsers()))
+	s.Mux.Handle("GET /api/sessions", dbAuth(s.handleListSessions()))
+	s.Mux.Handle("DELETE /api/sessions", dbAuth(s.handleDeleteSessions()))
+	s.Mux.Handle("DELETE /api/sessions/{sessionID}", dbAuth(s.handleDeleteSession()))
+	s.Mux.Handle("GET /api/users", adminOnly(s.handleListUsers()))
 	s.Mux.Handle("GET /api/courses", dbAuth(s.handleListCourses()))
 	s.Mux.Handle("GET /api/courses/{courseID}", dbAuth(s.handleGetCourse()))
+	s.Mux.Handle("GET /api/courses/{courseID}/map", dbAuth(s.handleGetCourseMap()))
 	s.Mux.Handle("GET /api/courses/{courseID}/lessons/{lessonID}", dbAuth(s.handleGetLesson()))
 
 	// Password reset routes (public)
@@ -72,9 +108,11 @@ func NewServer(userService user.Service, courseService course.Service, progressS
 	s.Mux.Handle("GET /api/courses/{courseID}/lessons/{lessonID}/progress", dbAuth(s.handleGetLessonProgress()))
 	s.Mux.Handle("POST /api/courses/{courseID}/lessons/{lessonID}/progress", dbAuth(s.handleUpdateLessonProgress()))
 
-	// Exercise attempt handler with points included
+	// Exercise attempts, scored with points. /points is the older name of the same route.
 	s.Mux.Handle("POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/attempt", dbAuth(s.handleExerciseAttempt()))
-	s.Mux.Handle("POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/points", dbAuth(s.handleExerciseAttemptPoints()))
+	s.Mux.Handle("POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/points", dbAuth(s.handleExerciseAttempt()))
+	s.Mux.Handle("POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/reveal", dbAuth(s.handleRevealAnswer()))
+	s.Mux.Handle("POST /api/courses/{courseID}/lessons/{lessonID}/exercises/{exerciseID}/hint", dbAuth(s.handleUseHint()))
 
 	// Points and gamification routes
 	s.Mux.Handle("GET /api/points/summary", dbAuth(s.handleGetPointsSummary()))
@@ -82,9 +120,49 @@ func NewServer(userService user.Service, courseService course.Service, progressS
 	s.Mux.Handle("POST /api/courses/{courseID}/complete", dbAuth(s.handleCompleteCourse()))
 	s.Mux.Handle("POST /api/courses/{courseID}/lessons/{lessonID}/complete", dbAuth(s.handleCompleteLesson()))
 	s.Mux.Handle("GET /api/leaderboard", dbAuth(s.handleGetLeaderboard()))
+	s.Mux.Handle("GET /api/courses/{courseID}/leaderboard", dbAuth(s.handleGetLeaderboard()))
 	s.Mux.Handle("GET /api/stats/daily-streak", dbAuth(s.handleGetDailyStreak()))
+	s.Mux.Handle("POST /api/stats/daily-streak/freezes", dbAuth(s.handleBuyStreakFreeze()))
+	s.Mux.Handle("POST /api/stats/daily-streak/repair", dbAuth(s.handleRepairDailyStreak()))
+	s.Mux.Handle("GET /api/stats/daily-activity", dbAuth(s.handleGetDailyActivity()))
 	s.Mux.Handle("GET /api/stats/accuracy", dbAuth(s.handleGetAccuracyStats()))
 
+	// Friends, blocks and what friends have been up to
+	s.Mux.Handle("GET /api/friends", dbAuth(s.handleListFriends()))
+	s.Mux.Handle("POST /api/friends/{username}", dbAuth(s.handleSendFriendRequest()))
+	s.Mux.Handle("POST /api/friends/{username}/accept", dbAuth(s.handleAcceptFriendRequest()))
+	s.Mux.Handle("POST /api/friends/{username}/decline", dbAuth(s.handleDeclineFriendRequest()))
+	s.Mux.Handle("DELETE /api/friends/{username}", dbAuth(s.handleRemoveFriend()))
+	s.Mux.Handle("GET /api/blocks", dbAuth(s.handleListBlocked()))
+	s.Mux.Handle("POST /api/blocks/{username}", dbAuth(s.handleBlockUser()))
+	s.Mux.Handle("DELETE /api/blocks/{username}", dbAuth(s.handleUnblockUser()))
+	s.Mux.Handle("GET /api/activity", dbAuth(s.handleGetActivity()))
+
+	// Shop
+	s.Mux.Handle("GET /api/shop/items", dbAuth(s.handleListShopItems()))
+	s.Mux.Handle("POST /api/shop/items/{itemID}/purchase", dbAuth(s.handlePurchaseItem()))
+	s.Mux.Handle("GET /api/shop/inventory", dbAuth(s.handleGetInventory()))
+
+	// Achievement routes
+	s.Mux.Handle("GET /api/achievements", dbAuth(s.handleListAchievements()))
+	s.Mux.Handle("GET /api/users/me/achievements", dbAuth(s.handleGetUserAchievements()))
+
+	// Admin routes
+	s.Mux.Handle("GET /api/admin/users", adminOnly(s.handleListUsers()))
+	s.Mux.Handle("DELETE /api/admin/users/{username}", adminOnly(s.handleAdminDeleteUser()))
+	s.Mux.Handle("PUT /api/admin/users/{username}/role", adminOnly(s.handleAdminSetRole()))
+	s.Mux.Handle("POST /api/admin/users/{username}/streaks/reset", adminOnly(s.handleAdminResetStreaks()))
+	s.Mux.Handle("POST /api/admin/users/{username}/points", adminOnly(s.handleAdminAdjustPoints()))
+	s.Mux.Handle("POST /api/admin/points/reconcile", adminOnly(s.handleAdminReconcilePoints()))
+	s.Mux.Handle("POST /api/admin/courses/reload", adminOnly(s.handleAdminReloadCourses()))
+	s.Mux.Handle("GET /api/admin/shop/items", adminOnly(s.handleAdminListShopItems()))
+	s.Mux.Handle("POST /api/admin/shop/items", adminOnly(s.handleAdminCreateShopItem()))
+	s.Mux.Handle("PUT /api/admin/shop/items/{itemID}", adminOnly(s.handleAdminUpdateShopItem()))
+	s.Mux.Handle("DELETE /api/admin/shop/items/{itemID}", adminOnly(s.handleAdminDeleteShopItem()))
+
+	// Course authoring routes
+	s.registerAuthoringRoutes(instructorOnly)
+
 	// Make sure the profile pictures directory exists
 	if err := EnsureProfilePicDirectory(); err != nil {
 		logger.Error("Failed to create profile pictures directory", "error", err)
@@ -94,11 +172,17 @@ func NewServer(userService user.Service, courseService course.Service, progressS
 	return s
 }
 
+// SetIdentityCache sizes the cache of who each session token belongs to, replacing the default one.
+// A size or TTL of 0 turns it off. Call it before the server starts handling requests.
+func (s *Server) SetIdentityCache(maxEntries int, ttl time.Duration) {
+	s.identities = newIdentityCache(maxEntries, ttl)
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// CORS headers for all requests
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 	// Handle OPTIONS requests
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/session"
+)
+
+// maxUserAgentLength keeps oversized User-Agent headers out of the sessions table
+const maxUserAgentLength = 255
+
+// SessionResponse is one signed in device. Current marks the session making the request.
+type SessionResponse struct {
+	ID         int       `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
+
+type LogoutEverywhereResponse struct {
+	SessionsEnded int `json:"sessionsEnded"`
+}
+
+// clientInfo describes the device a request came from
+func clientInfo(r *http.Request) session.ClientInfo {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return session.ClientInfo{UserAgent: userAgent, IPAddress: ip}
+}
+
+// GET /api/sessions lists the devices the user is signed in on
+func (s *Server) handleListSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+		token, _ := s.GetToken(r.Context())
+
+		current, err := s.SessionService.GetSessionByToken(token)
+		if err != nil {
+			s.logger.Error("Failed to get current session", "error", err)
+			http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+			return
+		}
+
+		sessions, err := s.SessionService.ListSessions(userID)
+		if err != nil {
+			s.logger.Error("Failed to list sessions", "error", err)
+			http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]SessionResponse, 0, len(sessions))
+		for _, listed := range sessions {
+			response = append(response, SessionResponse{
+				ID:         listed.ID,
+				UserAgent:  listed.UserAgent,
+				IPAddress:  listed.IPAddress,
+				CreatedAt:  listed.CreatedAt,
+				LastSeenAt: listed.LastSeenAt,
+				Current:    listed.ID == current.ID,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+// DELETE /api/sessions/{sessionID} signs out one device, which can be the current one
+func (s *Server) handleDeleteSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		sessionID, err := strconv.Atoi(r.PathValue("sessionID"))
+		if err != nil {
+			http.Error(w, "Invalid session ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.SessionService.DeleteUserSession(userID, sessionID); err != nil {
+			if errors.Is(err, session.ErrSessionNotFound) {
+				http.Error(w, "Session not found", http.StatusNotFound)
+				return
+			}
+			s.logger.Error("Failed to delete session", "error", err)
+			http.Error(w, "Failed to delete session", http.StatusInternalServerError)
+			return
+		}
+		// Only the session's ID is known, not its token
+		s.identities.removeUser(userID)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DELETE /api/sessions logs out everywhere. Add ?keepCurrent=true to stay signed in on this device.
+func (s *Server) handleDeleteSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		var keepToken string
+		if r.URL.Query().Get("keepCurrent") == "true" {
+			keepToken, _ = s.GetToken(r.Context())
+		}
+
+		ended, err := s.SessionService.DeleteUserSessions(userID, keepToken)
+		s.identities.removeUser(userID)
+		if err != nil {
+			s.logger.Error("Failed to delete sessions", "error", err)
+			http.Error(w, "Failed to log out everywhere", http.StatusInternalServerError)
+			return
+		}
+
+		s.logger.Info("Logged out everywhere", "userID", userID, "sessions", ended)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(LogoutEverywhereResponse{SessionsEnded: ended}); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestSessions(t *testing.T) {
+	server := setupTestServer(t)
+	token := signInAs(t, server, "student", user.RoleStudent)
+	otherToken := mustToken(t, server, "student")
+	strangerToken := signInAs(t, server, "stranger", user.RoleStudent)
+
+	listSessions := func(t *testing.T, token string) []api.SessionResponse {
+		t.Helper()
+
+		rr := serve(server, http.MethodGet, "/api/sessions", token, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		var sessions []api.SessionResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &sessions); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return sessions
+	}
+
+	t.Run("List", func(t *testing.T) {
+		sessions := listSessions(t, token)
+		if len(sessions) != 2 {
+			t.Fatalf("expected two sessions, got %+v", sessions)
+		}
+
+		current := 0
+		for _, listed := range sessions {
+			if listed.Current {
+				current++
+			}
+		}
+		if current != 1 {
+			t.Errorf("expected exactly one current session, got %+v", sessions)
+		}
+	})
+
+	t.Run("Delete One", func(t *testing.T) {
+		var otherID int
+		for _, listed := range listSessions(t, token) {
+			if !listed.Current {
+				otherID = listed.ID
+			}
+		}
+
+		path := fmt.Sprintf("/api/sessions/%d", otherID)
+		if rr := serve(server, http.MethodDelete, path, strangerToken, nil); rr.Code != http.StatusNotFound {
+			t.Errorf("expected other users' sessions to be out of reach, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodDelete, path, token, nil); rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodGet, "/api/sessions", otherToken, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the deleted session to be signed out, got status %v", rr.Code)
+		}
+	})
+
+	t.Run("Log Out Everywhere", func(t *testing.T) {
+		another := mustToken(t, server, "student")
+
+		rr := serve(server, http.MethodDelete, "/api/sessions?keepCurrent=true", token, nil)
+		var response api.LogoutEverywhereResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.SessionsEnded != 1 {
+			t.Errorf("expected one session to end, got %+v", response)
+		}
+		if rr := serve(server, http.MethodGet, "/api/sessions", another, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the other device to be signed out, got status %v", rr.Code)
+		}
+
+		if rr := serve(server, http.MethodDelete, "/api/sessions", token, nil); rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodGet, "/api/sessions", token, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the current session to be signed out too, got status %v", rr.Code)
+		}
+		if sessions := listSessions(t, strangerToken); len(sessions) != 1 {
+			t.Errorf("expected other users to stay signed in, got %+v", sessions)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/shop"
+)
+
+type PurchaseResponse struct {
+	*shop.Purchase
+	TotalPoints int `json:"totalPoints"` // The points left after paying
+}
+
+// InventoryResponse is what a user has bought. Streak freezes are held by the daily streak.
+type InventoryResponse struct {
+	Items         []*shop.InventoryItem `json:"items"`
+	StreakFreezes int                   `json:"streakFreezes"`
+}
+
+// GET /api/shop/items lists the items for sale
+func (s *Server) handleListShopItems() http.HandlerFunc {
+	return s.listShopItems(false)
+}
+
+// GET /api/admin/shop/items lists the whole catalog, including items taken off sale
+func (s *Server) handleAdminListShopItems() http.HandlerFunc {
+	return s.listShopItems(true)
+}
+
+func (s *Server) listShopItems(includeInactive bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items, err := s.ShopService.ListItems(includeInactive)
+		if err != nil {
+			s.writeShopError(w, "Failed to list shop items", err)
+			return
+		}
+
+		s.writeShopResponse(w, http.StatusOK, items)
+	}
+}
+
+// POST /api/shop/items/{itemID}/purchase
+func (s *Server) handlePurchaseItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		purchase, err := s.ShopService.Purchase(userID, r.PathValue("itemID"))
+		if err != nil {
+			s.writeShopError(w, "Failed to buy item", err)
+			return
+		}
+
+		totalPoints, err := s.PointsService.GetUserTotalPoints(userID)
+		if err != nil {
+			s.logger.Error("Failed to get total points after a purchase", "error", err)
+			http.Error(w, "Failed to get total points", http.StatusInternalServerError)
+			return
+		}
+
+		s.writeShopResponse(w, http.StatusCreated, PurchaseResponse{Purchase: purchase, TotalPoints: totalPoints.TotalPoints})
+	}
+}
+
+// GET /api/shop/inventory
+func (s *Server) handleGetInventory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		items, err := s.ShopService.Inventory(userID)
+		if err != nil {
+			s.writeShopError(w, "Failed to get inventory", err)
+			return
+		}
+
+		streak, err := s.PointsService.GetDailyStreak(userID, s.GetLocation(r.Context()))
+		if err != nil {
+			s.writeShopError(w, "Failed to get inventory", err)
+			return
+		}
+
+		s.writeShopResponse(w, http.StatusOK, InventoryResponse{Items: items, StreakFreezes: streak.Freezes})
+	}
+}
+
+// POST /api/admin/shop/items
+func (s *Server) handleAdminCreateShopItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var item shop.Item
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		created, err := s.ShopService.CreateItem(&item)
+		if err != nil {
+			s.writeShopError(w, "Failed to create shop item", err)
+			return
+		}
+
+		s.logger.Info("Admin created shop item", "item", created.ID, "price", created.Price)
+		s.writeShopResponse(w, http.StatusCreated, created)
+	}
+}
+
+// PUT /api/admin/shop/items/{itemID} replaces an item. Set active to false to take it off sale.
+func (s *Server) handleAdminUpdateShopItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var item shop.Item
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		item.ID = r.PathValue("itemID")
+
+		updated, err := s.ShopService.UpdateItem(&item)
+		if err != nil {
+			s.writeShopError(w, "Failed to update shop item", err)
+			return
+		}
+
+		s.logger.Info("Admin updated shop item", "item", updated.ID, "price", updated.Price, "active", updated.Active)
+		s.writeShopResponse(w, http.StatusOK, updated)
+	}
+}
+
+// DELETE /api/admin/shop/items/{itemID}
+func (s *Server) handleAdminDeleteShopItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		itemID := r.PathValue("itemID")
+		if err := s.ShopService.DeleteItem(itemID); err != nil {
+			s.writeShopError(w, "Failed to delete shop item", err)
+			return
+		}
+
+		s.logger.Info("Admin deleted shop item", "item", itemID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeShopError maps shop and points errors onto status codes, logging anything unexpected
+func (s *Server) writeShopError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, shop.ErrItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, shop.ErrInvalidItem):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, shop.ErrItemExists), errors.Is(err, shop.ErrNotForSale), errors.Is(err, shop.ErrAlreadyOwned),
+		errors.Is(err, shop.ErrItemInUse), errors.Is(err, shop.ErrNoHintTokens), errors.Is(err, points.ErrFreezeLimit):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, points.ErrInsufficientPoints):
+		http.Error(w, "Not enough points", http.StatusConflict)
+	default:
+		s.logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) writeShopResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error("Failed to encode response", "error", err)
+	}
+}
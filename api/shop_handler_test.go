@@ -0,0 +1,130 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/services/shop"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestShop(t *testing.T) {
+	server := setupTestServer(t)
+	adminToken := signInAs(t, server, "admin", user.RoleAdmin)
+	studentToken := signInAs(t, server, "student", user.RoleStudent)
+
+	robot := shop.Item{ID: "robot", Kind: shop.KindAvatar, Name: "Robot", Price: 40, ProfilePicID: "robot", Active: true}
+
+	t.Run("Admin Catalog", func(t *testing.T) {
+		if rr := serve(server, http.MethodPost, "/api/admin/shop/items", studentToken, robot); rr.Code != http.StatusForbidden {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusForbidden)
+		}
+		if rr := serve(server, http.MethodPost, "/api/admin/shop/items", adminToken, robot); rr.Code != http.StatusCreated {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodPost, "/api/admin/shop/items", adminToken, shop.Item{ID: "bad", Kind: "car", Name: "Car"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+
+		rr := serve(server, http.MethodGet, "/api/shop/items", studentToken, nil)
+		var items []shop.Item
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(items) != len(shop.DefaultItems)+1 {
+			t.Errorf("expected the default items and the robot, got %+v", items)
+		}
+	})
+
+	t.Run("Premium Avatar", func(t *testing.T) {
+		if rr := serve(server, http.MethodPut, "/api/users/profilepic", studentToken, api.ProfilePicRequest{ProfilePicID: "robot"}); rr.Code != http.StatusForbidden {
+			t.Errorf("expected the avatar to be locked, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPost, "/api/shop/items/robot/purchase", studentToken, nil); rr.Code != http.StatusConflict {
+			t.Errorf("expected buying without enough points to fail, got status %v", rr.Code)
+		}
+
+		u, err := server.UserService.Get("student")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.PointsService.AdjustPoints(u.ID, 50, "seed"); err != nil {
+			t.Fatal(err)
+		}
+
+		rr := serve(server, http.MethodPost, "/api/shop/items/robot/purchase", studentToken, nil)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		var purchase api.PurchaseResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &purchase); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if purchase.TotalPoints != 10 || purchase.Transaction == nil || purchase.Transaction.Points != -40 {
+			t.Errorf("expected 40 points to be spent, got %+v", purchase)
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/shop/items/robot/purchase", studentToken, nil); rr.Code != http.StatusConflict {
+			t.Errorf("expected buying twice to fail, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPut, "/api/users/profilepic", studentToken, api.ProfilePicRequest{ProfilePicID: "robot"}); rr.Code != http.StatusOK {
+			t.Errorf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Inventory", func(t *testing.T) {
+		rr := serve(server, http.MethodGet, "/api/shop/inventory", studentToken, nil)
+		var inventory api.InventoryResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &inventory); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(inventory.Items) != 1 || inventory.Items[0].Item.ID != "robot" || inventory.StreakFreezes != 0 {
+			t.Errorf("expected the robot in the inventory, got %+v", inventory)
+		}
+
+		if rr := serve(server, http.MethodDelete, "/api/admin/shop/items/robot", adminToken, nil); rr.Code != http.StatusConflict {
+			t.Errorf("expected a bought item to stay in the catalog, got status %v", rr.Code)
+		}
+	})
+	t.Run("Hint Tokens", func(t *testing.T) {
+		const exercisePath = "/api/courses/algorithms/lessons/introduction/exercises/algo_intro_1/hint"
+
+		if rr := serve(server, http.MethodPost, exercisePath, studentToken, nil); rr.Code != http.StatusConflict {
+			t.Errorf("expected a hint without tokens to fail, got status %v", rr.Code)
+		}
+
+		u, err := server.UserService.Get("student")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.PointsService.AdjustPoints(u.ID, 40, "seed"); err != nil {
+			t.Fatal(err)
+		}
+		if rr := serve(server, http.MethodPost, "/api/shop/items/hint_token/purchase", studentToken, nil); rr.Code != http.StatusCreated {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		// True or false questions have no hint, so no token is spent on them
+		if rr := serve(server, http.MethodPost, "/api/courses/algorithms/lessons/searching-algorithms/exercises/search_2/hint", studentToken, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+
+		rr := serve(server, http.MethodPost, exercisePath, studentToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		var hint api.HintResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &hint); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if hint.Hint != `It isn't "A programming language"` || hint.HintTokens != 0 {
+			t.Errorf("unexpected hint: %+v", hint)
+		}
+
+		if rr := serve(server, http.MethodPost, exercisePath, studentToken, nil); rr.Code != http.StatusConflict {
+			t.Errorf("expected the used token to be gone, got status %v", rr.Code)
+		}
+	})
+}
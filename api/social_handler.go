@@ -0,0 +1,307 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/social"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+// Scopes narrow the leaderboard and activity view down to the caller's friends
+const (
+	ScopeGlobal  = "global"
+	ScopeFriends = "friends"
+)
+
+var errInvalidScope = errors.New("invalid scope")
+
+// UserSummary is how other users appear in friend and block lists
+type UserSummary struct {
+	UserID         int       `json:"userId"`
+	Username       string    `json:"username"`
+	ProfilePicture string    `json:"profilePicture,omitempty"`
+	Since          time.Time `json:"since"`
+}
+
+type FriendsResponse struct {
+	Friends  []UserSummary `json:"friends"`
+	Incoming []UserSummary `json:"incoming"`
+	Outgoing []UserSummary `json:"outgoing"`
+}
+
+type FriendshipResponse struct {
+	Username string        `json:"username"`
+	Status   social.Status `json:"status"`
+}
+
+// GET /api/friends
+func (s *Server) handleListFriends() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		friendships, err := s.SocialService.ListFriendships(userID)
+		if err != nil {
+			s.logger.Error("Failed to list friendships", "error", err)
+			http.Error(w, "Failed to list friends", http.StatusInternalServerError)
+			return
+		}
+
+		otherIDs := make([]int, 0, len(friendships))
+		for _, friendship := range friendships {
+			otherIDs = append(otherIDs, friendship.Other(userID))
+		}
+		users, err := s.UserService.GetByIDs(otherIDs)
+		if err != nil {
+			s.logger.Error("Failed to get users for friends", "error", err)
+			http.Error(w, "Failed to list friends", http.StatusInternalServerError)
+			return
+		}
+
+		response := FriendsResponse{
+			Friends:  make([]UserSummary, 0),
+			Incoming: make([]UserSummary, 0),
+			Outgoing: make([]UserSummary, 0),
+		}
+		for _, friendship := range friendships {
+			summary := newUserSummary(users, friendship.Other(userID), friendship.CreatedAt)
+			switch {
+			case friendship.Status == social.StatusAccepted:
+				if friendship.AcceptedAt != nil {
+					summary.Since = *friendship.AcceptedAt
+				}
+				response.Friends = append(response.Friends, summary)
+			case friendship.AddresseeID == userID:
+				response.Incoming = append(response.Incoming, summary)
+			default:
+				response.Outgoing = append(response.Outgoing, summary)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+// POST /api/friends/{username} sends a friend request, or accepts theirs if they already asked
+func (s *Server) handleSendFriendRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, target, ok := s.socialTarget(w, r)
+		if !ok {
+			return
+		}
+
+		friendship, err := s.SocialService.SendRequest(userID, target.ID)
+		if err != nil {
+			s.writeSocialError(w, err)
+			return
+		}
+
+		status := http.StatusCreated
+		if friendship.Status == social.StatusAccepted {
+			status = http.StatusOK
+		}
+		s.writeFriendship(w, status, target, friendship)
+	}
+}
+
+// POST /api/friends/{username}/accept
+func (s *Server) handleAcceptFriendRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, target, ok := s.socialTarget(w, r)
+		if !ok {
+			return
+		}
+
+		friendship, err := s.SocialService.Accept(userID, target.ID)
+		if err != nil {
+			s.writeSocialError(w, err)
+			return
+		}
+
+		s.writeFriendship(w, http.StatusOK, target, friendship)
+	}
+}
+
+// POST /api/friends/{username}/decline
+func (s *Server) handleDeclineFriendRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, target, ok := s.socialTarget(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.SocialService.Decline(userID, target.ID); err != nil {
+			s.writeSocialError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DELETE /api/friends/{username} unfriends a user or withdraws a request sent to them
+func (s *Server) handleRemoveFriend() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, target, ok := s.socialTarget(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.SocialService.Remove(userID, target.ID); err != nil {
+			s.writeSocialError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GET /api/blocks
+func (s *Server) handleListBlocked() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		blocks, err := s.SocialService.ListBlocked(userID)
+		if err != nil {
+			s.logger.Error("Failed to list blocked users", "error", err)
+			http.Error(w, "Failed to list blocked users", http.StatusInternalServerError)
+			return
+		}
+
+		blockedIDs := make([]int, 0, len(blocks))
+		for _, block := range blocks {
+			blockedIDs = append(blockedIDs, block.BlockedID)
+		}
+		users, err := s.UserService.GetByIDs(blockedIDs)
+		if err != nil {
+			s.logger.Error("Failed to get users for blocks", "error", err)
+			http.Error(w, "Failed to list blocked users", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]UserSummary, 0, len(blocks))
+		for _, block := range blocks {
+			response = append(response, newUserSummary(users, block.BlockedID, block.CreatedAt))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+// POST /api/blocks/{username}
+func (s *Server) handleBlockUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, target, ok := s.socialTarget(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.SocialService.Block(userID, target.ID); err != nil {
+			s.writeSocialError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DELETE /api/blocks/{username}
+func (s *Server) handleUnblockUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, target, ok := s.socialTarget(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.SocialService.Unblock(userID, target.ID); err != nil {
+			s.writeSocialError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// socialTarget returns the caller and the user named in the path, writing the error response if either is missing
+func (s *Server) socialTarget(w http.ResponseWriter, r *http.Request) (int, *user.User, bool) {
+	userID, ok := s.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return 0, nil, false
+	}
+
+	target, ok := s.pathUser(w, r)
+	if !ok {
+		return 0, nil, false
+	}
+
+	return userID, target, true
+}
+
+func (s *Server) writeSocialError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, social.ErrSelf):
+		http.Error(w, "You can't do that to yourself", http.StatusBadRequest)
+	case errors.Is(err, social.ErrBlocked):
+		http.Error(w, "You can't send a friend request to this user", http.StatusForbidden)
+	case errors.Is(err, social.ErrAlreadyFriends):
+		http.Error(w, "You are already friends", http.StatusConflict)
+	case errors.Is(err, social.ErrRequestExists):
+		http.Error(w, "Friend request already sent", http.StatusConflict)
+	case errors.Is(err, social.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		s.logger.Error("Social graph error", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) writeFriendship(w http.ResponseWriter, status int, target *user.User, friendship *social.Friendship) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(FriendshipResponse{Username: target.Username, Status: friendship.Status}); err != nil {
+		s.logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// scopeUserIDs returns the users a scope covers: nil for everyone, or the caller and their friends
+func (s *Server) scopeUserIDs(userID int, scope string) ([]int, error) {
+	switch scope {
+	case "", ScopeGlobal:
+		return nil, nil
+	case ScopeFriends:
+		friendIDs, err := s.SocialService.FriendIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+		return append(friendIDs, userID), nil
+	}
+
+	return nil, errInvalidScope
+}
+
+func newUserSummary(users map[int]*user.User, userID int, since time.Time) UserSummary {
+	summary := UserSummary{UserID: userID, Since: since}
+	if u, ok := users[userID]; ok {
+		summary.Username = u.Username
+		summary.ProfilePicture = u.ProfilePicID
+	}
+
+	return summary
+}
@@ -0,0 +1,135 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/api"
+	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestFriends(t *testing.T) {
+	server := setupTestServer(t)
+	aliceToken := signInAs(t, server, "alice", user.RoleStudent)
+	bobToken := signInAs(t, server, "bob", user.RoleStudent)
+	signInAs(t, server, "carol", user.RoleStudent)
+
+	for username, amount := range map[string]int{"alice": 10, "bob": 20, "carol": 30} {
+		u, err := server.UserService.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.PointsService.AwardPointsForCorrectAnswer(u.ID, "algorithms", "introduction", "ex"+username, 1, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.PointsService.AdjustPoints(u.ID, amount, "seed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Request And Accept", func(t *testing.T) {
+		if rr := serve(server, http.MethodPost, "/api/friends/bob", aliceToken, nil); rr.Code != http.StatusCreated {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(server, http.MethodPost, "/api/friends/bob", aliceToken, nil); rr.Code != http.StatusConflict {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusConflict)
+		}
+		if rr := serve(server, http.MethodPost, "/api/friends/nobody", aliceToken, nil); rr.Code != http.StatusNotFound {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusNotFound)
+		}
+
+		rr := serve(server, http.MethodGet, "/api/friends", bobToken, nil)
+		var friends api.FriendsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &friends); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(friends.Incoming) != 1 || friends.Incoming[0].Username != "alice" || len(friends.Friends) != 0 {
+			t.Errorf("expected a request from alice, got %+v", friends)
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/friends/alice/accept", bobToken, nil); rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Friends Leaderboard", func(t *testing.T) {
+		rr := serve(server, http.MethodGet, "/api/leaderboard?scope=friends", aliceToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var leaderboard api.LeaderboardResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &leaderboard); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if leaderboard.Scope != api.ScopeFriends || len(leaderboard.Entries) != 2 || leaderboard.Entries[0].Username != "bob" {
+			t.Errorf("expected bob ahead of alice and no carol, got %+v", leaderboard)
+		}
+		if leaderboard.Me == nil || leaderboard.Me.Rank != 2 {
+			t.Errorf("expected alice second among friends, got %+v", leaderboard.Me)
+		}
+
+		if rr := serve(server, http.MethodGet, "/api/leaderboard?scope=class", aliceToken, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Activity", func(t *testing.T) {
+		rr := serve(server, http.MethodGet, "/api/activity?scope=friends", aliceToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		var activity []api.ActivityEntry
+		if err := json.Unmarshal(rr.Body.Bytes(), &activity); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, entry := range activity {
+			if entry.Username == "carol" || entry.PointTransaction == nil {
+				t.Errorf("unexpected friends activity: %+v", entry)
+			}
+		}
+		if len(activity) != 2 {
+			t.Errorf("expected the correct answers of alice and bob without admin adjustments, got %d entries", len(activity))
+		}
+	})
+
+	t.Run("Blocking Hides Activity", func(t *testing.T) {
+		if rr := serve(server, http.MethodPost, "/api/blocks/carol", aliceToken, nil); rr.Code != http.StatusNoContent {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		rr := serve(server, http.MethodGet, "/api/activity", aliceToken, nil)
+		var activity []api.ActivityEntry
+		if err := json.Unmarshal(rr.Body.Bytes(), &activity); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, entry := range activity {
+			if entry.Username == "carol" {
+				t.Errorf("expected carol's activity to be hidden, got %+v", entry)
+			}
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/friends/alice", mustToken(t, server, "carol"), nil); rr.Code != http.StatusForbidden {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusForbidden)
+		}
+	})
+}
+
+// mustToken starts a new session for an existing user
+func mustToken(t *testing.T, server *api.Server, username string) string {
+	t.Helper()
+
+	u, err := server.UserService.Get(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := server.SessionService.CreateSession(u.ID, session.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return created.Token
+}
@@ -2,16 +2,27 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
 )
 
 // Stats response types
 type DailyStreakResponse struct {
 	CurrentStreak   int    `json:"currentStreak"`
 	MaxStreak       int    `json:"maxStreak"`
-	LastLoginDate   string `json:"lastLoginDate,omitempty"`
+	Timezone        string `json:"timezone"`
+	LastActiveDate  string `json:"lastActiveDate,omitempty"` // The last day that counted, in Timezone
 	NextMilestone   int    `json:"nextMilestone,omitempty"`
 	DaysToMilestone int    `json:"daysToMilestone,omitempty"`
+	Freezes         int    `json:"freezes"`
+	// Set while a broken streak can still be bought back with POST /api/stats/daily-streak/repair
+	RepairableStreak int        `json:"repairableStreak,omitempty"`
+	RepairDeadline   *time.Time `json:"repairDeadline,omitempty"`
+	RepairCost       int        `json:"repairCost,omitempty"`
 }
 
 type AccuracyResponse struct {
@@ -29,7 +40,7 @@ func (s *Server) handleGetDailyStreak() http.HandlerFunc {
 			return
 		}
 
-		streakInfo, err := s.PointsService.GetDailyStreak(userID)
+		streakInfo, err := s.PointsService.GetDailyStreak(userID, s.GetLocation(r.Context()))
 		if err != nil {
 			s.logger.Error("Failed to get daily streak", "error", err)
 			http.Error(w, "Failed to get daily streak information", http.StatusInternalServerError)
@@ -37,14 +48,19 @@ func (s *Server) handleGetDailyStreak() http.HandlerFunc {
 		}
 
 		response := DailyStreakResponse{
-			CurrentStreak:   streakInfo.CurrentStreak,
-			MaxStreak:       streakInfo.MaxStreak,
-			NextMilestone:   streakInfo.NextMilestone,
-			DaysToMilestone: streakInfo.DaysToMilestone,
+			CurrentStreak:    streakInfo.CurrentStreak,
+			MaxStreak:        streakInfo.MaxStreak,
+			Timezone:         streakInfo.Timezone,
+			NextMilestone:    streakInfo.NextMilestone,
+			DaysToMilestone:  streakInfo.DaysToMilestone,
+			Freezes:          streakInfo.Freezes,
+			RepairableStreak: streakInfo.RepairableStreak,
+			RepairDeadline:   streakInfo.RepairDeadline,
+			RepairCost:       streakInfo.RepairCost,
 		}
 
-		if !streakInfo.LastLoginDate.IsZero() {
-			response.LastLoginDate = streakInfo.LastLoginDate.Format("2006-01-02")
+		if !streakInfo.LastActiveDate.IsZero() {
+			response.LastActiveDate = streakInfo.LastActiveDate.Format("2006-01-02")
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -56,6 +72,96 @@ func (s *Server) handleGetDailyStreak() http.HandlerFunc {
 	}
 }
 
+// POST /api/stats/daily-streak/freezes buys a streak freeze with points
+func (s *Server) handleBuyStreakFreeze() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		transaction, err := s.PointsService.BuyStreakFreeze(userID, s.PointsService.GetPointsConfig().StreakFreezeCost)
+		if err != nil {
+			s.writeStreakPurchaseError(w, "buy a streak freeze", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(transaction); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+// POST /api/stats/daily-streak/repair buys back a daily streak that broke recently
+func (s *Server) handleRepairDailyStreak() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		transaction, err := s.PointsService.RepairDailyStreak(userID, s.GetLocation(r.Context()))
+		if err != nil {
+			s.writeStreakPurchaseError(w, "repair the daily streak", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(transaction); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
+func (s *Server) writeStreakPurchaseError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, points.ErrInsufficientPoints):
+		http.Error(w, "Not enough points", http.StatusConflict)
+	case errors.Is(err, points.ErrFreezeLimit):
+		http.Error(w, "Already holding the most streak freezes allowed", http.StatusConflict)
+	case errors.Is(err, points.ErrNothingToRepair):
+		http.Error(w, "No broken streak to repair", http.StatusConflict)
+	default:
+		s.logger.Error("Failed to "+action, "error", err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+// GET /api/stats/daily-activity?limit=30 lists the caller's recent days and why each one counted toward the streak
+func (s *Server) handleGetDailyActivity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := s.GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "User not found in context", http.StatusUnauthorized)
+			return
+		}
+
+		limit := 30
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err == nil && parsedLimit > 0 {
+				limit = min(parsedLimit, maxDailyActivityLimit)
+			}
+		}
+
+		activity, err := s.PointsService.ListDailyActivity(userID, limit)
+		if err != nil {
+			s.logger.Error("Failed to get daily activity", "error", err)
+			http.Error(w, "Failed to get daily activity", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(activity); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
 // GET /api/stats/accuracy
 func (s *Server) handleGetAccuracyStats() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -13,7 +13,7 @@ import (
 
 	visionpb "cloud.google.com/go/vision/v2/apiv1/visionpb" // Import the correct protobuf package path
 
-	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/session"
 	"github.com/tylerolson/capstone-backend/services/user"
 )
 
@@ -35,10 +35,26 @@ type SignInRequest struct {
 }
 
 type SignInResponse struct {
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Token     string `json:"token"`
-	ExpiresAt string `json:"expiresAt"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email"`
+	Role             user.Role `json:"role"`
+	Timezone         string    `json:"timezone"`
+	Token            string    `json:"token"`
+	ExpiresAt        string    `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt string    `json:"refreshExpiresAt"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RefreshTokenResponse replaces both tokens; the old refresh token can't be used again
+type RefreshTokenResponse struct {
+	Token            string `json:"token"`
+	ExpiresAt        string `json:"expiresAt"`
+	RefreshToken     string `json:"refreshToken"`
+	RefreshExpiresAt string `json:"refreshExpiresAt"`
 }
 
 // Request/response types for profile pictures
@@ -50,6 +66,15 @@ type ProfilePicResponse struct {
 	ProfilePicID string `json:"profilePicId"`
 }
 
+// TimezoneRequest sets the IANA timezone, e.g. "America/Los_Angeles", that daily streaks are counted in
+type TimezoneRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+type TimezoneResponse struct {
+	Timezone string `json:"timezone"`
+}
+
 type RequestPasswordResetRequest struct {
 	Email string `json:"email"`
 }
@@ -144,48 +169,25 @@ func (s *Server) handleSignIn() http.HandlerFunc {
 			return
 		}
 
-		session, err := s.SessionService.CreateSession(user.ID)
+		session, err := s.SessionService.CreateSession(user.ID, clientInfo(r))
 		if err != nil {
 			s.logger.Debug("Sign in authentication failed", "error", err)
 			http.Error(w, "Could not create session", http.StatusInternalServerError)
 			return
 		}
 
-		// Update daily streak
-		transaction, err := s.PointsService.UpdateDailyStreak(user.ID)
-		if err != nil {
-			s.logger.Error("Failed to update daily streak", "error", err)
-			// Don't fail the whole sign-in process if streak update fails
-		}
-
 		// Prepare response
 		response := SignInResponse{
-			Username:  user.Username,
-			Email:     user.Email,
-			Token:     session.Token,
-			ExpiresAt: session.ExpiresAt.Format(time.RFC3339),
-		}
-
-		// If streak update resulted in points, include that info
-		if transaction != nil {
-			response := struct {
-				SignInResponse
-				StreakTransaction *points.PointTransaction `json:"streakTransaction,omitempty"`
-			}{
-				SignInResponse:    response,
-				StreakTransaction: transaction,
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(response); err != nil {
-				s.logger.Error("Error encoding sign in response", "err", err)
-				http.Error(w, "Error encoding response", http.StatusInternalServerError)
-				return
-			}
-			return
+			Username:         user.Username,
+			Email:            user.Email,
+			Role:             user.Role,
+			Timezone:         user.Timezone,
+			Token:            session.Token,
+			ExpiresAt:        session.ExpiresAt.Format(time.RFC3339),
+			RefreshToken:     session.RefreshToken,
+			RefreshExpiresAt: session.RefreshExpiresAt.Format(time.RFC3339),
 		}
 
-		// Regular response without streak transaction
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			s.logger.Error("Error encoding sign in response", "err", err)
@@ -195,6 +197,49 @@ func (s *Server) handleSignIn() http.HandlerFunc {
 	}
 }
 
+// handleRefreshToken swaps a refresh token for a new access token and refresh token
+func (s *Server) handleRefreshToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request RefreshTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.RefreshToken == "" {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		refreshed, err := s.SessionService.Refresh(request.RefreshToken, clientInfo(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, session.ErrRefreshTokenReused):
+				s.logger.Warn("Refresh token was reused, revoked its sessions")
+				// Whose sessions they were isn't known here, so nothing cached can be trusted
+				s.identities.clear()
+				http.Error(w, "Refresh token was already used, please sign in again", http.StatusUnauthorized)
+			case errors.Is(err, session.ErrInvalidRefreshToken):
+				http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+			default:
+				s.logger.Error("Failed to refresh session", "error", err)
+				http.Error(w, "Could not refresh session", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		// The session that was swapped out may still be cached
+		s.identities.removeUser(refreshed.UserID)
+
+		response := RefreshTokenResponse{
+			Token:            refreshed.Token,
+			ExpiresAt:        refreshed.ExpiresAt.Format(time.RFC3339),
+			RefreshToken:     refreshed.RefreshToken,
+			RefreshExpiresAt: refreshed.RefreshExpiresAt.Format(time.RFC3339),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			s.logger.Error("Error encoding refresh response", "err", err)
+		}
+	}
+}
+
 // handleLogout ends a user session
 func (s *Server) handleLogout() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -212,6 +257,7 @@ func (s *Server) handleLogout() http.HandlerFunc {
 			http.Error(w, "Failed to logout", http.StatusInternalServerError)
 			return
 		}
+		s.identities.removeToken(token)
 
 		w.WriteHeader(http.StatusOK)
 	}
@@ -283,6 +329,19 @@ func (s *Server) handleUpdateProfilePic() http.HandlerFunc {
 			return
 		}
 
+		// Premium avatars have to be bought in the shop first
+		userID, _ := s.GetUserID(r.Context())
+		allowed, err := s.ShopService.CanUseProfilePic(userID, req.ProfilePicID)
+		if err != nil {
+			s.logger.Error("Error checking profile pic", "error", err)
+			http.Error(w, "Failed to update profile picture", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Buy this avatar in the shop first", http.StatusForbidden)
+			return
+		}
+
 		// Update profile pic
 		err = s.UserService.UpdateProfilePic(username, req.ProfilePicID)
 		if err != nil {
@@ -306,6 +365,43 @@ func (s *Server) handleUpdateProfilePic() http.HandlerFunc {
 	}
 }
 
+// PUT /api/users/timezone
+func (s *Server) handleUpdateTimezone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := s.GetUsername(r.Context())
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req TimezoneRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// The last streak day is kept as the date it counted on, so switching zones doesn't move it
+		err := s.UserService.SetTimezone(username, req.Timezone)
+		if errors.Is(err, user.ErrInvalidTimezone) {
+			http.Error(w, "Unknown timezone, expected an IANA name such as America/Los_Angeles", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			s.logger.Error("Error updating timezone", "error", err)
+			http.Error(w, "Failed to update timezone", http.StatusInternalServerError)
+			return
+		}
+		if userID, ok := s.GetUserID(r.Context()); ok {
+			s.identities.removeUser(userID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(TimezoneResponse(req)); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
+		}
+	}
+}
+
 // handleUploadProfilePic handles custom image uploads
 func (s *Server) handleUploadProfilePic() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -513,13 +609,23 @@ func (s *Server) handleResetPassword() http.HandlerFunc {
 			return
 		}
 
-		err := s.UserService.ResetPassword(req.Token, req.NewPassword)
+		user, err := s.UserService.ResetPassword(req.Token, req.NewPassword)
 		if err != nil {
 			s.logger.Error("Failed to reset password", "error", err)
 			http.Error(w, "Failed to reset password", http.StatusBadRequest)
 			return
 		}
 
+		// Whoever knew the old password is signed out too
+		ended, err := s.SessionService.DeleteUserSessions(user.ID, "")
+		s.identities.removeUser(user.ID)
+		if err != nil {
+			s.logger.Error("Failed to end sessions after password reset", "userID", user.ID, "error", err)
+			http.Error(w, "Password was reset, but signing out other devices failed", http.StatusInternalServerError)
+			return
+		}
+		s.logger.Info("Password reset, ended sessions", "userID", user.ID, "sessions", ended)
+
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "Password has been reset successfully",
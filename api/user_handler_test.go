@@ -7,53 +7,67 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/tylerolson/capstone-backend/api"
 	"github.com/tylerolson/capstone-backend/course"
-	"github.com/tylerolson/capstone-backend/db"
+	"github.com/tylerolson/capstone-backend/services/achievements"
+	"github.com/tylerolson/capstone-backend/services/attempt"
+	"github.com/tylerolson/capstone-backend/services/idempotency"
 	"github.com/tylerolson/capstone-backend/services/points"
 	"github.com/tylerolson/capstone-backend/services/progress"
 	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/shop"
+	"github.com/tylerolson/capstone-backend/services/social"
 	"github.com/tylerolson/capstone-backend/services/user"
+	"github.com/tylerolson/capstone-backend/tokenhash"
 
 	"log/slog"
 )
 
 func setupTestServer(t *testing.T) *api.Server {
+	// Initialize course store
+	coursesStore := course.NewJSONStore("../data")
+	if err := coursesStore.LoadCourseDir(); err != nil {
+		t.Fatalf("Failed to load courses: %v", err)
+	}
+
+	return setupTestServerWithCourses(coursesStore)
+}
+
+// setupTestServerWithCourses builds a test server around another course store, such as one that can be edited
+func setupTestServerWithCourses(coursesStore course.Service) *api.Server {
 	logger := slog.New(
 		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 			Level: slog.LevelDebug,
 		}),
 	)
 
-	database, err := db.NewDatabase("dbuser", "dbpassword", "capstone_db", "", "")
-	if err != nil {
-		logger.Error("Failed to connect to database", "error", err)
-		os.Exit(1)
-	}
-
-	// Initialize services with database
-	userService := user.NewService(database)
+	course.SetShuffleKey([]byte("test shuffle key"))
+	tokenhash.SetKey([]byte("test token key"))
 
-	// Initialize course store with database
-	coursesStore := course.NewJSONStore("../data")
-	if err := coursesStore.LoadCourseDir(); err != nil {
-		logger.Error("Failed to load courses", "error", err)
-		os.Exit(1)
-	}
-
-	progressService := progress.NewService(database)
-	sessionService := session.NewService(database)
-	pointsService := points.NewService(database)
+	// In-memory services keep the tests independent of a running database
+	progressService := progress.NewMemoryService()
+	pointsService := points.NewMemoryService()
+	achievementsService := achievements.NewMemoryService(pointsService, progressService, nil)
+	attemptService := attempt.NewMemoryService(progressService, pointsService)
+	idempotencyService := idempotency.NewMemoryService(idempotency.DefaultWindow)
+	socialService := social.NewMemoryService()
+	shopService := shop.NewMemoryService(pointsService)
 
 	// Initialize server with all required dependencies
 	server := api.NewServer(
-		userService,
+		user.NewMemoryService(),
 		coursesStore,
 		progressService,
-		sessionService,
+		session.NewMemoryService(),
 		pointsService,
-		database,
+		achievementsService,
+		attemptService,
+		idempotencyService,
+		socialService,
+		shopService,
+		nil,
 		logger,
 	)
 
@@ -161,3 +175,165 @@ func TestRegisterLoginEndpoint(t *testing.T) {
 		t.Log("Successfully rejected login with incorrect password")
 	})
 }
+
+func TestRefreshToken(t *testing.T) {
+	server := setupTestServer(t)
+	signInAs(t, server, "student", user.RoleStudent)
+
+	rr := serve(server, http.MethodPost, "/api/signin", "", api.SignInRequest{Username: "student", Password: "password123"})
+	var signIn api.SignInResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &signIn); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if signIn.RefreshToken == "" || signIn.RefreshExpiresAt == "" {
+		t.Fatalf("expected a refresh token on sign in, got %s", rr.Body.String())
+	}
+
+	var refreshed api.RefreshTokenResponse
+	t.Run("Refresh", func(t *testing.T) {
+		rr := serve(server, http.MethodPost, "/api/token/refresh", "", api.RefreshTokenRequest{RefreshToken: signIn.RefreshToken})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &refreshed); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		if rr := serve(server, http.MethodGet, "/api/stats/daily-streak", refreshed.Token, nil); rr.Code != http.StatusOK {
+			t.Errorf("expected the new token to work, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodGet, "/api/stats/daily-streak", signIn.Token, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the old token to stop working, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPost, "/api/token/refresh", "", api.RefreshTokenRequest{}); rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Reuse Revokes The Family", func(t *testing.T) {
+		if rr := serve(server, http.MethodPost, "/api/token/refresh", "", api.RefreshTokenRequest{RefreshToken: signIn.RefreshToken}); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected reusing a refresh token to fail, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodGet, "/api/stats/daily-streak", refreshed.Token, nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the family's session to be revoked, got status %v", rr.Code)
+		}
+		if rr := serve(server, http.MethodPost, "/api/token/refresh", "", api.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken}); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the family's refresh token to be revoked, got status %v", rr.Code)
+		}
+	})
+
+	t.Run("Sliding Expiry", func(t *testing.T) {
+		token := mustToken(t, server, "student")
+		almostExpired := time.Now().Add(time.Minute)
+		if err := server.SessionService.ExtendSession(token, almostExpired); err != nil {
+			t.Fatal(err)
+		}
+
+		serve(server, http.MethodGet, "/api/stats/daily-streak", token, nil)
+		if found, err := server.SessionService.GetSessionByToken(token); err != nil || !found.ExpiresAt.Equal(almostExpired) {
+			t.Errorf("expected the expiry to stay put without sliding sessions, got %+v, %v", found, err)
+		}
+
+		server.SlidingSessions = true
+		serve(server, http.MethodGet, "/api/stats/daily-streak", token, nil)
+		found, err := server.SessionService.GetSessionByToken(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if time.Until(found.ExpiresAt) <= session.AccessTokenLifetime/2 {
+			t.Errorf("expected the session to be extended, expires at %v", found.ExpiresAt)
+		}
+	})
+}
+
+func TestDailyStreak(t *testing.T) {
+	server := setupTestServer(t)
+	token := signInAs(t, server, "student", user.RoleStudent)
+
+	getStreak := func(t *testing.T) api.DailyStreakResponse {
+		t.Helper()
+
+		rr := serve(server, http.MethodGet, "/api/stats/daily-streak", token, nil)
+		var streak api.DailyStreakResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &streak); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return streak
+	}
+
+	t.Run("Timezone", func(t *testing.T) {
+		rr := serve(server, http.MethodPut, "/api/users/timezone", token, api.TimezoneRequest{Timezone: "America/Los_Angeles"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+
+		if rr := serve(server, http.MethodPut, "/api/users/timezone", token, api.TimezoneRequest{Timezone: "Nevada/Reno"}); rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Signing In Does Not Count", func(t *testing.T) {
+		rr := serve(server, http.MethodPost, "/api/signin", "", api.SignInRequest{Username: "student", Password: "password123"})
+		var signIn api.SignInResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &signIn); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if signIn.Timezone != "America/Los_Angeles" {
+			t.Errorf("expected the timezone on sign in, got %q", signIn.Timezone)
+		}
+
+		if streak := getStreak(t); streak.Timezone != "America/Los_Angeles" || streak.CurrentStreak != 0 {
+			t.Errorf("expected no streak in Los Angeles time, got %+v", streak)
+		}
+	})
+
+	t.Run("Correct Answer Counts", func(t *testing.T) {
+		rr := serve(server, http.MethodPost, "/api/courses/algorithms/lessons/introduction/exercises/algo_intro_1/attempt", token, map[string]any{"answer": 0})
+		var attempt api.ExerciseAttemptResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &attempt); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if !attempt.IsCorrect || attempt.DailyActivity == nil || attempt.DailyActivity.QualifiedAt == nil {
+			t.Fatalf("expected the correct answer to count the day, got %+v", attempt.DailyActivity)
+		}
+
+		if streak := getStreak(t); streak.CurrentStreak != 1 {
+			t.Errorf("expected a one day streak, got %+v", streak)
+		}
+
+		rr = serve(server, http.MethodGet, "/api/stats/daily-activity", token, nil)
+		var activity []points.DailyActivity
+		if err := json.Unmarshal(rr.Body.Bytes(), &activity); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(activity) != 1 || activity[0].Reason == "" || activity[0].Date != attempt.DailyActivity.Date {
+			t.Errorf("expected today's activity and why it counted, got %+v", activity)
+		}
+	})
+
+	t.Run("Streak Freezes", func(t *testing.T) {
+		if rr := serve(server, http.MethodPost, "/api/stats/daily-streak/freezes", token, nil); rr.Code != http.StatusConflict {
+			t.Errorf("expected buying without enough points to fail, got status %v", rr.Code)
+		}
+
+		u, err := server.UserService.Get("student")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.PointsService.AdjustPoints(u.ID, points.DefaultPointsConfig.StreakFreezeCost, "seed"); err != nil {
+			t.Fatal(err)
+		}
+
+		rr := serve(server, http.MethodPost, "/api/stats/daily-streak/freezes", token, nil)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("got status %v, response: %s", rr.Code, rr.Body.String())
+		}
+		if streak := getStreak(t); streak.Freezes != 1 || streak.RepairDeadline != nil {
+			t.Errorf("expected one freeze and nothing to repair, got %+v", streak)
+		}
+
+		if rr := serve(server, http.MethodPost, "/api/stats/daily-streak/repair", token, nil); rr.Code != http.StatusConflict {
+			t.Errorf("got status %v, want %v", rr.Code, http.StatusConflict)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/jobs"
+	"github.com/tylerolson/capstone-backend/services/idempotency"
+	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+// defaultCleanupInterval is how often expired rows are cleared out when CLEANUP_INTERVAL isn't set
+const defaultCleanupInterval = time.Hour
+
+// cleanupJobs delete the rows that are only ever rejected once they expire, so their tables don't grow forever
+func cleanupJobs(interval time.Duration, sessionService session.Service, userService user.Service, idempotencyService idempotency.Service, logger *slog.Logger) []jobs.Job {
+	return []jobs.Job{
+		{
+			Name:     "purge-expired-sessions",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				sessions, refreshTokens, err := sessionService.PurgeExpired(ctx)
+				if err != nil {
+					return err
+				}
+				logger.Info("Purged expired sessions", "sessions", sessions, "refreshTokens", refreshTokens)
+				return nil
+			},
+		},
+		{
+			Name:     "purge-expired-reset-tokens",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				purged, err := userService.PurgeExpiredResetTokens(ctx)
+				if err != nil {
+					return err
+				}
+				logger.Info("Purged expired password reset tokens", "resetTokens", purged)
+				return nil
+			},
+		},
+		{
+			Name:     "purge-expired-idempotency-keys",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				purged, err := idempotencyService.PurgeExpired(ctx)
+				if err != nil {
+					return err
+				}
+				logger.Info("Purged expired idempotency keys", "idempotencyKeys", purged)
+				return nil
+			},
+		},
+	}
+}
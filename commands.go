@@ -0,0 +1,237 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/db"
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+const usage = `usage: backend [command]
+
+With no command the API server is started.
+
+commands:
+  validate [dir]       check the course files in dir (default ./data) without starting
+  migrate up           apply all pending migrations
+  migrate down [n]     revert the last n migrations (default 1)
+  migrate status       list migrations and when they were applied
+  import-courses [dir] [--draft]
+                       copy the courses in dir (default ./data) into the database,
+                       replacing courses with the same ID; published unless --draft
+  set-role <user> <role>
+                       make a user a student, instructor or admin
+  reconcile-points [--dry-run]
+                       recompute the user, course and lesson point totals from the
+                       transaction ledger, printing every total that drifted`
+
+func runCommand(database *sql.DB, logger *slog.Logger, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(database, logger, args[1:])
+	case "import-courses":
+		return runImportCourses(database, logger, args[1:])
+	case "set-role":
+		return runSetRole(database, logger, args[1:])
+	case "reconcile-points":
+		return runReconcilePoints(database, logger, args[1:])
+	default:
+		fmt.Println(usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func runMigrate(database *sql.DB, logger *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return errors.New("missing migrate direction")
+	}
+
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrateUp(database, logger)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			logger.Info("Reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+
+	default:
+		fmt.Println(usage)
+		return fmt.Errorf("unknown migrate direction %q", args[0])
+	}
+}
+
+// runValidate loads a course directory and prints every problem in it. It doesn't need the database.
+func runValidate(args []string) error {
+	dataDir := "./data"
+	if len(args) > 0 {
+		dataDir = args[0]
+	}
+
+	err := course.NewJSONStore(dataDir).LoadCourseDir()
+	problems := course.Problems(err)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems in %s", len(problems), dataDir)
+	}
+
+	fmt.Printf("%s is valid\n", dataDir)
+	return nil
+}
+
+// logCourseProblems logs each problem in an error returned while loading courses
+func logCourseProblems(logger *slog.Logger, err error) {
+	for _, problem := range course.Problems(err) {
+		logger.Error("Invalid course content", "file", problem.File, "lesson", problem.Lesson, "exercise", problem.Exercise, "problem", problem.Message)
+	}
+}
+
+// runImportCourses seeds the database course store from a directory of JSON courses
+func runImportCourses(database *sql.DB, logger *slog.Logger, args []string) error {
+	dataDir := "./data"
+	status := course.StatusPublished
+	for _, arg := range args {
+		if arg == "--draft" {
+			status = course.StatusDraft
+		} else {
+			dataDir = arg
+		}
+	}
+
+	jsonStore := course.NewJSONStore(dataDir)
+	if err := jsonStore.LoadCourseDir(); err != nil {
+		logCourseProblems(logger, err)
+		return fmt.Errorf("failed to load courses from %s", dataDir)
+	}
+
+	// Unlisted courses are imported too, the database store keeps their visibility
+	courses := jsonStore.AllCourses()
+
+	// New courses are added after the existing ones, so import in a stable order
+	sort.Slice(courses, func(i, j int) bool {
+		return courses[i].ID < courses[j].ID
+	})
+
+	dbStore := course.NewDBStore(database)
+	for _, c := range courses {
+		if err := dbStore.ImportCourse(c, status); err != nil {
+			return fmt.Errorf("failed to import course %s: %w", c.ID, err)
+		}
+		logger.Info("Imported course", "courseID", c.ID, "lessons", len(c.Lessons), "status", status)
+	}
+
+	return nil
+}
+
+// runSetRole changes a user's role. It is how the first admin gets created.
+func runSetRole(database *sql.DB, logger *slog.Logger, args []string) error {
+	if len(args) != 2 {
+		fmt.Println(usage)
+		return errors.New("set-role needs a username and a role")
+	}
+
+	username, role := args[0], user.Role(args[1])
+	if err := user.NewService(database).SetRole(username, role); err != nil {
+		return err
+	}
+
+	logger.Info("Changed user role", "username", username, "role", role)
+	return nil
+}
+
+// runReconcilePoints prints the point totals that disagree with the ledger and repairs them unless --dry-run is given
+func runReconcilePoints(database *sql.DB, logger *slog.Logger, args []string) error {
+	dryRun := false
+	for _, arg := range args {
+		if arg != "--dry-run" {
+			fmt.Println(usage)
+			return fmt.Errorf("unknown reconcile-points option %q", arg)
+		}
+		dryRun = true
+	}
+
+	report, err := points.NewService(database).Reconcile(dryRun)
+	if err != nil {
+		return err
+	}
+
+	unrepaired := 0
+	for _, drift := range report.Drift {
+		fmt.Println(drift)
+		if !drift.Repaired {
+			unrepaired++
+		}
+	}
+
+	switch {
+	case len(report.Drift) == 0:
+		fmt.Println("all point totals match the ledger")
+	case dryRun:
+		fmt.Printf("%d point totals drifted, run without --dry-run to repair them\n", len(report.Drift))
+	case unrepaired > 0:
+		return fmt.Errorf("%d point totals changed while reconciling, run reconcile-points again", unrepaired)
+	default:
+		logger.Info("Repaired point totals", "count", len(report.Drift))
+	}
+
+	return nil
+}
+
+// migrateUp applies all pending migrations, logging each one
+func migrateUp(database *sql.DB, logger *slog.Logger) error {
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	for _, migration := range applied {
+		logger.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	if err == nil && len(applied) == 0 {
+		logger.Debug("Database schema is up to date")
+	}
+
+	return err
+}
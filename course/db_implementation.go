@@ -0,0 +1,746 @@
+package course
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes the store turns into course errors
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// DBStore implements course.Service and course.Editor on top of Postgres
+type DBStore struct {
+	db *sql.DB
+}
+
+func NewDBStore(database *sql.DB) *DBStore {
+	return &DBStore{db: database}
+}
+
+// LoadCourseDir does nothing, the content already lives in the database.
+// Use ImportCourse to seed it from JSON files.
+func (d *DBStore) LoadCourseDir() error {
+	return nil
+}
+
+// LoadCourse does nothing, see LoadCourseDir
+func (d *DBStore) LoadCourse(filename string) error {
+	return nil
+}
+
+func (d *DBStore) ListCourses() ([]*Course, error) {
+	return d.loadCourses(false, "")
+}
+
+func (d *DBStore) GetCourseByID(courseID string) (*Course, error) {
+	return d.loadCourse(false, courseID)
+}
+
+// GetLessonByID reads one published lesson and its exercises, without loading the rest of the course
+func (d *DBStore) GetLessonByID(courseID, lessonID string) (*Lesson, error) {
+	lesson := &Lesson{Exercises: make([]Exercise, 0)}
+	err := d.db.QueryRow(`
+		SELECT l.id, l.title, l.description, l.status, l.difficulty, l.estimated_minutes, l.prerequisites
+		FROM lessons l
+		JOIN courses c ON c.id = l.course_id
+		WHERE l.course_id = $1 AND l.id = $2 AND c.status = 'published' AND l.status = 'published'`,
+		courseID, lessonID).
+		Scan(&lesson.ID, &lesson.Title, &lesson.Description, &lesson.Status,
+			&lesson.Difficulty, &lesson.EstimatedMinutes, pq.Array(&lesson.Prerequisites))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, d.missingLesson(courseID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lesson: %v", err)
+	}
+
+	rows, err := d.db.Query(`
+		SELECT id, content
+		FROM exercises
+		WHERE course_id = $1 AND lesson_id = $2
+		ORDER BY position, id`,
+		courseID, lessonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exercises: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var exerciseID string
+		var content []byte
+		if err := rows.Scan(&exerciseID, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise row: %v", err)
+		}
+
+		exercise, err := decodeExercise(exerciseID, content)
+		if err != nil {
+			return nil, err
+		}
+		lesson.Exercises = append(lesson.Exercises, *exercise)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exercise rows: %v", err)
+	}
+
+	return lesson, nil
+}
+
+// GetExerciseByID reads one exercise of a published lesson
+func (d *DBStore) GetExerciseByID(courseID, lessonID, exerciseID string) (*Exercise, error) {
+	var lessonFound bool
+	var content []byte
+	err := d.db.QueryRow(`
+		SELECT l.id IS NOT NULL, e.content
+		FROM courses c
+		LEFT JOIN lessons l ON l.course_id = c.id AND l.id = $2 AND l.status = 'published'
+		LEFT JOIN exercises e ON e.course_id = l.course_id AND e.lesson_id = l.id AND e.id = $3
+		WHERE c.id = $1 AND c.status = 'published'`,
+		courseID, lessonID, exerciseID).Scan(&lessonFound, &content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrCourseNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to get exercise: %v", err)
+	case !lessonFound:
+		return nil, ErrLessonNotFound
+	case content == nil:
+		return nil, ErrExerciseNotFound
+	}
+
+	return decodeExercise(exerciseID, content)
+}
+
+// missingLesson tells apart a lesson that isn't there from a course that isn't
+func (d *DBStore) missingLesson(courseID string) error {
+	var exists bool
+	err := d.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM courses WHERE id = $1 AND status = 'published')`, courseID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check course: %v", err)
+	}
+	if !exists {
+		return ErrCourseNotFound
+	}
+
+	return ErrLessonNotFound
+}
+
+func (d *DBStore) VerifyExerciseAnswer(courseID, lessonID, exerciseID string, answer interface{}) (bool, error) {
+	exercise, err := d.GetExerciseByID(courseID, lessonID, exerciseID)
+	if err != nil {
+		return false, err
+	}
+
+	return VerifyAnswer(exercise, answer)
+}
+
+func (d *DBStore) ListCoursesForEditing() ([]*Course, error) {
+	return d.loadCourses(true, "")
+}
+
+func (d *DBStore) GetCourseForEditing(courseID string) (*Course, error) {
+	return d.loadCourse(true, courseID)
+}
+
+func (d *DBStore) loadCourse(includeDrafts bool, courseID string) (*Course, error) {
+	courses, err := d.loadCourses(includeDrafts, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(courses) == 0 {
+		return nil, ErrCourseNotFound
+	}
+
+	return courses[0], nil
+}
+
+// loadCourses reads courses with their lessons and exercises in order. An empty courseID loads every course.
+// Without drafts, only published lessons of published courses are returned.
+func (d *DBStore) loadCourses(includeDrafts bool, courseID string) ([]*Course, error) {
+	rows, err := d.db.Query(`
+		SELECT id, name, description, status, visibility, difficulty, estimated_minutes, tags, cover_image, prerequisites
+		FROM courses
+		WHERE ($1::boolean OR status = 'published') AND ($2::text = '' OR id = $2)
+		ORDER BY position, id`,
+		includeDrafts, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query courses: %v", err)
+	}
+	defer rows.Close()
+
+	courses := make([]*Course, 0)
+	byID := make(map[string]*Course)
+	for rows.Next() {
+		course := &Course{Lessons: make([]Lesson, 0)}
+		err := rows.Scan(&course.ID, &course.Name, &course.Description, &course.Status,
+			&course.Visibility, &course.Difficulty, &course.EstimatedMinutes, pq.Array(&course.Tags), &course.CoverImage,
+			pq.Array(&course.Prerequisites))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan course row: %v", err)
+		}
+
+		byID[course.ID] = course
+		// Unlisted courses are only loaded by ID
+		if includeDrafts || courseID != "" || course.Listed() {
+			courses = append(courses, course)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating course rows: %v", err)
+	}
+
+	rows, err = d.db.Query(`
+		SELECT l.course_id, l.id, l.title, l.description, l.status, l.difficulty, l.estimated_minutes, l.prerequisites
+		FROM lessons l
+		JOIN courses c ON c.id = l.course_id
+		WHERE ($1::boolean OR (c.status = 'published' AND l.status = 'published')) AND ($2::text = '' OR l.course_id = $2)
+		ORDER BY l.course_id, l.position, l.id`,
+		includeDrafts, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lessons: %v", err)
+	}
+	defer rows.Close()
+
+	type lessonKey struct{ courseID, lessonID string }
+	lessonIndex := make(map[lessonKey]int)
+	for rows.Next() {
+		var lessonCourseID string
+		lesson := Lesson{Exercises: make([]Exercise, 0)}
+		err := rows.Scan(&lessonCourseID, &lesson.ID, &lesson.Title, &lesson.Description, &lesson.Status,
+			&lesson.Difficulty, &lesson.EstimatedMinutes, pq.Array(&lesson.Prerequisites))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lesson row: %v", err)
+		}
+
+		course, ok := byID[lessonCourseID]
+		if !ok {
+			continue
+		}
+
+		lessonIndex[lessonKey{lessonCourseID, lesson.ID}] = len(course.Lessons)
+		course.Lessons = append(course.Lessons, lesson)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lesson rows: %v", err)
+	}
+
+	rows, err = d.db.Query(`
+		SELECT e.course_id, e.lesson_id, e.id, e.content
+		FROM exercises e
+		JOIN lessons l ON l.course_id = e.course_id AND l.id = e.lesson_id
+		JOIN courses c ON c.id = e.course_id
+		WHERE ($1::boolean OR (c.status = 'published' AND l.status = 'published')) AND ($2::text = '' OR e.course_id = $2)
+		ORDER BY e.course_id, e.lesson_id, e.position, e.id`,
+		includeDrafts, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exercises: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var exerciseCourseID, exerciseLessonID, exerciseID string
+		var content []byte
+		if err := rows.Scan(&exerciseCourseID, &exerciseLessonID, &exerciseID, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise row: %v", err)
+		}
+
+		exercise, err := decodeExercise(exerciseID, content)
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := lessonIndex[lessonKey{exerciseCourseID, exerciseLessonID}]
+		if !ok {
+			continue
+		}
+
+		lesson := &byID[exerciseCourseID].Lessons[i]
+		lesson.Exercises = append(lesson.Exercises, *exercise)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exercise rows: %v", err)
+	}
+
+	return courses, nil
+}
+
+// CreateCourse adds a course after the existing ones, along with any lessons and exercises it contains
+func (d *DBStore) CreateCourse(course *Course) error {
+	return d.inTx(func(tx *sql.Tx) error {
+		return insertCourse(tx, course)
+	})
+}
+
+// UpdateCourse changes the details and status of a course. A course left without a status or
+// visibility keeps the one it has, so editing its details doesn't unpublish it.
+func (d *DBStore) UpdateCourse(courseID string, course *Course) error {
+	course.ID = courseID
+	course.Lessons = nil
+	course.LessonOrder = nil
+	if err := checkCourseFields(course); err != nil {
+		return err
+	}
+
+	err := d.db.QueryRow(`
+		UPDATE courses
+		SET name = $1, description = $2, status = COALESCE(NULLIF($3, ''), status), visibility = COALESCE(NULLIF($4, ''), visibility),
+			difficulty = $5, estimated_minutes = $6, tags = $7, cover_image = $8, prerequisites = $9
+		WHERE id = $10
+		RETURNING status, visibility`,
+		course.Name, course.Description, course.Status, course.Visibility, course.Difficulty,
+		course.EstimatedMinutes, textArray(course.Tags), course.CoverImage, textArray(course.Prerequisites), courseID).
+		Scan(&course.Status, &course.Visibility)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCourseNotFound
+		}
+		return fmt.Errorf("failed to update course: %v", err)
+	}
+
+	return nil
+}
+
+func (d *DBStore) DeleteCourse(courseID string) error {
+	result, err := d.db.Exec(`DELETE FROM courses WHERE id = $1`, courseID)
+	if err != nil {
+		return fmt.Errorf("failed to delete course: %v", err)
+	}
+
+	return requireRow(result, ErrCourseNotFound)
+}
+
+func (d *DBStore) ReorderCourses(courseIDs []string) error {
+	return d.inTx(func(tx *sql.Tx) error {
+		return reorder(tx, `SELECT id FROM courses`, `UPDATE courses SET position = $1 WHERE id = $2`, courseIDs)
+	})
+}
+
+// CreateLesson adds a lesson, with any exercises it contains, to the end of a course
+func (d *DBStore) CreateLesson(courseID string, lesson *Lesson) error {
+	return d.inTx(func(tx *sql.Tx) error {
+		var position int
+		err := tx.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM lessons WHERE course_id = $1`, courseID).Scan(&position)
+		if err != nil {
+			return fmt.Errorf("failed to get lesson position: %v", err)
+		}
+
+		return insertLesson(tx, courseID, lesson, position)
+	})
+}
+
+// UpdateLesson changes the details and status of a lesson. A lesson left without a status keeps the one it has.
+func (d *DBStore) UpdateLesson(courseID, lessonID string, lesson *Lesson) error {
+	lesson.ID = lessonID
+	lesson.Exercises = nil
+	if err := checkLessonFields(lesson); err != nil {
+		return err
+	}
+
+	err := d.db.QueryRow(`
+		UPDATE lessons
+		SET title = $1, description = $2, status = COALESCE(NULLIF($3, ''), status), difficulty = $4, estimated_minutes = $5, prerequisites = $6
+		WHERE course_id = $7 AND id = $8
+		RETURNING status`,
+		lesson.Title, lesson.Description, lesson.Status, lesson.Difficulty, lesson.EstimatedMinutes,
+		textArray(lesson.Prerequisites), courseID, lessonID).
+		Scan(&lesson.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLessonNotFound
+		}
+		return fmt.Errorf("failed to update lesson: %v", err)
+	}
+
+	return nil
+}
+
+func (d *DBStore) DeleteLesson(courseID, lessonID string) error {
+	result, err := d.db.Exec(`DELETE FROM lessons WHERE course_id = $1 AND id = $2`, courseID, lessonID)
+	if err != nil {
+		return fmt.Errorf("failed to delete lesson: %v", err)
+	}
+
+	return requireRow(result, ErrLessonNotFound)
+}
+
+func (d *DBStore) ReorderLessons(courseID string, lessonIDs []string) error {
+	return d.inTx(func(tx *sql.Tx) error {
+		if err := requireCourse(tx, courseID); err != nil {
+			return err
+		}
+
+		return reorder(tx,
+			`SELECT id FROM lessons WHERE course_id = $1`,
+			`UPDATE lessons SET position = $1 WHERE id = $2 AND course_id = $3`,
+			lessonIDs, courseID)
+	})
+}
+
+// CreateExercise adds an exercise to the end of a lesson
+func (d *DBStore) CreateExercise(courseID, lessonID string, exercise *Exercise) error {
+	return d.inTx(func(tx *sql.Tx) error {
+		var position int
+		err := tx.QueryRow(`
+			SELECT COALESCE(MAX(position), 0) + 1
+			FROM exercises
+			WHERE course_id = $1 AND lesson_id = $2`,
+			courseID, lessonID).Scan(&position)
+		if err != nil {
+			return fmt.Errorf("failed to get exercise position: %v", err)
+		}
+
+		return insertExercise(tx, courseID, lessonID, exercise, position)
+	})
+}
+
+// UpdateExercise replaces the content of an exercise, keeping its ID and position
+func (d *DBStore) UpdateExercise(courseID, lessonID, exerciseID string, exercise *Exercise) error {
+	exercise.ID = exerciseID
+	if err := checkExerciseFields(exercise); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(exercise)
+	if err != nil {
+		return fmt.Errorf("failed to encode exercise: %v", err)
+	}
+
+	result, err := d.db.Exec(`
+		UPDATE exercises
+		SET type = $1, content = $2
+		WHERE course_id = $3 AND lesson_id = $4 AND id = $5`,
+		exercise.Type, content, courseID, lessonID, exerciseID)
+	if err != nil {
+		return fmt.Errorf("failed to update exercise: %v", err)
+	}
+
+	return requireRow(result, ErrExerciseNotFound)
+}
+
+func (d *DBStore) DeleteExercise(courseID, lessonID, exerciseID string) error {
+	result, err := d.db.Exec(`
+		DELETE FROM exercises
+		WHERE course_id = $1 AND lesson_id = $2 AND id = $3`,
+		courseID, lessonID, exerciseID)
+	if err != nil {
+		return fmt.Errorf("failed to delete exercise: %v", err)
+	}
+
+	return requireRow(result, ErrExerciseNotFound)
+}
+
+func (d *DBStore) ReorderExercises(courseID, lessonID string, exerciseIDs []string) error {
+	return d.inTx(func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM lessons WHERE course_id = $1 AND id = $2)`, courseID, lessonID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check lesson: %v", err)
+		}
+		if !exists {
+			return ErrLessonNotFound
+		}
+
+		return reorder(tx,
+			`SELECT id FROM exercises WHERE course_id = $1 AND lesson_id = $2`,
+			`UPDATE exercises SET position = $1 WHERE id = $2 AND course_id = $3 AND lesson_id = $4`,
+			exerciseIDs, courseID, lessonID)
+	})
+}
+
+// ImportCourse replaces a course and everything in it, keeping its place in the course order
+func (d *DBStore) ImportCourse(course *Course, status Status) error {
+	imported := *course
+	imported.Status = status
+	imported.Lessons = make([]Lesson, len(course.Lessons))
+	for i, lesson := range course.Lessons {
+		lesson.Status = status
+		imported.Lessons[i] = lesson
+	}
+
+	return d.inTx(func(tx *sql.Tx) error {
+		var position sql.NullInt64
+		err := tx.QueryRow(`DELETE FROM courses WHERE id = $1 RETURNING position`, course.ID).Scan(&position)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to delete existing course: %v", err)
+		}
+
+		if err := insertCourse(tx, &imported); err != nil {
+			return err
+		}
+
+		if position.Valid {
+			_, err = tx.Exec(`UPDATE courses SET position = $1 WHERE id = $2`, position.Int64, course.ID)
+			if err != nil {
+				return fmt.Errorf("failed to restore course position: %v", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// decodeExercise reads an exercise from its stored content, which doesn't repeat the ID
+func decodeExercise(exerciseID string, content []byte) (*Exercise, error) {
+	var exercise Exercise
+	if err := json.Unmarshal(content, &exercise); err != nil {
+		return nil, fmt.Errorf("failed to decode exercise %s: %v", exerciseID, err)
+	}
+	exercise.ID = exerciseID
+
+	return &exercise, nil
+}
+
+func (d *DBStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func insertCourse(tx *sql.Tx, course *Course) error {
+	if err := checkCourseFields(course); err != nil {
+		return err
+	}
+
+	course.Status = statusOrDraft(course.Status)
+	course.Visibility = visibilityOrPublic(course.Visibility)
+	course.LessonOrder = nil
+	_, err := tx.Exec(`
+		INSERT INTO courses (id, name, description, status, visibility, difficulty, estimated_minutes, tags, cover_image, prerequisites, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, (SELECT COALESCE(MAX(position), 0) + 1 FROM courses))`,
+		course.ID, course.Name, course.Description, course.Status, course.Visibility, course.Difficulty,
+		course.EstimatedMinutes, textArray(course.Tags), course.CoverImage, textArray(course.Prerequisites))
+	if err != nil {
+		if isPQError(err, uniqueViolation) {
+			return fmt.Errorf("%w: course %s", ErrDuplicateID, course.ID)
+		}
+		return fmt.Errorf("failed to insert course: %v", err)
+	}
+
+	for i := range course.Lessons {
+		if err := insertLesson(tx, course.ID, &course.Lessons[i], i+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertLesson(tx *sql.Tx, courseID string, lesson *Lesson, position int) error {
+	if err := checkLessonFields(lesson); err != nil {
+		return err
+	}
+
+	lesson.Status = statusOrDraft(lesson.Status)
+	_, err := tx.Exec(`
+		INSERT INTO lessons (course_id, id, title, description, status, difficulty, estimated_minutes, prerequisites, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		courseID, lesson.ID, lesson.Title, lesson.Description, lesson.Status, lesson.Difficulty, lesson.EstimatedMinutes,
+		textArray(lesson.Prerequisites), position)
+	if err != nil {
+		switch {
+		case isPQError(err, uniqueViolation):
+			return fmt.Errorf("%w: lesson %s", ErrDuplicateID, lesson.ID)
+		case isPQError(err, foreignKeyViolation):
+			return ErrCourseNotFound
+		}
+		return fmt.Errorf("failed to insert lesson: %v", err)
+	}
+
+	for i := range lesson.Exercises {
+		if err := insertExercise(tx, courseID, lesson.ID, &lesson.Exercises[i], i+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertExercise(tx *sql.Tx, courseID, lessonID string, exercise *Exercise, position int) error {
+	if err := checkExerciseFields(exercise); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(exercise)
+	if err != nil {
+		return fmt.Errorf("failed to encode exercise: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO exercises (course_id, lesson_id, id, type, content, position)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		courseID, lessonID, exercise.ID, exercise.Type, content, position)
+	if err != nil {
+		switch {
+		case isPQError(err, uniqueViolation):
+			return fmt.Errorf("%w: exercise %s", ErrDuplicateID, exercise.ID)
+		case isPQError(err, foreignKeyViolation):
+			return ErrLessonNotFound
+		}
+		return fmt.Errorf("failed to insert exercise: %v", err)
+	}
+
+	return nil
+}
+
+// reorder sets positions to match ids, which must list every row returned by existingQuery exactly once.
+// scope fills the placeholders after $1 in existingQuery and after $2 in updateQuery.
+func reorder(tx *sql.Tx, existingQuery, updateQuery string, ids []string, scope ...any) error {
+	rows, err := tx.Query(existingQuery, scope...)
+	if err != nil {
+		return fmt.Errorf("failed to query current order: %v", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan id: %v", err)
+		}
+		existing[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating ids: %v", err)
+	}
+
+	if len(ids) != len(existing) {
+		return ErrInvalidOrder
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if !existing[id] || seen[id] {
+			return ErrInvalidOrder
+		}
+		seen[id] = true
+	}
+
+	for i, id := range ids {
+		args := append([]any{i + 1, id}, scope...)
+		if _, err := tx.Exec(updateQuery, args...); err != nil {
+			return fmt.Errorf("failed to update position: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func requireCourse(tx *sql.Tx, courseID string) error {
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM courses WHERE id = $1)`, courseID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check course: %v", err)
+	}
+	if !exists {
+		return ErrCourseNotFound
+	}
+
+	return nil
+}
+
+// requireRow returns notFound when result didn't touch any rows
+func requireRow(result sql.Result, notFound error) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+func statusOrDraft(status Status) Status {
+	if status == "" {
+		return StatusDraft
+	}
+
+	return status
+}
+
+// textArray encodes a list for a NOT NULL text[] column, pq would store a nil slice as NULL
+func textArray(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+
+	return pq.Array(values)
+}
+
+func visibilityOrPublic(visibility Visibility) Visibility {
+	if visibility == "" {
+		return VisibilityPublic
+	}
+
+	return visibility
+}
+
+func checkCourseFields(course *Course) error {
+	problems := ValidateCourse(course)
+	if err := checkStatus(course.Status); err != nil {
+		problems = append(problems, Problem{Message: err.Error()})
+	}
+
+	return problemsError(problems)
+}
+
+func checkLessonFields(lesson *Lesson) error {
+	problems := ValidateLesson(lesson)
+	if err := checkStatus(lesson.Status); err != nil {
+		problems = append(problems, Problem{Lesson: lesson.ID, Message: err.Error()})
+	}
+
+	return problemsError(problems)
+}
+
+func checkExerciseFields(exercise *Exercise) error {
+	var problems []Problem
+	for _, message := range ValidateExercise(exercise) {
+		problems = append(problems, Problem{Exercise: exercise.ID, Message: message})
+	}
+
+	return problemsError(problems)
+}
+
+func checkStatus(status Status) error {
+	switch status {
+	case "", StatusDraft, StatusPublished:
+		return nil
+	}
+
+	return fmt.Errorf("unknown status %q", status)
+}
+
+func problemsError(problems []Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Problems: problems}
+}
@@ -0,0 +1,169 @@
+package course_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/course"
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+)
+
+func TestDBStoreAuthoring(t *testing.T) {
+	store := course.NewDBStore(dbtest.Open(t))
+
+	courseID := dbtest.UniqueName("course")
+	t.Cleanup(func() { store.DeleteCourse(courseID) })
+
+	err := store.CreateCourse(&course.Course{
+		ID:   courseID,
+		Name: "Test Course",
+		Lessons: []course.Lesson{
+			{ID: "one", Title: "One", Exercises: []course.Exercise{
+				{ID: "q1", Type: course.ExerciseTypeTrueFalse, Question: "True?", CorrectAnswer: true},
+			}},
+			{ID: "two", Title: "Two"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetCourseByID(courseID); !errors.Is(err, course.ErrCourseNotFound) {
+		t.Errorf("expected draft course to be hidden, got %v", err)
+	}
+
+	if err := store.UpdateCourse(courseID, &course.Course{Name: "Test Course", Status: course.StatusPublished}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateLesson(courseID, "two", &course.Lesson{Title: "Two", Status: course.StatusPublished}); err != nil {
+		t.Fatal(err)
+	}
+
+	published, err := store.GetCourseByID(courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published.Lessons) != 1 || published.Lessons[0].ID != "two" {
+		t.Errorf("expected only the published lesson, got %+v", published.Lessons)
+	}
+
+	// Leaving the status out of an update keeps the course and lesson published
+	edited := &course.Course{Name: "Test Course", Description: "Fixed a typo"}
+	if err := store.UpdateCourse(courseID, edited); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateLesson(courseID, "two", &course.Lesson{Title: "Two", Description: "Fixed a typo"}); err != nil {
+		t.Fatal(err)
+	}
+	if edited.Status != course.StatusPublished {
+		t.Errorf("expected the update to report the kept status, got %q", edited.Status)
+	}
+	if published, err := store.GetCourseByID(courseID); err != nil || len(published.Lessons) != 1 {
+		t.Errorf("expected the course and lesson to stay published, got %v", err)
+	}
+
+	if lesson, err := store.GetLessonByID(courseID, "two"); err != nil || lesson.Description != "Fixed a typo" {
+		t.Errorf("expected the published lesson, got %+v, %v", lesson, err)
+	}
+	if _, err := store.GetLessonByID(courseID, "one"); !errors.Is(err, course.ErrLessonNotFound) {
+		t.Errorf("expected a draft lesson to be hidden, got %v", err)
+	}
+	if _, err := store.GetLessonByID(courseID+"_missing", "two"); !errors.Is(err, course.ErrCourseNotFound) {
+		t.Errorf("expected ErrCourseNotFound, got %v", err)
+	}
+	if _, err := store.GetExerciseByID(courseID, "one", "q1"); !errors.Is(err, course.ErrLessonNotFound) {
+		t.Errorf("expected an exercise of a draft lesson to be hidden, got %v", err)
+	}
+
+	if err := store.ReorderLessons(courseID, []string{"two"}); !errors.Is(err, course.ErrInvalidOrder) {
+		t.Errorf("expected ErrInvalidOrder, got %v", err)
+	}
+	if err := store.ReorderLessons(courseID, []string{"two", "one"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.CreateExercise(courseID, "one", &course.Exercise{ID: "q2", Type: course.ExerciseTypeFillBlank, Question: "Say hi", CorrectAnswer: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.CreateExercise(courseID, "one", &course.Exercise{ID: "q2", Type: course.ExerciseTypeFillBlank, Question: "Again", CorrectAnswer: "hi"})
+	if !errors.Is(err, course.ErrDuplicateID) {
+		t.Errorf("expected ErrDuplicateID, got %v", err)
+	}
+	if err := store.ReorderExercises(courseID, "one", []string{"q2", "q1"}); err != nil {
+		t.Fatal(err)
+	}
+	err = store.UpdateExercise(courseID, "one", "q1", &course.Exercise{Type: course.ExerciseTypeTrueFalse, Question: "False?", CorrectAnswer: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	editing, err := store.GetCourseForEditing(courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(editing.Lessons) != 2 || editing.Lessons[0].ID != "two" || editing.Lessons[1].ID != "one" {
+		t.Fatalf("unexpected lesson order: %+v", editing.Lessons)
+	}
+	exercises := editing.Lessons[1].Exercises
+	if len(exercises) != 2 || exercises[0].ID != "q2" || exercises[1].Question != "False?" {
+		t.Errorf("unexpected exercises: %+v", exercises)
+	}
+
+	if err := store.DeleteLesson(courseID, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetExerciseByID(courseID, "one", "q1"); err == nil {
+		t.Error("expected exercises to be deleted with their lesson")
+	}
+}
+
+func TestDBStoreImport(t *testing.T) {
+	store := course.NewDBStore(dbtest.Open(t))
+
+	jsonStore := course.NewJSONStore("../data")
+	if err := jsonStore.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := jsonStore.GetCourseByID("algorithms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported := *want
+	imported.ID = dbtest.UniqueName("algorithms")
+	t.Cleanup(func() { store.DeleteCourse(imported.ID) })
+
+	// Importing twice replaces the course instead of failing on duplicate IDs
+	for range 2 {
+		if err := store.ImportCourse(&imported, course.StatusPublished); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.GetCourseByID(imported.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Difficulty != want.Difficulty || !slices.Equal(got.Tags, want.Tags) || got.Visibility != course.VisibilityPublic {
+		t.Errorf("expected metadata to be imported, got %+v", got)
+	}
+	if len(got.Lessons) != len(want.Lessons) {
+		t.Fatalf("expected %d lessons, got %d", len(want.Lessons), len(got.Lessons))
+	}
+
+	for i, lesson := range want.Lessons {
+		for j, exercise := range lesson.Exercises {
+			if got.Lessons[i].Exercises[j].ID != exercise.ID {
+				t.Errorf("expected exercise %s at %s[%d], got %s", exercise.ID, lesson.ID, j, got.Lessons[i].Exercises[j].ID)
+			}
+		}
+	}
+
+	correct, err := store.VerifyExerciseAnswer(imported.ID, "introduction", "algo_intro_1", float64(0))
+	if err != nil || !correct {
+		t.Errorf("expected imported answer key to verify, got %v, %v", correct, err)
+	}
+}
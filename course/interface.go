@@ -1,5 +1,40 @@
 package course
 
+import "errors"
+
+var (
+	ErrCourseNotFound   = errors.New("course not found")
+	ErrLessonNotFound   = errors.New("lesson not found")
+	ErrExerciseNotFound = errors.New("exercise not found")
+	ErrDuplicateID      = errors.New("id already exists")
+	ErrInvalidContent   = errors.New("invalid course content")
+	ErrInvalidOrder     = errors.New("order must list every item exactly once")
+)
+
+// Status controls whether learners can see a course or lesson
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusPublished Status = "published"
+)
+
+// Visibility controls whether a published course is listed. Unlisted courses can still be opened by ID.
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"
+	VisibilityUnlisted Visibility = "unlisted"
+)
+
+type Difficulty string
+
+const (
+	DifficultyBeginner     Difficulty = "beginner"
+	DifficultyIntermediate Difficulty = "intermediate"
+	DifficultyAdvanced     Difficulty = "advanced"
+)
+
 // Constants for exercise types
 type ExerciseType string
 
@@ -12,17 +47,52 @@ const (
 )
 
 type Course struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Status           Status     `json:"status,omitempty"`
+	Visibility       Visibility `json:"visibility,omitempty"`
+	Difficulty       Difficulty `json:"difficulty,omitempty"`
+	EstimatedMinutes int        `json:"estimatedMinutes,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+	CoverImage       string     `json:"coverImage,omitempty"`
+	// Prerequisites are the IDs of courses that have to be completed before any lesson of this one
+	Prerequisites []string `json:"prerequisites,omitempty"`
+	// LessonOrder lists lesson IDs in the order they're taught. It's only read from root.json,
+	// where it overrides the order of the lesson file name prefixes.
+	LessonOrder []string `json:"lessonOrder,omitempty"`
 	Lessons     []Lesson `json:"lessons"`
 }
 
 type Lesson struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Exercises   []Exercise `json:"exercises"`
+	ID               string     `json:"id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	Status           Status     `json:"status,omitempty"`
+	Difficulty       Difficulty `json:"difficulty,omitempty"`
+	EstimatedMinutes int        `json:"estimatedMinutes,omitempty"`
+	// Prerequisites are the IDs of lessons in the same course that have to be completed first
+	Prerequisites []string   `json:"prerequisites,omitempty"`
+	Exercises     []Exercise `json:"exercises"`
+}
+
+// Listed reports whether the course belongs in the course list
+func (c *Course) Listed() bool {
+	return c.Visibility == "" || c.Visibility == VisibilityPublic
+}
+
+// TotalMinutes is the course's estimated time, or the sum of its lessons' when it doesn't give one
+func (c *Course) TotalMinutes() int {
+	if c.EstimatedMinutes > 0 {
+		return c.EstimatedMinutes
+	}
+
+	total := 0
+	for _, lesson := range c.Lessons {
+		total += lesson.EstimatedMinutes
+	}
+
+	return total
 }
 
 type Exercise struct {
@@ -38,9 +108,11 @@ type Exercise struct {
 
 type Service interface {
 	// Course Management
+	// ListCourses returns the listed courses, always in the same order
 	ListCourses() ([]*Course, error)
 	GetCourseByID(courseID string) (*Course, error)
 	GetLessonByID(courseID, lessonID string) (*Lesson, error)
+	GetExerciseByID(courseID, lessonID, exerciseID string) (*Exercise, error)
 
 	// Progress Tracking
 
@@ -51,3 +123,29 @@ type Service interface {
 	LoadCourse(filename string) error
 	LoadCourseDir() error
 }
+
+// Editor is implemented by course stores that can be changed at runtime. Unlike the Service
+// methods, which only return published content, its reads include drafts. New courses and
+// lessons start out as drafts unless a status is given, and updates without one keep the status they had.
+type Editor interface {
+	ListCoursesForEditing() ([]*Course, error)
+	GetCourseForEditing(courseID string) (*Course, error)
+
+	CreateCourse(course *Course) error
+	UpdateCourse(courseID string, course *Course) error
+	DeleteCourse(courseID string) error
+	ReorderCourses(courseIDs []string) error
+
+	CreateLesson(courseID string, lesson *Lesson) error
+	UpdateLesson(courseID, lessonID string, lesson *Lesson) error
+	DeleteLesson(courseID, lessonID string) error
+	ReorderLessons(courseID string, lessonIDs []string) error
+
+	CreateExercise(courseID, lessonID string, exercise *Exercise) error
+	UpdateExercise(courseID, lessonID, exerciseID string, exercise *Exercise) error
+	DeleteExercise(courseID, lessonID, exerciseID string) error
+	ReorderExercises(courseID, lessonID string, exerciseIDs []string) error
+
+	// ImportCourse replaces a course and everything in it, giving every part the same status
+	ImportCourse(course *Course, status Status) error
+}
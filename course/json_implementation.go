@@ -1,257 +1,394 @@
 package course
 
 import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-// JSONStore implements the course.Service interface
+// JSONStore implements the course.Service interface. Courses are reloaded as a whole
+// and swapped in under the lock, so readers always see a complete set. Reloads hold
+// reloadMu throughout, so one that started earlier can't swap in older courses over a later one.
 type JSONStore struct {
-	courses map[string]*Course
-	dataDir string
+	reloadMu sync.Mutex
+	mu       sync.RWMutex
+	courses  map[string]*Course // keyed by course ID
+	byDir    map[string]*Course // keyed by course directory, to fall back on when a reload fails
+	dataDir  string
 }
 
 func NewJSONStore(dataDir string) *JSONStore {
 	return &JSONStore{
 		courses: make(map[string]*Course),
+		byDir:   make(map[string]*Course),
 		dataDir: dataDir,
 	}
 }
 
-// LoadCourseDir loads all courses from the data directory
+// LoadCourseDir (re)loads all courses from the data directory. A course that fails to load
+// keeps its last good version, and the errors for every failed course are returned together.
+// A directory whose course ID another directory already uses fails the same way.
+// If the courses require a course that doesn't exist, none of the changes are loaded.
 func (j *JSONStore) LoadCourseDir() error {
+	j.reloadMu.Lock()
+	defer j.reloadMu.Unlock()
+
 	// Read all course directories
 	entries, err := os.ReadDir(j.dataDir)
 	if err != nil {
 		return err
 	}
 
+	j.mu.RLock()
+	previous := j.byDir
+	j.mu.RUnlock()
+
+	courses := make(map[string]*Course)
+	byDir := make(map[string]*Course)
+	dirOf := make(map[string]string) // course ID -> the directory it was loaded from
+	var errs []error
 	for _, entry := range entries {
-		if entry.IsDir() {
-			if err := j.loadCourseFromDir(entry.Name()); err != nil {
-				return err
+		if !entry.IsDir() {
+			continue
+		}
+
+		course, err := j.readCourseDir(entry.Name())
+		if err == nil {
+			// Directories are read in name order, so the first one to use an ID keeps it
+			if first, ok := dirOf[course.ID]; ok {
+				err = &ValidationError{Problems: []Problem{{
+					File:    filepath.Join(j.dataDir, entry.Name(), "root.json"),
+					Message: fmt.Sprintf("course id %q is already used by %s", course.ID, first),
+				}}}
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+
+			// The last good version is kept only while its ID is still free
+			last, ok := previous[entry.Name()]
+			if !ok || dirOf[last.ID] != "" {
+				continue
 			}
+			course = last
 		}
+
+		courses[course.ID] = course
+		byDir[entry.Name()] = course
+		dirOf[course.ID] = entry.Name()
 	}
 
-	return nil
+	// A prerequisite on a missing course can't be blamed on one directory, so the whole reload is rejected
+	if problems := validateCoursePrerequisites(courses); len(problems) > 0 {
+		errs = append(errs, &ValidationError{Problems: problems})
+		return errors.Join(errs...)
+	}
+
+	j.mu.Lock()
+	j.courses = courses
+	j.byDir = byDir
+	j.mu.Unlock()
+
+	return errors.Join(errs...)
 }
 
-func (j *JSONStore) loadCourseFromDir(courseID string) error {
+// readCourseDir reads a course and its lessons from a directory under dataDir. Files that don't parse
+// and invalid content are reported together as a *ValidationError.
+func (j *JSONStore) readCourseDir(dir string) (*Course, error) {
 	// Load root.json first
-	rootPath := filepath.Join(j.dataDir, courseID, "root.json")
+	rootPath := filepath.Join(j.dataDir, dir, "root.json")
 	var course Course
 
 	data, err := os.ReadFile(rootPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var problems []Problem
 	if err := json.Unmarshal(data, &course); err != nil {
-		return err
+		problems = append(problems, Problem{File: rootPath, Message: err.Error()})
 	}
 
 	// Load all lesson files
-	lessonFiles, err := filepath.Glob(filepath.Join(j.dataDir, courseID, "*.json"))
+	lessonFiles, err := filepath.Glob(filepath.Join(j.dataDir, dir, "*.json"))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	sortLessonFiles(lessonFiles)
 
 	lessons := make([]Lesson, 0)
+	files := make([]string, 0)
 	for _, file := range lessonFiles {
 		if filepath.Base(file) != "root.json" {
 			data, err := os.ReadFile(file)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			var lesson Lesson
 			if err := json.Unmarshal(data, &lesson); err != nil {
-				return err
+				problems = append(problems, Problem{File: file, Message: err.Error()})
+				continue
 			}
 			lessons = append(lessons, lesson)
+			files = append(files, file)
 		}
 	}
 
 	course.Lessons = lessons
-	j.courses[course.ID] = &course
-
-	return nil
-}
-
-func (j *JSONStore) ListCourses() ([]*Course, error) {
-	courses := make([]*Course, 0)
-	for _, course := range j.courses {
-		courses = append(courses, course)
+	if len(course.LessonOrder) > 0 {
+		if err := orderLessons(&course, files); err != nil {
+			problems = append(problems, Problem{File: rootPath, Message: err.Error()})
+		}
+	}
+	problems = append(problems, validateCourse(&course, rootPath, files)...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
 
-	return courses, nil
+	return &course, nil
 }
 
-func (j *JSONStore) GetCourseByID(courseID string) (*Course, error) {
-	course, ok := j.courses[courseID]
-	if !ok {
-		return nil, errors.New("course not found")
-	}
+// sortLessonFiles sorts lesson files by the number their name starts with, so 10_x comes after 2_x.
+// Files without a number come last, in name order.
+func sortLessonFiles(files []string) {
+	slices.SortStableFunc(files, func(a, b string) int {
+		aPosition, aOK := filePosition(a)
+		bPosition, bOK := filePosition(b)
+		switch {
+		case aOK && bOK && aPosition != bPosition:
+			return cmp.Compare(aPosition, bPosition)
+		case aOK != bOK:
+			if aOK {
+				return -1
+			}
+			return 1
+		}
 
-	return course, nil
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
 }
 
-func (j *JSONStore) GetLessonByID(courseID string, lessonID string) (*Lesson, error) {
-	course, ok := j.courses[courseID]
-	if !ok {
-		return nil, errors.New("course not found")
+// filePosition parses the numeric prefix of a file name like 01_introduction.json
+func filePosition(file string) (int, bool) {
+	name := filepath.Base(file)
+	digits := len(name) - len(strings.TrimLeft(name, "0123456789"))
+	if digits == 0 {
+		return 0, false
 	}
 
-	for i := range course.Lessons {
-		if course.Lessons[i].ID == lessonID {
-			return &course.Lessons[i], nil
-		}
+	position, err := strconv.Atoi(name[:digits])
+	if err != nil {
+		return 0, false
 	}
 
-	return nil, errors.New("lesson not found")
+	return position, true
 }
 
-func (j *JSONStore) LoadCourse(filename string) error {
-	return j.loadCourseFromDir(filepath.Base(filepath.Dir(filename)))
-}
+// orderLessons puts the lessons, and the files they came from, in the order given by root.json.
+// The order has to list every lesson exactly once.
+func orderLessons(course *Course, files []string) error {
+	index := make(map[string]int, len(course.Lessons))
+	for i, lesson := range course.Lessons {
+		index[lesson.ID] = i
+	}
 
-func (j *JSONStore) VerifyExerciseAnswer(courseID, lessonID, exerciseID string, answer interface{}) (bool, error) {
-	course, ok := j.courses[courseID]
-	if !ok {
-		return false, errors.New("course not found")
+	if len(course.LessonOrder) != len(course.Lessons) {
+		return fmt.Errorf("lessonOrder lists %d lessons but the course has %d", len(course.LessonOrder), len(course.Lessons))
 	}
 
-	var targetExercise *Exercise
-	for _, lesson := range course.Lessons {
-		if lesson.ID == lessonID {
-			for _, ex := range lesson.Exercises {
-				if ex.ID == exerciseID {
-					targetExercise = &ex
-					break
-				}
-			}
-			break
+	lessons := make([]Lesson, 0, len(course.Lessons))
+	lessonFiles := make([]string, 0, len(files))
+	seen := make(map[string]bool)
+	for _, id := range course.LessonOrder {
+		i, ok := index[id]
+		if !ok {
+			return fmt.Errorf("lessonOrder lists unknown lesson %q", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("lessonOrder lists lesson %q more than once", id)
 		}
+		seen[id] = true
+
+		lessons = append(lessons, course.Lessons[i])
+		lessonFiles = append(lessonFiles, files[i])
 	}
 
-	if targetExercise == nil {
-		return false, errors.New("exercise not found")
+	course.Lessons = lessons
+	copy(files, lessonFiles)
+	return nil
+}
+
+// Watch polls the data directory every interval and reloads the courses when a file changes,
+// until ctx is done. Failed reloads are logged and the last good courses keep being served.
+func (j *JSONStore) Watch(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	last, err := j.fingerprint()
+	if err != nil {
+		logger.Warn("Failed to read course directory", "dir", j.dataDir, "error", err)
 	}
 
-	switch targetExercise.Type {
-	case ExerciseTypeMultipleChoice:
-		if choiceIdx, ok := answer.(float64); ok {
-			correctAnswer, ok := targetExercise.CorrectAnswer.(float64)
-			if !ok {
-				log.Printf("Invalid correct answer format for multiple choice. Expected float64, got %T", targetExercise.CorrectAnswer)
-				return false, errors.New("invalid correct answer format for multiple choice")
-			}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-			return choiceIdx == correctAnswer, nil
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
-		log.Printf("Invalid answer format for multiple choice. Expected float64, got %T", answer)
-		return false, errors.New("invalid answer format for multiple choice")
-
-	case ExerciseTypeTrueFalse:
-		if boolAnswer, ok := answer.(bool); ok {
-			log.Printf("True/False verification - Answer: %v, Correct: %v", boolAnswer, targetExercise.CorrectAnswer)
-			return boolAnswer == targetExercise.CorrectAnswer.(bool), nil
+		current, err := j.fingerprint()
+		if err != nil {
+			logger.Warn("Failed to read course directory", "dir", j.dataDir, "error", err)
+			continue
 		}
-		return false, errors.New("invalid answer format for true/false")
+		if current == last {
+			continue
+		}
+		last = current
 
-	case ExerciseTypeMatching:
-		answerPairs, ok := answer.([]interface{})
-		if !ok {
-			log.Printf("Invalid answer format for matching. Got type: %T", answer)
-			return false, errors.New("invalid answer format for matching")
+		if err := j.LoadCourseDir(); err != nil {
+			for _, problem := range Problems(err) {
+				logger.Error("Rejected course changes, serving the last good version", "problem", problem.String())
+			}
+			continue
 		}
-		return verifyMatchingAnswer(answerPairs, targetExercise.Pairs)
 
-	case ExerciseTypeOrdering:
-		answerOrder, ok := answer.([]interface{})
-		if !ok {
-			return false, errors.New("invalid answer format for ordering")
+		logger.Info("Reloaded courses", "dir", j.dataDir)
+	}
+}
+
+// fingerprint summarizes the names, sizes and modification times of everything under dataDir
+func (j *JSONStore) fingerprint() (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(j.dataDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-		return verifyOrderingAnswer(answerOrder, targetExercise.CorrectOrder)
 
-	case ExerciseTypeFillBlank:
-		userAnswer, ok := answer.(string)
-		if !ok {
-			log.Printf("Invalid answer format for fill_blank. Got type: %T", answer)
-			return false, errors.New("invalid answer format for fill blank")
+		info, err := entry.Info()
+		if err != nil {
+			return err
 		}
-		correctAnswer, ok := targetExercise.CorrectAnswer.(string)
-		if !ok {
-			log.Printf("Invalid correct answer format for fill_blank. Expected string, got %T", targetExercise.CorrectAnswer)
-			return false, errors.New("invalid correct answer format for fill blank")
+
+		fmt.Fprintf(hash, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ListCourses returns the listed courses in the order of their directory names
+func (j *JSONStore) ListCourses() ([]*Course, error) {
+	courses := make([]*Course, 0)
+	for _, course := range j.AllCourses() {
+		if course.Listed() {
+			courses = append(courses, course)
 		}
-		// Case-insensitive comparison
-		return strings.EqualFold(strings.TrimSpace(userAnswer), strings.TrimSpace(correctAnswer)), nil
+	}
+
+	return courses, nil
+}
 
-	default:
-		return false, errors.New("unsupported exercise type")
+// AllCourses returns every loaded course, unlisted ones included, in the order of their directory names
+func (j *JSONStore) AllCourses() []*Course {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	courses := make([]*Course, 0, len(j.byDir))
+	for _, dir := range slices.Sorted(maps.Keys(j.byDir)) {
+		courses = append(courses, j.byDir[dir])
 	}
+
+	return courses
 }
 
-// Helper functions for exercise verification
-func verifyMatchingAnswer(answer []interface{}, correctPairs [][]string) (bool, error) {
-	log.Printf("Verifying matching answer. Got: %v, Expected: %v", answer, correctPairs)
+func (j *JSONStore) GetCourseByID(courseID string) (*Course, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
 
-	if len(answer) != len(correctPairs) {
-		log.Printf("Length mismatch. Answer length: %d, Expected length: %d", len(answer), len(correctPairs))
-		return false, nil
+	course, ok := j.courses[courseID]
+	if !ok {
+		return nil, ErrCourseNotFound
 	}
 
-	// Convert answer pairs to a map for easier verification
-	answerMap := make(map[string]string)
-	for _, pair := range answer {
-		answerPair, ok := pair.([]interface{})
-		if !ok || len(answerPair) != 2 {
-			log.Printf("Invalid pair format: %v", pair)
-			return false, errors.New("invalid matching pair format")
-		}
+	return course, nil
+}
 
-		term, ok1 := answerPair[0].(string)
-		definition, ok2 := answerPair[1].(string)
-		if !ok1 || !ok2 {
-			log.Printf("Invalid pair values: %v", answerPair)
-			return false, errors.New("invalid matching pair values")
+func (j *JSONStore) GetLessonByID(courseID string, lessonID string) (*Lesson, error) {
+	course, err := j.GetCourseByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range course.Lessons {
+		if course.Lessons[i].ID == lessonID {
+			return &course.Lessons[i], nil
 		}
+	}
+
+	return nil, ErrLessonNotFound
+}
 
-		answerMap[term] = definition
+func (j *JSONStore) GetExerciseByID(courseID, lessonID, exerciseID string) (*Exercise, error) {
+	lesson, err := j.GetLessonByID(courseID, lessonID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check each correct pair
-	for _, pair := range correctPairs {
-		if answerMap[pair[0]] != pair[1] {
-			log.Printf("Mismatch found. Term: %s, Expected: %s, Got: %s",
-				pair[0], pair[1], answerMap[pair[0]])
-			return false, nil
+	for i := range lesson.Exercises {
+		if lesson.Exercises[i].ID == exerciseID {
+			return &lesson.Exercises[i], nil
 		}
 	}
 
-	return true, nil
+	return nil, ErrExerciseNotFound
 }
 
-func verifyOrderingAnswer(answer []interface{}, correctOrder []int) (bool, error) {
-	if len(answer) != len(correctOrder) {
-		return false, nil
+func (j *JSONStore) LoadCourse(filename string) error {
+	j.reloadMu.Lock()
+	defer j.reloadMu.Unlock()
+
+	dir := filepath.Base(filepath.Dir(filename))
+	course, err := j.readCourseDir(dir)
+	if err != nil {
+		return err
 	}
 
-	for i, val := range answer {
-		idx, ok := val.(float64)
-		if !ok {
-			return false, errors.New("invalid ordering value format")
-		}
-		if int(idx) != correctOrder[i] {
-			return false, nil
-		}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if previous, ok := j.byDir[dir]; ok {
+		delete(j.courses, previous.ID)
+	}
+	j.courses[course.ID] = course
+	j.byDir[dir] = course
+
+	return nil
+}
+
+func (j *JSONStore) VerifyExerciseAnswer(courseID, lessonID, exerciseID string, answer interface{}) (bool, error) {
+	exercise, err := j.GetExerciseByID(courseID, lessonID, exerciseID)
+	if err != nil {
+		return false, err
 	}
-	return true, nil
+
+	return VerifyAnswer(exercise, answer)
 }
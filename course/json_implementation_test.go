@@ -0,0 +1,316 @@
+package course_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/course"
+)
+
+// copyDataDir copies the course content into a temporary directory the test can edit
+func copyDataDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS("../data")); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lessonTitle(t *testing.T, store *course.JSONStore, courseID, lessonID string) string {
+	t.Helper()
+
+	lesson, err := store.GetLessonByID(courseID, lessonID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return lesson.Title
+}
+
+const replacementLesson = `{"id": "introduction", "title": "Edited", "description": "", "exercises": []}`
+
+func TestReloadKeepsLastGoodCourse(t *testing.T) {
+	dir := copyDataDir(t)
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	lessonPath := filepath.Join(dir, "algorithms", "01_introduction.json")
+	writeFile(t, lessonPath, `{"id": "introduction", "title": "Broken`)
+
+	if err := store.LoadCourseDir(); err == nil {
+		t.Fatal("expected the broken lesson to be rejected")
+	}
+	if title := lessonTitle(t, store, "algorithms", "introduction"); title != "Introduction" {
+		t.Errorf("expected the last good lesson to keep serving, got %q", title)
+	}
+
+	writeFile(t, lessonPath, replacementLesson)
+
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+	if title := lessonTitle(t, store, "algorithms", "introduction"); title != "Edited" {
+		t.Errorf("expected the fixed lesson to be loaded, got %q", title)
+	}
+}
+
+func TestReloadRejectsUnknownPrerequisite(t *testing.T) {
+	dir := copyDataDir(t)
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "algorithms", "01_introduction.json"), replacementLesson)
+	writeFile(t, filepath.Join(dir, "algorithms", "root.json"), `{"id": "algorithms", "name": "Algorithms", "prerequisites": ["missing"]}`)
+
+	if err := store.LoadCourseDir(); err == nil {
+		t.Fatal("expected the unknown prerequisite to be rejected")
+	}
+	if title := lessonTitle(t, store, "algorithms", "introduction"); title != "Introduction" {
+		t.Errorf("expected the last good courses to keep serving, got %q", title)
+	}
+	if algorithms, err := store.GetCourseByID("algorithms"); err != nil || len(algorithms.Prerequisites) != 0 {
+		t.Errorf("expected the last good course without prerequisites, got %+v, %v", algorithms, err)
+	}
+}
+
+func TestReloadRejectsPrerequisiteCycle(t *testing.T) {
+	dir := copyDataDir(t)
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "algorithms", "root.json"), `{"id": "algorithms", "name": "Algorithms", "prerequisites": ["data_structures"]}`)
+	writeFile(t, filepath.Join(dir, "data_structures", "root.json"), `{"id": "data_structures", "name": "Data Structures", "prerequisites": ["algorithms"]}`)
+
+	err := store.LoadCourseDir()
+	if err == nil || !strings.Contains(err.Error(), "course prerequisites form a cycle") {
+		t.Fatalf("expected the cycle to be rejected, got %v", err)
+	}
+	if algorithms, err := store.GetCourseByID("algorithms"); err != nil || len(algorithms.Prerequisites) != 0 {
+		t.Errorf("expected the last good course without prerequisites, got %+v, %v", algorithms, err)
+	}
+}
+
+func TestLoadRejectsDuplicateCourseID(t *testing.T) {
+	dir := copyDataDir(t)
+	if err := os.CopyFS(filepath.Join(dir, "zz_algorithms"), os.DirFS(filepath.Join(dir, "algorithms"))); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "zz_algorithms", "01_introduction.json"), replacementLesson)
+
+	store := course.NewJSONStore(dir)
+	problems := course.Problems(store.LoadCourseDir())
+	want := course.Problem{File: filepath.Join(dir, "zz_algorithms", "root.json"), Message: `course id "algorithms" is already used by algorithms`}
+	if !slices.Equal(problems, []course.Problem{want}) {
+		t.Errorf("expected the second directory to be rejected, got %v", problems)
+	}
+
+	courses, err := store.ListCourses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 3 {
+		t.Errorf("expected each course once, got %d courses", len(courses))
+	}
+	if title := lessonTitle(t, store, "algorithms", "introduction"); title != "Introduction" {
+		t.Errorf("expected the first directory's course, got lesson %q", title)
+	}
+}
+
+func TestReloadRemovesDeletedCourse(t *testing.T) {
+	dir := copyDataDir(t)
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, "algorithms")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetCourseByID("algorithms"); err == nil {
+		t.Error("expected the deleted course to be gone")
+	}
+}
+
+func TestConcurrentReload(t *testing.T) {
+	store := course.NewJSONStore("../data")
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if err := store.LoadCourseDir(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if _, err := store.GetExerciseByID("algorithms", "introduction", "algo_intro_1"); err != nil {
+					t.Error(err)
+				}
+				if courses, _ := store.ListCourses(); len(courses) != 3 {
+					t.Errorf("expected 3 courses, got %d", len(courses))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestWatchReloadsChangedFiles(t *testing.T) {
+	dir := copyDataDir(t)
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Give the watcher time to take its first fingerprint
+	time.Sleep(50 * time.Millisecond)
+	writeFile(t, filepath.Join(dir, "algorithms", "01_introduction.json"), replacementLesson)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for lessonTitle(t, store, "algorithms", "introduction") != "Edited" {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the watcher to reload")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func lessonIDs(t *testing.T, store *course.JSONStore, courseID string) []string {
+	t.Helper()
+
+	c, err := store.GetCourseByID(courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, len(c.Lessons))
+	for i, lesson := range c.Lessons {
+		ids[i] = lesson.ID
+	}
+
+	return ids
+}
+
+func TestLessonsFollowFilePrefixes(t *testing.T) {
+	dir := copyDataDir(t)
+	courseDir := filepath.Join(dir, "programming_basics")
+	if err := os.Rename(filepath.Join(courseDir, "01_introduction.json"), filepath.Join(courseDir, "10_introduction.json")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(courseDir, "appendix.json"), `{"id": "appendix", "title": "Appendix", "description": "", "exercises": []}`)
+
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"variables", "functions", "programming-basics", "loops", "practice", "introduction", "appendix"}
+	if got := lessonIDs(t, store, "programming_basics"); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRootLessonOrder(t *testing.T) {
+	dir := copyDataDir(t)
+	rootPath := filepath.Join(dir, "programming_basics", "root.json")
+	writeFile(t, rootPath, `{"id": "programming_basics", "name": "Programming Basics", "description": "",
+		"lessonOrder": ["practice", "loops", "programming-basics", "functions", "variables", "introduction"]}`)
+
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"practice", "loops", "programming-basics", "functions", "variables", "introduction"}
+	if got := lessonIDs(t, store, "programming_basics"); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	writeFile(t, rootPath, `{"id": "programming_basics", "name": "Programming Basics", "description": "",
+		"lessonOrder": ["practice", "loops", "programming-basics", "functions", "variables", "missing"]}`)
+
+	problems := course.Problems(store.LoadCourseDir())
+	if len(problems) != 1 || problems[0].File != rootPath || !strings.Contains(problems[0].Message, `unknown lesson "missing"`) {
+		t.Errorf("expected an unknown lesson problem in root.json, got %v", problems)
+	}
+}
+
+func TestListCoursesIsOrderedAndSkipsUnlisted(t *testing.T) {
+	dir := copyDataDir(t)
+	writeFile(t, filepath.Join(dir, "data_structures", "root.json"),
+		`{"id": "data_structures", "name": "Data Structures", "description": "", "visibility": "unlisted"}`)
+
+	store := course.NewJSONStore(dir)
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	courses, err := store.ListCourses()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, c := range courses {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"algorithms", "programming_basics"}; !slices.Equal(ids, want) {
+		t.Errorf("expected %v, got %v", want, ids)
+	}
+
+	if _, err := store.GetCourseByID("data_structures"); err != nil {
+		t.Errorf("expected unlisted course to open by ID, got %v", err)
+	}
+
+	ids = nil
+	for _, c := range store.AllCourses() {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"algorithms", "data_structures", "programming_basics"}; !slices.Equal(ids, want) {
+		t.Errorf("expected every course including unlisted ones, got %v", ids)
+	}
+}
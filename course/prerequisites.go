@@ -0,0 +1,173 @@
+package course
+
+// LessonState is how a lesson looks to one user on the course map
+type LessonState string
+
+const (
+	LessonLocked    LessonState = "locked"
+	LessonAvailable LessonState = "available"
+	LessonCompleted LessonState = "completed"
+)
+
+// Completion is the set of courses and lessons a user has completed
+type Completion struct {
+	courses map[string]bool
+	lessons map[string]map[string]bool
+}
+
+func NewCompletion() *Completion {
+	return &Completion{
+		courses: make(map[string]bool),
+		lessons: make(map[string]map[string]bool),
+	}
+}
+
+func (c *Completion) AddCourse(courseID string) {
+	c.courses[courseID] = true
+}
+
+func (c *Completion) AddLesson(courseID, lessonID string) {
+	if c.lessons[courseID] == nil {
+		c.lessons[courseID] = make(map[string]bool)
+	}
+	c.lessons[courseID][lessonID] = true
+}
+
+func (c *Completion) CourseCompleted(courseID string) bool {
+	return c.courses[courseID]
+}
+
+func (c *Completion) LessonCompleted(courseID, lessonID string) bool {
+	return c.lessons[courseID][lessonID]
+}
+
+// Missing lists the prerequisites a user still has to complete
+type Missing struct {
+	Courses []string `json:"courses,omitempty"`
+	Lessons []string `json:"lessons,omitempty"`
+}
+
+func (m Missing) Empty() bool {
+	return len(m.Courses) == 0 && len(m.Lessons) == 0
+}
+
+// MissingForCourse returns the prerequisite courses of a course that haven't been completed
+func MissingForCourse(course *Course, done *Completion) Missing {
+	var missing Missing
+	for _, courseID := range course.Prerequisites {
+		if !done.CourseCompleted(courseID) {
+			missing.Courses = append(missing.Courses, courseID)
+		}
+	}
+
+	return missing
+}
+
+// MissingToComplete returns everything that has to be completed before a course counts as completed:
+// its prerequisite courses and every one of its lessons
+func MissingToComplete(course *Course, done *Completion) Missing {
+	missing := MissingForCourse(course, done)
+	for _, lesson := range course.Lessons {
+		if !done.LessonCompleted(course.ID, lesson.ID) {
+			missing.Lessons = append(missing.Lessons, lesson.ID)
+		}
+	}
+
+	return missing
+}
+
+// MissingForLesson returns everything that has to be completed before a lesson unlocks,
+// including the prerequisites of its course
+func MissingForLesson(course *Course, lesson *Lesson, done *Completion) Missing {
+	missing := MissingForCourse(course, done)
+	for _, lessonID := range lesson.Prerequisites {
+		if !done.LessonCompleted(course.ID, lessonID) {
+			missing.Lessons = append(missing.Lessons, lessonID)
+		}
+	}
+
+	return missing
+}
+
+type LessonMapEntry struct {
+	ID      string      `json:"id"`
+	Title   string      `json:"title"`
+	State   LessonState `json:"state"`
+	Missing *Missing    `json:"missing,omitempty"`
+}
+
+// CourseMap shows every lesson of a course as locked, available or completed for one user
+type CourseMap struct {
+	CourseID string           `json:"courseId"`
+	Locked   bool             `json:"locked"`
+	Missing  *Missing         `json:"missing,omitempty"`
+	Lessons  []LessonMapEntry `json:"lessons"`
+}
+
+func NewCourseMap(course *Course, done *Completion) CourseMap {
+	courseMap := CourseMap{
+		CourseID: course.ID,
+		Lessons:  make([]LessonMapEntry, 0, len(course.Lessons)),
+	}
+
+	if missing := MissingForCourse(course, done); !missing.Empty() {
+		courseMap.Locked = true
+		courseMap.Missing = &missing
+	}
+
+	for i := range course.Lessons {
+		lesson := &course.Lessons[i]
+		entry := LessonMapEntry{ID: lesson.ID, Title: lesson.Title}
+
+		if missing := MissingForLesson(course, lesson, done); !missing.Empty() {
+			entry.State = LessonLocked
+			entry.Missing = &missing
+		} else if done.LessonCompleted(course.ID, lesson.ID) {
+			entry.State = LessonCompleted
+		} else {
+			entry.State = LessonAvailable
+		}
+
+		courseMap.Lessons = append(courseMap.Lessons, entry)
+	}
+
+	return courseMap
+}
+
+// prerequisiteCycle returns an ID whose prerequisites lead back to itself, or "" when there is none.
+// IDs are checked in the given order, so the same one is reported every time.
+func prerequisiteCycle(ids []string, prerequisites map[string][]string) string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		switch state[id] {
+		case visiting:
+			return true
+		case visited:
+			return false
+		}
+
+		state[id] = visiting
+		for _, next := range prerequisites[id] {
+			if visit(next) {
+				return true
+			}
+		}
+		state[id] = visited
+
+		return false
+	}
+
+	for _, id := range ids {
+		if visit(id) {
+			return id
+		}
+	}
+
+	return ""
+}
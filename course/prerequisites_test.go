@@ -0,0 +1,87 @@
+package course_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/course"
+)
+
+func prerequisiteCourse() *course.Course {
+	return &course.Course{
+		ID:            "test",
+		Name:          "Test",
+		Prerequisites: []string{"basics"},
+		Lessons: []course.Lesson{
+			{ID: "one", Title: "One"},
+			{ID: "two", Title: "Two", Prerequisites: []string{"one"}},
+			{ID: "three", Title: "Three", Prerequisites: []string{"one", "two"}},
+		},
+	}
+}
+
+func TestCourseMap(t *testing.T) {
+	c := prerequisiteCourse()
+	done := course.NewCompletion()
+
+	courseMap := course.NewCourseMap(c, done)
+	if !courseMap.Locked || !slices.Equal(courseMap.Missing.Courses, []string{"basics"}) {
+		t.Errorf("expected course to be locked behind basics, got %+v", courseMap)
+	}
+	for _, lesson := range courseMap.Lessons {
+		if lesson.State != course.LessonLocked {
+			t.Errorf("expected %s to be locked with its course, got %s", lesson.ID, lesson.State)
+		}
+	}
+
+	done.AddCourse("basics")
+	done.AddLesson("test", "one")
+	courseMap = course.NewCourseMap(c, done)
+
+	want := []course.LessonState{course.LessonCompleted, course.LessonAvailable, course.LessonLocked}
+	for i, lesson := range courseMap.Lessons {
+		if lesson.State != want[i] {
+			t.Errorf("expected %s to be %s, got %s", lesson.ID, want[i], lesson.State)
+		}
+	}
+	if missing := courseMap.Lessons[2].Missing; missing == nil || !slices.Equal(missing.Lessons, []string{"two"}) {
+		t.Errorf("expected three to be missing two, got %+v", missing)
+	}
+}
+
+func TestMissingToComplete(t *testing.T) {
+	c := prerequisiteCourse()
+	done := course.NewCompletion()
+	done.AddLesson("test", "one")
+
+	missing := course.MissingToComplete(c, done)
+	if !slices.Equal(missing.Courses, []string{"basics"}) || !slices.Equal(missing.Lessons, []string{"two", "three"}) {
+		t.Errorf("expected basics, two and three to be missing, got %+v", missing)
+	}
+
+	done.AddCourse("basics")
+	done.AddLesson("test", "two")
+	done.AddLesson("test", "three")
+	if missing := course.MissingToComplete(c, done); !missing.Empty() {
+		t.Errorf("expected nothing to be missing, got %+v", missing)
+	}
+}
+
+func TestValidatePrerequisites(t *testing.T) {
+	c := prerequisiteCourse()
+	c.Lessons[0].Prerequisites = []string{"three"}
+	c.Lessons[1].Prerequisites = []string{"one", "nope"}
+
+	var messages []string
+	for _, problem := range course.ValidateCourse(c) {
+		messages = append(messages, problem.String())
+	}
+
+	got := strings.Join(messages, "\n")
+	for _, want := range []string{`lesson two: invalid prerequisite lesson "nope"`, "prerequisites form a cycle"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected a problem containing %q, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package course
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Problem is one thing wrong with course content, with as much of its location as is known
+type Problem struct {
+	File     string `json:"file,omitempty"`
+	Lesson   string `json:"lesson,omitempty"`
+	Exercise string `json:"exercise,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (p Problem) String() string {
+	var location []string
+	if p.File != "" {
+		location = append(location, p.File)
+	}
+	if p.Lesson != "" {
+		location = append(location, "lesson "+p.Lesson)
+	}
+	if p.Exercise != "" {
+		location = append(location, "exercise "+p.Exercise)
+	}
+
+	if len(location) == 0 {
+		return p.Message
+	}
+
+	return strings.Join(location, ": ") + ": " + p.Message
+}
+
+// ValidationError holds every problem found in a piece of content. It matches ErrInvalidContent with errors.Is.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = problem.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidContent
+}
+
+// Problems flattens an error returned while loading content into a list of problems.
+// Errors that aren't validation errors, like unreadable files, become problems with only a message.
+func Problems(err error) []Problem {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var problems []Problem
+		for _, err := range joined.Unwrap() {
+			problems = append(problems, Problems(err)...)
+		}
+		return problems
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Problems
+	}
+
+	return []Problem{{Message: err.Error()}}
+}
+
+// ValidateCourse checks a course and all of its lessons
+func ValidateCourse(course *Course) []Problem {
+	return validateCourse(course, "", nil)
+}
+
+// validateCourse checks a course loaded from files. lessonFiles[i] is the file Lessons[i] came from.
+func validateCourse(course *Course, rootFile string, lessonFiles []string) []Problem {
+	var problems []Problem
+	if course.ID == "" {
+		problems = append(problems, Problem{File: rootFile, Message: "course id is required"})
+	}
+	if course.Name == "" {
+		problems = append(problems, Problem{File: rootFile, Message: "course name is required"})
+	}
+	for _, message := range validateMetadata(course.Difficulty, course.EstimatedMinutes) {
+		problems = append(problems, Problem{File: rootFile, Message: message})
+	}
+	switch course.Visibility {
+	case "", VisibilityPublic, VisibilityUnlisted:
+	default:
+		problems = append(problems, Problem{File: rootFile, Message: fmt.Sprintf("unknown visibility %q", course.Visibility)})
+	}
+	for _, tag := range course.Tags {
+		if strings.TrimSpace(tag) == "" {
+			problems = append(problems, Problem{File: rootFile, Message: "tags can't be empty"})
+			break
+		}
+	}
+
+	for _, courseID := range course.Prerequisites {
+		if courseID == "" || courseID == course.ID {
+			problems = append(problems, Problem{File: rootFile, Message: fmt.Sprintf("invalid prerequisite course %q", courseID)})
+		}
+	}
+
+	lessonIDs := make(map[string]bool)
+	for _, lesson := range course.Lessons {
+		lessonIDs[lesson.ID] = true
+	}
+
+	seen := make(map[string]bool)
+	for i := range course.Lessons {
+		lesson := &course.Lessons[i]
+
+		file := rootFile
+		if i < len(lessonFiles) {
+			file = lessonFiles[i]
+		}
+
+		if lesson.ID != "" && seen[lesson.ID] {
+			problems = append(problems, Problem{File: file, Lesson: lesson.ID, Message: "duplicate lesson id"})
+		}
+		seen[lesson.ID] = true
+
+		for _, problem := range ValidateLesson(lesson) {
+			problem.File = file
+			problems = append(problems, problem)
+		}
+
+		for _, lessonID := range lesson.Prerequisites {
+			if lessonID == lesson.ID || !lessonIDs[lessonID] {
+				problems = append(problems, Problem{File: file, Lesson: lesson.ID, Message: fmt.Sprintf("invalid prerequisite lesson %q", lessonID)})
+			}
+		}
+	}
+
+	order := make([]string, 0, len(course.Lessons))
+	prerequisites := make(map[string][]string, len(course.Lessons))
+	for _, lesson := range course.Lessons {
+		order = append(order, lesson.ID)
+		prerequisites[lesson.ID] = lesson.Prerequisites
+	}
+	if lessonID := prerequisiteCycle(order, prerequisites); lessonID != "" {
+		problems = append(problems, Problem{File: rootFile, Lesson: lessonID, Message: "lesson prerequisites form a cycle"})
+	}
+
+	return problems
+}
+
+// validateCoursePrerequisites checks that every prerequisite course exists and that no course requires itself
+func validateCoursePrerequisites(courses map[string]*Course) []Problem {
+	var problems []Problem
+	courseIDs := slices.Sorted(maps.Keys(courses))
+	prerequisites := make(map[string][]string, len(courses))
+	for _, courseID := range courseIDs {
+		for _, prerequisite := range courses[courseID].Prerequisites {
+			if _, ok := courses[prerequisite]; !ok {
+				problems = append(problems, Problem{Message: fmt.Sprintf("course %s requires unknown course %q", courseID, prerequisite)})
+			}
+		}
+		prerequisites[courseID] = courses[courseID].Prerequisites
+	}
+
+	if courseID := prerequisiteCycle(courseIDs, prerequisites); courseID != "" {
+		problems = append(problems, Problem{Message: fmt.Sprintf("course %s: course prerequisites form a cycle", courseID)})
+	}
+
+	return problems
+}
+
+// ValidateLesson checks a lesson and all of its exercises
+func ValidateLesson(lesson *Lesson) []Problem {
+	var problems []Problem
+	if lesson.ID == "" {
+		problems = append(problems, Problem{Message: "lesson id is required"})
+	}
+	if lesson.Title == "" {
+		problems = append(problems, Problem{Lesson: lesson.ID, Message: "lesson title is required"})
+	}
+	for _, message := range validateMetadata(lesson.Difficulty, lesson.EstimatedMinutes) {
+		problems = append(problems, Problem{Lesson: lesson.ID, Message: message})
+	}
+
+	seen := make(map[string]bool)
+	for i := range lesson.Exercises {
+		exercise := &lesson.Exercises[i]
+
+		if exercise.ID != "" && seen[exercise.ID] {
+			problems = append(problems, Problem{Lesson: lesson.ID, Exercise: exercise.ID, Message: "duplicate exercise id"})
+		}
+		seen[exercise.ID] = true
+
+		exerciseID := exercise.ID
+		if exerciseID == "" {
+			exerciseID = fmt.Sprintf("#%d", i+1)
+		}
+		for _, message := range ValidateExercise(exercise) {
+			problems = append(problems, Problem{Lesson: lesson.ID, Exercise: exerciseID, Message: message})
+		}
+	}
+
+	return problems
+}
+
+// validateMetadata checks the fields courses and lessons share
+func validateMetadata(difficulty Difficulty, estimatedMinutes int) []string {
+	var problems []string
+	switch difficulty {
+	case "", DifficultyBeginner, DifficultyIntermediate, DifficultyAdvanced:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown difficulty %q", difficulty))
+	}
+	if estimatedMinutes < 0 {
+		problems = append(problems, "estimatedMinutes can't be negative")
+	}
+
+	return problems
+}
+
+// ValidateExercise checks that an exercise is complete and its answer key can be checked
+func ValidateExercise(exercise *Exercise) []string {
+	var problems []string
+	if exercise.ID == "" {
+		problems = append(problems, "exercise id is required")
+	}
+	if strings.TrimSpace(exercise.Question) == "" {
+		problems = append(problems, "question is required")
+	}
+
+	switch exercise.Type {
+	case ExerciseTypeMultipleChoice:
+		if len(exercise.Choices) < 2 {
+			problems = append(problems, "multiple choice needs at least 2 choices")
+		}
+		index, ok := exercise.CorrectAnswer.(float64)
+		if !ok || index != math.Trunc(index) {
+			problems = append(problems, fmt.Sprintf("correctAnswer must be the index of a choice, got %v", exercise.CorrectAnswer))
+		} else if index < 0 || int(index) >= len(exercise.Choices) {
+			problems = append(problems, fmt.Sprintf("correctAnswer %v is outside the %d choices", index, len(exercise.Choices)))
+		}
+
+	case ExerciseTypeTrueFalse:
+		if _, ok := exercise.CorrectAnswer.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("correctAnswer must be true or false, got %v", exercise.CorrectAnswer))
+		}
+
+	case ExerciseTypeFillBlank:
+		if answer, ok := exercise.CorrectAnswer.(string); !ok || strings.TrimSpace(answer) == "" {
+			problems = append(problems, fmt.Sprintf("correctAnswer must be a non-empty string, got %v", exercise.CorrectAnswer))
+		}
+
+	case ExerciseTypeMatching:
+		if len(exercise.Pairs) < 2 {
+			problems = append(problems, "matching needs at least 2 pairs")
+		}
+		// Several terms may share a definition, but answers are keyed by term
+		terms := make(map[string]bool)
+		for i, pair := range exercise.Pairs {
+			if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+				problems = append(problems, fmt.Sprintf("pair %d must be a term and a definition", i))
+				continue
+			}
+			if terms[pair[0]] {
+				problems = append(problems, fmt.Sprintf("term %q appears more than once", pair[0]))
+			}
+			terms[pair[0]] = true
+		}
+
+	case ExerciseTypeOrdering:
+		if len(exercise.Items) < 2 {
+			problems = append(problems, "ordering needs at least 2 items")
+		}
+		if !isPermutation(exercise.CorrectOrder, len(exercise.Items)) {
+			problems = append(problems, fmt.Sprintf("correctOrder %v is not a permutation of the %d items", exercise.CorrectOrder, len(exercise.Items)))
+		}
+
+	default:
+		problems = append(problems, fmt.Sprintf("unknown exercise type %q", exercise.Type))
+	}
+
+	return problems
+}
+
+// isPermutation reports whether order contains each of 0..n-1 exactly once
+func isPermutation(order []int, n int) bool {
+	if len(order) != n {
+		return false
+	}
+
+	seen := make([]bool, n)
+	for _, index := range order {
+		if index < 0 || index >= n || seen[index] {
+			return false
+		}
+		seen[index] = true
+	}
+
+	return true
+}
@@ -0,0 +1,151 @@
+package course_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/course"
+)
+
+func TestDataDirIsValid(t *testing.T) {
+	store := course.NewJSONStore("../data")
+	if err := store.LoadCourseDir(); err != nil {
+		for _, problem := range course.Problems(err) {
+			t.Error(problem)
+		}
+	}
+}
+
+func TestValidateExercise(t *testing.T) {
+	tests := []struct {
+		name     string
+		exercise course.Exercise
+		problem  string
+	}{
+		{
+			name:     "valid multiple choice",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeMultipleChoice, Question: "?", Choices: []string{"a", "b"}, CorrectAnswer: float64(1)},
+		},
+		{
+			name:     "multiple choice index out of range",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeMultipleChoice, Question: "?", Choices: []string{"a", "b"}, CorrectAnswer: float64(2)},
+			problem:  "outside the 2 choices",
+		},
+		{
+			name:     "multiple choice answer is not an index",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeMultipleChoice, Question: "?", Choices: []string{"a", "b"}, CorrectAnswer: "a"},
+			problem:  "must be the index of a choice",
+		},
+		{
+			name:     "true false without a bool",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeTrueFalse, Question: "?", CorrectAnswer: "true"},
+			problem:  "must be true or false",
+		},
+		{
+			name:     "true false missing answer",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeTrueFalse, Question: "?"},
+			problem:  "must be true or false",
+		},
+		{
+			name:     "empty fill blank answer",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeFillBlank, Question: "?", CorrectAnswer: " "},
+			problem:  "non-empty string",
+		},
+		{
+			name:     "matching repeats a term",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeMatching, Question: "?", Pairs: [][]string{{"a", "1"}, {"a", "2"}}},
+			problem:  `term "a" appears more than once`,
+		},
+		{
+			name:     "matching shares a definition",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeMatching, Question: "?", Pairs: [][]string{{"a", "1"}, {"b", "1"}}},
+		},
+		{
+			name:     "ordering repeats an index",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeOrdering, Question: "?", Items: []string{"a", "b", "c"}, CorrectOrder: []int{0, 1, 1}},
+			problem:  "not a permutation",
+		},
+		{
+			name:     "ordering is too short",
+			exercise: course.Exercise{ID: "q", Type: course.ExerciseTypeOrdering, Question: "?", Items: []string{"a", "b", "c"}, CorrectOrder: []int{2, 1}},
+			problem:  "not a permutation",
+		},
+		{
+			name:     "unknown type",
+			exercise: course.Exercise{ID: "q", Type: "essay", Question: "?"},
+			problem:  `unknown exercise type "essay"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := course.ValidateExercise(&tt.exercise)
+			if tt.problem == "" {
+				if len(problems) != 0 {
+					t.Errorf("expected no problems, got %v", problems)
+				}
+				return
+			}
+
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.problem) {
+				t.Errorf("expected a problem containing %q, got %v", tt.problem, problems)
+			}
+		})
+	}
+}
+
+func TestValidateCourseDuplicateIDs(t *testing.T) {
+	exercise := course.Exercise{ID: "q1", Type: course.ExerciseTypeTrueFalse, Question: "?", CorrectAnswer: true}
+	c := course.Course{
+		ID:   "test",
+		Name: "Test",
+		Lessons: []course.Lesson{
+			{ID: "one", Title: "One", Exercises: []course.Exercise{exercise, exercise}},
+			{ID: "one", Title: "Again"},
+		},
+	}
+
+	problems := course.ValidateCourse(&c)
+	want := []course.Problem{
+		{Lesson: "one", Exercise: "q1", Message: "duplicate exercise id"},
+		{Lesson: "one", Message: "duplicate lesson id"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %v, got %v", want, problems)
+	}
+	for i := range want {
+		if problems[i] != want[i] {
+			t.Errorf("expected %v, got %v", want[i], problems[i])
+		}
+	}
+}
+
+func TestLoadReportsProblemLocations(t *testing.T) {
+	dir := copyDataDir(t)
+
+	file := filepath.Join(dir, "algorithms", "01_introduction.json")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), `"correctAnswer": 0`, `"correctAnswer": 9`, 1))
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = course.NewJSONStore(dir).LoadCourseDir()
+	if !errors.Is(err, course.ErrInvalidContent) {
+		t.Fatalf("expected ErrInvalidContent, got %v", err)
+	}
+
+	problems := course.Problems(err)
+	if len(problems) != 1 {
+		t.Fatalf("expected 1 problem, got %v", problems)
+	}
+	if problems[0].File != file || problems[0].Lesson != "introduction" || problems[0].Exercise != "algo_intro_1" {
+		t.Errorf("unexpected problem location: %+v", problems[0])
+	}
+}
@@ -0,0 +1,128 @@
+package course
+
+import (
+	"errors"
+	"log"
+	"strings"
+)
+
+// VerifyAnswer checks an answer, given in terms of the answer key, against an exercise
+func VerifyAnswer(exercise *Exercise, answer interface{}) (bool, error) {
+	switch exercise.Type {
+	case ExerciseTypeMultipleChoice:
+		if choiceIdx, ok := answer.(float64); ok {
+			correctAnswer, ok := exercise.CorrectAnswer.(float64)
+			if !ok {
+				log.Printf("Invalid correct answer format for multiple choice. Expected float64, got %T", exercise.CorrectAnswer)
+				return false, errors.New("invalid correct answer format for multiple choice")
+			}
+
+			return choiceIdx == correctAnswer, nil
+		}
+
+		log.Printf("Invalid answer format for multiple choice. Expected float64, got %T", answer)
+		return false, errors.New("invalid answer format for multiple choice")
+
+	case ExerciseTypeTrueFalse:
+		if boolAnswer, ok := answer.(bool); ok {
+			correctAnswer, ok := exercise.CorrectAnswer.(bool)
+			if !ok {
+				log.Printf("Invalid correct answer format for true/false. Expected bool, got %T", exercise.CorrectAnswer)
+				return false, errors.New("invalid correct answer format for true/false")
+			}
+
+			log.Printf("True/False verification - Answer: %v, Correct: %v", boolAnswer, correctAnswer)
+			return boolAnswer == correctAnswer, nil
+		}
+		return false, errors.New("invalid answer format for true/false")
+
+	case ExerciseTypeMatching:
+		answerPairs, ok := answer.([]interface{})
+		if !ok {
+			log.Printf("Invalid answer format for matching. Got type: %T", answer)
+			return false, errors.New("invalid answer format for matching")
+		}
+		return verifyMatchingAnswer(answerPairs, exercise.Pairs)
+
+	case ExerciseTypeOrdering:
+		answerOrder, ok := answer.([]interface{})
+		if !ok {
+			return false, errors.New("invalid answer format for ordering")
+		}
+		return verifyOrderingAnswer(answerOrder, exercise.CorrectOrder)
+
+	case ExerciseTypeFillBlank:
+		userAnswer, ok := answer.(string)
+		if !ok {
+			log.Printf("Invalid answer format for fill_blank. Got type: %T", answer)
+			return false, errors.New("invalid answer format for fill blank")
+		}
+		correctAnswer, ok := exercise.CorrectAnswer.(string)
+		if !ok {
+			log.Printf("Invalid correct answer format for fill_blank. Expected string, got %T", exercise.CorrectAnswer)
+			return false, errors.New("invalid correct answer format for fill blank")
+		}
+		// Case-insensitive comparison
+		return strings.EqualFold(strings.TrimSpace(userAnswer), strings.TrimSpace(correctAnswer)), nil
+
+	default:
+		return false, errors.New("unsupported exercise type")
+	}
+}
+
+// Helper functions for exercise verification
+func verifyMatchingAnswer(answer []interface{}, correctPairs [][]string) (bool, error) {
+	log.Printf("Verifying matching answer. Got: %v, Expected: %v", answer, correctPairs)
+
+	if len(answer) != len(correctPairs) {
+		log.Printf("Length mismatch. Answer length: %d, Expected length: %d", len(answer), len(correctPairs))
+		return false, nil
+	}
+
+	// Convert answer pairs to a map for easier verification
+	answerMap := make(map[string]string)
+	for _, pair := range answer {
+		answerPair, ok := pair.([]interface{})
+		if !ok || len(answerPair) != 2 {
+			log.Printf("Invalid pair format: %v", pair)
+			return false, errors.New("invalid matching pair format")
+		}
+
+		term, ok1 := answerPair[0].(string)
+		definition, ok2 := answerPair[1].(string)
+		if !ok1 || !ok2 {
+			log.Printf("Invalid pair values: %v", answerPair)
+			return false, errors.New("invalid matching pair values")
+		}
+
+		answerMap[term] = definition
+	}
+
+	// Check each correct pair
+	for _, pair := range correctPairs {
+		if answerMap[pair[0]] != pair[1] {
+			log.Printf("Mismatch found. Term: %s, Expected: %s, Got: %s",
+				pair[0], pair[1], answerMap[pair[0]])
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func verifyOrderingAnswer(answer []interface{}, correctOrder []int) (bool, error) {
+	if len(answer) != len(correctOrder) {
+		return false, nil
+	}
+
+	for i, val := range answer {
+		idx, ok := val.(float64)
+		if !ok {
+			return false, errors.New("invalid ordering value format")
+		}
+		if int(idx) != correctOrder[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
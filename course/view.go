@@ -0,0 +1,306 @@
+package course
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	mrand "math/rand/v2"
+	"strings"
+	"unicode/utf8"
+)
+
+// shuffleKey keys the per-user shuffle so clients can't recompute the permutation
+var shuffleKey []byte
+
+// SetShuffleKey sets the secret used to derive exercise shuffles, and must be called before any are.
+// It has to be the same across restarts and instances, otherwise a lesson loaded from one is graded
+// with a different order.
+func SetShuffleKey(key []byte) {
+	shuffleKey = key
+}
+
+// ExerciseView is the client-facing form of an Exercise. It never carries the answer key:
+// matching pairs are split into two shuffled columns and ordering items are shuffled.
+type ExerciseView struct {
+	ID       string       `json:"id"`
+	Type     ExerciseType `json:"type"`
+	Question string       `json:"question"`
+	Choices  []string     `json:"choices,omitempty"`
+	Items    []string     `json:"items,omitempty"`
+	Left     []string     `json:"left,omitempty"`
+	Right    []string     `json:"right,omitempty"`
+}
+
+type LessonView struct {
+	ID               string         `json:"id"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description"`
+	Difficulty       Difficulty     `json:"difficulty,omitempty"`
+	EstimatedMinutes int            `json:"estimatedMinutes,omitempty"`
+	Locked           bool           `json:"locked,omitempty"` // shown without exercises until the prerequisites are completed
+	Exercises        []ExerciseView `json:"exercises"`
+}
+
+type CourseView struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	Description      string       `json:"description"`
+	Difficulty       Difficulty   `json:"difficulty,omitempty"`
+	EstimatedMinutes int          `json:"estimatedMinutes,omitempty"`
+	Tags             []string     `json:"tags,omitempty"`
+	CoverImage       string       `json:"coverImage,omitempty"`
+	Lessons          []LessonView `json:"lessons"`
+}
+
+// Shuffle is the server-side mapping of an exercise shown to one user.
+// Each slice maps a position shown to the client to an index in the answer key.
+type Shuffle struct {
+	Items []int
+	Left  []int
+	Right []int
+}
+
+// NewShuffle derives the shuffle for an exercise shown to a user. The same inputs
+// always produce the same shuffle, so nothing needs to be stored between requests.
+func NewShuffle(userID int, courseID, lessonID string, ex *Exercise) Shuffle {
+	if len(shuffleKey) == 0 {
+		panic("course: SetShuffleKey must be called before exercises are shuffled")
+	}
+
+	mac := hmac.New(sha256.New, shuffleKey)
+	fmt.Fprintf(mac, "%d/%s/%s/%s", userID, courseID, lessonID, ex.ID)
+	sum := mac.Sum(nil)
+	rng := mrand.New(mrand.NewPCG(binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16])))
+
+	var shuffle Shuffle
+	switch ex.Type {
+	case ExerciseTypeOrdering:
+		shuffle.Items = permutation(rng, len(ex.Items))
+	case ExerciseTypeMatching:
+		shuffle.Left = permutation(rng, len(ex.Pairs))
+		shuffle.Right = permutation(rng, len(ex.Pairs))
+	}
+
+	return shuffle
+}
+
+// permutation returns a random permutation of n that is never the identity when n > 1,
+// since content authors usually list ordering items in the correct order
+func permutation(rng *mrand.Rand, n int) []int {
+	perm := rng.Perm(n)
+	if n < 2 {
+		return perm
+	}
+
+	for i, v := range perm {
+		if i != v {
+			return perm
+		}
+	}
+
+	// Rotate by one
+	return append(perm[1:], perm[0])
+}
+
+// NewExerciseView builds the client-facing view of an exercise using the given shuffle
+func NewExerciseView(ex *Exercise, shuffle Shuffle) ExerciseView {
+	view := ExerciseView{
+		ID:       ex.ID,
+		Type:     ex.Type,
+		Question: ex.Question,
+		Choices:  ex.Choices,
+	}
+
+	switch ex.Type {
+	case ExerciseTypeOrdering:
+		view.Items = make([]string, len(shuffle.Items))
+		for i, idx := range shuffle.Items {
+			view.Items[i] = ex.Items[idx]
+		}
+	case ExerciseTypeMatching:
+		view.Left = make([]string, len(shuffle.Left))
+		for i, idx := range shuffle.Left {
+			view.Left[i] = ex.Pairs[idx][0]
+		}
+		view.Right = make([]string, len(shuffle.Right))
+		for i, idx := range shuffle.Right {
+			view.Right[i] = ex.Pairs[idx][1]
+		}
+	}
+
+	return view
+}
+
+// NewLessonView builds the client-facing view of a lesson for a user
+func NewLessonView(userID int, courseID string, lesson *Lesson) LessonView {
+	view := LessonView{
+		ID:               lesson.ID,
+		Title:            lesson.Title,
+		Description:      lesson.Description,
+		Difficulty:       lesson.Difficulty,
+		EstimatedMinutes: lesson.EstimatedMinutes,
+		Exercises:        make([]ExerciseView, 0, len(lesson.Exercises)),
+	}
+
+	for i := range lesson.Exercises {
+		ex := &lesson.Exercises[i]
+		view.Exercises = append(view.Exercises, NewExerciseView(ex, NewShuffle(userID, courseID, lesson.ID, ex)))
+	}
+
+	return view
+}
+
+// NewCourseView builds the client-facing view of a course for a user, leaving out the exercises
+// of lessons the user hasn't unlocked
+func NewCourseView(userID int, course *Course, done *Completion) CourseView {
+	view := CourseView{
+		ID:               course.ID,
+		Name:             course.Name,
+		Description:      course.Description,
+		Difficulty:       course.Difficulty,
+		EstimatedMinutes: course.TotalMinutes(),
+		Tags:             course.Tags,
+		CoverImage:       course.CoverImage,
+		Lessons:          make([]LessonView, 0, len(course.Lessons)),
+	}
+
+	for i := range course.Lessons {
+		lesson := &course.Lessons[i]
+		if missing := MissingForLesson(course, lesson, done); !missing.Empty() {
+			view.Lessons = append(view.Lessons, LessonView{
+				ID:               lesson.ID,
+				Title:            lesson.Title,
+				Description:      lesson.Description,
+				Difficulty:       lesson.Difficulty,
+				EstimatedMinutes: lesson.EstimatedMinutes,
+				Locked:           true,
+				Exercises:        make([]ExerciseView, 0),
+			})
+			continue
+		}
+
+		view.Lessons = append(view.Lessons, NewLessonView(userID, course.ID, lesson))
+	}
+
+	return view
+}
+
+// RevealedAnswer is the answer to an exercise in a form the client can show: the choice index,
+// true or false, the text to fill in, the items in order or the term and definition pairs
+func RevealedAnswer(ex *Exercise) interface{} {
+	switch ex.Type {
+	case ExerciseTypeOrdering:
+		items := make([]string, len(ex.CorrectOrder))
+		for i, idx := range ex.CorrectOrder {
+			items[i] = ex.Items[idx]
+		}
+		return items
+	case ExerciseTypeMatching:
+		return ex.Pairs
+	default:
+		return ex.CorrectAnswer
+	}
+}
+
+// Hint gives away part of an exercise's answer: a wrong choice, the first letter, the first item in
+// order or one matching pair. True or false questions can't be partly answered, so they have no hint.
+func Hint(ex *Exercise) (string, bool) {
+	switch ex.Type {
+	case ExerciseTypeMultipleChoice:
+		correct, ok := ex.CorrectAnswer.(float64)
+		if !ok {
+			return "", false
+		}
+		for i, choice := range ex.Choices {
+			if float64(i) != correct {
+				return fmt.Sprintf("It isn't %q", choice), true
+			}
+		}
+	case ExerciseTypeFillBlank:
+		answer, _ := ex.CorrectAnswer.(string)
+		if first, _ := utf8.DecodeRuneInString(strings.TrimSpace(answer)); first != utf8.RuneError {
+			return fmt.Sprintf("It starts with %q", string(first)), true
+		}
+	case ExerciseTypeOrdering:
+		if len(ex.CorrectOrder) > 0 && ex.CorrectOrder[0] < len(ex.Items) {
+			return fmt.Sprintf("%q comes first", ex.Items[ex.CorrectOrder[0]]), true
+		}
+	case ExerciseTypeMatching:
+		if len(ex.Pairs) > 0 {
+			return fmt.Sprintf("%q goes with %q", ex.Pairs[0][0], ex.Pairs[0][1]), true
+		}
+	}
+
+	return "", false
+}
+
+// TranslateAnswer maps an answer given in terms of the shuffled view back onto the answer key,
+// so it can be graded by VerifyExerciseAnswer. Ordering answers are indices into the shown items.
+// Matching answers may be [left, right] index pairs into the shown columns or term/definition strings.
+func (s Shuffle) TranslateAnswer(ex *Exercise, answer interface{}) (interface{}, error) {
+	switch ex.Type {
+	case ExerciseTypeOrdering:
+		shown, ok := answer.([]interface{})
+		if !ok {
+			return nil, errors.New("invalid answer format for ordering")
+		}
+
+		translated := make([]interface{}, len(shown))
+		for i, val := range shown {
+			idx, ok := shuffledIndex(val, s.Items)
+			if !ok {
+				return nil, errors.New("invalid ordering value")
+			}
+			translated[i] = float64(idx)
+		}
+		return translated, nil
+
+	case ExerciseTypeMatching:
+		shown, ok := answer.([]interface{})
+		if !ok {
+			return nil, errors.New("invalid answer format for matching")
+		}
+
+		translated := make([]interface{}, len(shown))
+		for i, val := range shown {
+			pair, ok := val.([]interface{})
+			if !ok || len(pair) != 2 {
+				return nil, errors.New("invalid matching pair format")
+			}
+
+			// String pairs already reference the key
+			if _, isString := pair[0].(string); isString {
+				translated[i] = pair
+				continue
+			}
+
+			left, ok1 := shuffledIndex(pair[0], s.Left)
+			right, ok2 := shuffledIndex(pair[1], s.Right)
+			if !ok1 || !ok2 {
+				return nil, errors.New("invalid matching pair values")
+			}
+			translated[i] = []interface{}{ex.Pairs[left][0], ex.Pairs[right][1]}
+		}
+		return translated, nil
+
+	default:
+		return answer, nil
+	}
+}
+
+// shuffledIndex maps a JSON number shown to the client back to its key index
+func shuffledIndex(val interface{}, perm []int) (int, bool) {
+	f, ok := val.(float64)
+	if !ok {
+		return 0, false
+	}
+
+	idx := int(f)
+	if float64(idx) != f || idx < 0 || idx >= len(perm) {
+		return 0, false
+	}
+
+	return perm[idx], true
+}
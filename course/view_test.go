@@ -0,0 +1,159 @@
+package course_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/course"
+)
+
+func setupTestStore(t *testing.T) *course.JSONStore {
+	course.SetShuffleKey([]byte("test shuffle key"))
+	store := course.NewJSONStore("../data")
+	if err := store.LoadCourseDir(); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestLessonViewHidesAnswers(t *testing.T) {
+	store := setupTestStore(t)
+
+	lesson, err := store.GetLessonByID("algorithms", "time-complexity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonBytes, err := json.Marshal(course.NewLessonView(1, "algorithms", lesson))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"correctAnswer", "correctOrder", "pairs"} {
+		if strings.Contains(string(jsonBytes), key) {
+			t.Errorf("lesson view leaks %q: %s", key, jsonBytes)
+		}
+	}
+}
+
+func TestCourseViewHidesLockedLessons(t *testing.T) {
+	store := setupTestStore(t)
+
+	c, err := store.GetCourseByID("algorithms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lesson := range course.NewCourseView(1, c, course.NewCompletion()).Lessons {
+		locked := lesson.ID == "divide-conquer" || lesson.ID == "dynamic-programming"
+		if lesson.Locked != locked || (locked && len(lesson.Exercises) > 0) || (!locked && len(lesson.Exercises) == 0) {
+			t.Errorf("expected %s to be locked: %v, got locked: %v with %d exercises", lesson.ID, locked, lesson.Locked, len(lesson.Exercises))
+		}
+	}
+}
+
+func TestHint(t *testing.T) {
+	tests := []struct {
+		exercise course.Exercise
+		want     string
+	}{
+		{course.Exercise{Type: course.ExerciseTypeMultipleChoice, Choices: []string{"A", "B"}, CorrectAnswer: float64(0)}, `It isn't "B"`},
+		{course.Exercise{Type: course.ExerciseTypeFillBlank, CorrectAnswer: " élan"}, `It starts with "é"`},
+		{course.Exercise{Type: course.ExerciseTypeOrdering, Items: []string{"b", "a"}, CorrectOrder: []int{1, 0}}, `"a" comes first`},
+		{course.Exercise{Type: course.ExerciseTypeMatching, Pairs: [][]string{{"x", "1"}, {"y", "2"}}}, `"x" goes with "1"`},
+		{course.Exercise{Type: course.ExerciseTypeTrueFalse, CorrectAnswer: true}, ""},
+	}
+
+	for _, tt := range tests {
+		hint, ok := course.Hint(&tt.exercise)
+		if hint != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: got %q, %v, want %q", tt.exercise.Type, hint, ok, tt.want)
+		}
+	}
+}
+
+func TestShuffledOrderingAnswer(t *testing.T) {
+	store := setupTestStore(t)
+
+	exercise, err := store.GetExerciseByID("algorithms", "time-complexity", "tc_3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shuffle := course.NewShuffle(1, "algorithms", "time-complexity", exercise)
+	view := course.NewExerciseView(exercise, shuffle)
+
+	if strings.Join(view.Items, ",") == strings.Join(exercise.Items, ",") {
+		t.Errorf("ordering items were not shuffled: %v", view.Items)
+	}
+
+	// Build the correct answer in terms of the shown items
+	answer := make([]interface{}, 0, len(exercise.CorrectOrder))
+	for _, keyIdx := range exercise.CorrectOrder {
+		for shownIdx, item := range view.Items {
+			if item == exercise.Items[keyIdx] {
+				answer = append(answer, float64(shownIdx))
+			}
+		}
+	}
+
+	translated, err := shuffle.TranslateAnswer(exercise, answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isCorrect, err := store.VerifyExerciseAnswer("algorithms", "time-complexity", "tc_3", translated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCorrect {
+		t.Errorf("expected shuffled answer %v (translated %v) to be correct", answer, translated)
+	}
+
+	if again := course.NewShuffle(1, "algorithms", "time-complexity", exercise); strings.Join(course.NewExerciseView(exercise, again).Items, ",") != strings.Join(view.Items, ",") {
+		t.Error("expected the same shuffle for the same user")
+	}
+}
+
+func TestShuffledMatchingAnswer(t *testing.T) {
+	store := setupTestStore(t)
+
+	exercise, err := store.GetExerciseByID("algorithms", "introduction", "algo_intro_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shuffle := course.NewShuffle(7, "algorithms", "introduction", exercise)
+	view := course.NewExerciseView(exercise, shuffle)
+
+	// Pair each shown term with its shown definition by index
+	answer := make([]interface{}, 0, len(exercise.Pairs))
+	for _, pair := range exercise.Pairs {
+		left, right := -1, -1
+		for i, term := range view.Left {
+			if term == pair[0] {
+				left = i
+			}
+		}
+		for i, definition := range view.Right {
+			if definition == pair[1] {
+				right = i
+			}
+		}
+		answer = append(answer, []interface{}{float64(left), float64(right)})
+	}
+
+	translated, err := shuffle.TranslateAnswer(exercise, answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	isCorrect, err := store.VerifyExerciseAnswer("algorithms", "introduction", "algo_intro_2", translated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCorrect {
+		t.Errorf("expected shuffled matching answer %v to be correct", answer)
+	}
+}
@@ -8,6 +8,14 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// Querier is implemented by both *sql.DB and *sql.Tx, so the same queries can run on their own
+// or inside a transaction started by the caller
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // Creates a new database connection. Leave host/port blank for localhost/5433
 func NewDatabase(user, password, dbName, host, port string) (*sql.DB, error) {
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbName)
@@ -0,0 +1,83 @@
+// Package dbtest connects tests to a real Postgres database when one is available.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/tylerolson/capstone-backend/db"
+)
+
+var (
+	once     sync.Once
+	database *sql.DB
+	openErr  error
+)
+
+// Open returns a migrated connection to the test database described by the POSTGRES_*
+// environment variables, defaulting to the docker-compose credentials on localhost.
+// The test is skipped when the database can't be reached.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	once.Do(func() {
+		database, openErr = open()
+	})
+
+	if openErr != nil {
+		t.Skipf("Postgres not available: %v", openErr)
+	}
+
+	return database
+}
+
+// UniqueName returns a name that won't collide with rows left behind by earlier test runs
+func UniqueName(prefix string) string {
+	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+}
+
+func open() (*sql.DB, error) {
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable connect_timeout=2",
+		env("POSTGRES_HOST", "localhost"),
+		env("POSTGRES_PORT", "5432"),
+		env("POSTGRES_USER", "dbuser"),
+		env("POSTGRES_PASSWORD", "dbpassword"),
+		env("POSTGRES_DB", "capstone_db"),
+	)
+
+	conn, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func env(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
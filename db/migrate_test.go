@@ -0,0 +1,71 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+
+	for i, migration := range migrations {
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migrations out of order: %d after %d", migration.Version, migrations[i-1].Version)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0010_later.up.sql":    {Data: []byte("SELECT 10")},
+		"m/0002_second.up.sql":   {Data: []byte("SELECT 2")},
+		"m/0002_second.down.sql": {Data: []byte("SELECT -2")},
+		"m/0001_first.up.sql":    {Data: []byte("SELECT 1")},
+	}
+
+	migrations, err := loadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{1, 2, 10}
+	if len(migrations) != len(want) {
+		t.Fatalf("expected %d migrations, got %d", len(want), len(migrations))
+	}
+	for i, version := range want {
+		if migrations[i].Version != version {
+			t.Errorf("migration %d: expected version %d, got %d", i, version, migrations[i].Version)
+		}
+	}
+
+	if migrations[1].Name != "second" || migrations[1].Down != "SELECT -2" {
+		t.Errorf("unexpected migration: %+v", migrations[1])
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":    {"m/first.up.sql": {Data: []byte("SELECT 1")}},
+		"missing up":  {"m/0001_first.down.sql": {Data: []byte("SELECT 1")}},
+		"name clash":  {"m/0001_a.up.sql": {Data: []byte("SELECT 1")}, "m/0001_b.up.sql": {Data: []byte("SELECT 1")}},
+		"missing dir": {},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadMigrations(fsys, "m"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS user_achievements;
+DROP TABLE IF EXISTS achievements;
+DROP TABLE IF EXISTS user_point_transactions;
+DROP TABLE IF EXISTS user_exercise_attempts;
+DROP TABLE IF EXISTS user_lesson_progress;
+DROP TABLE IF EXISTS user_course_progress;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS users;
+
+DROP FUNCTION IF EXISTS update_last_accessed_timestamp();
+DROP FUNCTION IF EXISTS update_updated_at_column();
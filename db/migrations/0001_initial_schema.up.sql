@@ -1,4 +1,4 @@
--- Existing tables from init.sql
+-- Initial schema, formerly init.sql. Safe to run against a database created by init.sql.
 CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     username VARCHAR(50) UNIQUE NOT NULL,
@@ -42,7 +42,7 @@ END;
 $$ language 'plpgsql';
 
 -- Create trigger for users table
-CREATE TRIGGER update_user_updated_at
+CREATE OR REPLACE TRIGGER update_user_updated_at
     BEFORE UPDATE ON users
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
@@ -122,6 +122,19 @@ CREATE TABLE IF NOT EXISTS user_achievements (
     UNIQUE(user_id, achievement_id)
 );
 
+-- Default achievements, see services/achievements for the criteria format
+INSERT INTO achievements (name, description, criteria) VALUES
+    ('First Steps', 'Complete your first lesson', '{"type": "lessons_completed", "count": 1}'),
+    ('Dedicated Learner', 'Complete 10 lessons', '{"type": "lessons_completed", "count": 10}'),
+    ('Week Warrior', 'Reach a 7 day streak', '{"type": "daily_streak", "days": 7}'),
+    ('Monthly Master', 'Reach a 30 day streak', '{"type": "daily_streak", "days": 30}'),
+    ('Sharpshooter', 'Reach 90% accuracy over at least 50 attempts', '{"type": "accuracy", "percent": 90, "minAttempts": 50}'),
+    ('Point Collector', 'Earn 1000 points', '{"type": "total_points", "points": 1000}'),
+    ('Programming Pro', 'Finish the Programming Basics course', '{"type": "course_completed", "courseId": "programming_basics"}'),
+    ('Structured Thinker', 'Finish the Data Structures course', '{"type": "course_completed", "courseId": "data_structures"}'),
+    ('Algorithm Ace', 'Finish the Algorithms course', '{"type": "course_completed", "courseId": "algorithms"}')
+ON CONFLICT (name) DO NOTHING;
+
 -- Add indexes for performance
 CREATE INDEX IF NOT EXISTS idx_user_course_progress_user ON user_course_progress(user_id);
 CREATE INDEX IF NOT EXISTS idx_user_lesson_progress_user ON user_lesson_progress(user_id);
@@ -148,12 +161,12 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
-CREATE TRIGGER update_course_progress_timestamp
+CREATE OR REPLACE TRIGGER update_course_progress_timestamp
     BEFORE UPDATE ON user_course_progress
     FOR EACH ROW
     EXECUTE FUNCTION update_last_accessed_timestamp();
 
-CREATE TRIGGER update_lesson_progress_timestamp
+CREATE OR REPLACE TRIGGER update_lesson_progress_timestamp
     BEFORE UPDATE ON user_lesson_progress
     FOR EACH ROW
     EXECUTE FUNCTION update_last_accessed_timestamp();
@@ -0,0 +1,4 @@
+-- Roles gate the admin and authoring routes. Everyone starts out as a student.
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'student'
+    CHECK (role IN ('student', 'instructor', 'admin'));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS exercises;
+DROP TABLE IF EXISTS lessons;
+DROP TABLE IF EXISTS courses;
@@ -0,0 +1,10 @@
+ALTER TABLE lessons
+    DROP COLUMN IF EXISTS estimated_minutes,
+    DROP COLUMN IF EXISTS difficulty;
+
+ALTER TABLE courses
+    DROP COLUMN IF EXISTS cover_image,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS estimated_minutes,
+    DROP COLUMN IF EXISTS difficulty,
+    DROP COLUMN IF EXISTS visibility;
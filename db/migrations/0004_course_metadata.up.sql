@@ -0,0 +1,11 @@
+-- Catalog metadata shown in the course list. Unlisted courses are left out of it but can still be opened by ID.
+ALTER TABLE courses
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted')),
+    ADD COLUMN difficulty VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN estimated_minutes INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN cover_image TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE lessons
+    ADD COLUMN difficulty VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN estimated_minutes INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE lessons DROP COLUMN IF EXISTS prerequisites;
+ALTER TABLE courses DROP COLUMN IF EXISTS prerequisites;
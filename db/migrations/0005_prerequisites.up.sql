@@ -0,0 +1,3 @@
+-- Courses list the courses, and lessons the lessons of the same course, that have to be completed first
+ALTER TABLE courses ADD COLUMN prerequisites TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE lessons ADD COLUMN prerequisites TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_revealed_answers;
+ALTER TABLE user_point_transactions DROP COLUMN IF EXISTS scoring_policy;
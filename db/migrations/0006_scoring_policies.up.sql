@@ -0,0 +1,12 @@
+-- The scoring policy that decided a correct answer's points, so farmed answers can be told apart in the ledger
+ALTER TABLE user_point_transactions ADD COLUMN scoring_policy VARCHAR(50);
+
+-- Exercises whose answer a user has been shown. Later correct answers to them earn nothing.
+CREATE TABLE IF NOT EXISTS user_revealed_answers (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    course_id VARCHAR(100) NOT NULL,
+    lesson_id VARCHAR(100) NOT NULL,
+    exercise_id VARCHAR(100) NOT NULL,
+    revealed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, course_id, lesson_id, exercise_id)
+);
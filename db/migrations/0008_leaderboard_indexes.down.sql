@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_point_transactions_course_created_at;
+DROP INDEX IF EXISTS idx_point_transactions_created_at;
@@ -0,0 +1,3 @@
+-- Leaderboards sum the transactions in a time window, globally or for one course
+CREATE INDEX IF NOT EXISTS idx_point_transactions_created_at ON user_point_transactions(created_at);
+CREATE INDEX IF NOT EXISTS idx_point_transactions_course_created_at ON user_point_transactions(course_id, created_at);
@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_date DATE;
+
+UPDATE users
+SET last_login_date = (last_login_at AT TIME ZONE timezone)::date
+WHERE last_login_at IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
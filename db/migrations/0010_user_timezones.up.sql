@@ -0,0 +1,11 @@
+-- Daily streaks are counted in each user's own timezone. The last login is kept as an instant
+-- rather than a date so that it can be placed on the calendar of whatever zone the user picks later.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP WITH TIME ZONE;
+
+-- Streak dates so far were UTC days. Noon keeps them on the same date in most zones.
+UPDATE users
+SET last_login_at = (last_login_date + TIME '12:00') AT TIME ZONE 'UTC'
+WHERE last_login_date IS NOT NULL AND last_login_at IS NULL;
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_date;
@@ -0,0 +1,3 @@
+ALTER TABLE users RENAME COLUMN last_active_at TO last_login_at;
+
+DROP TABLE IF EXISTS user_daily_activity;
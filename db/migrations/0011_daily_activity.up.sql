@@ -0,0 +1,14 @@
+-- Daily streaks advance on learning activity instead of sign-in. Each row is one day in the
+-- user's timezone, and qualified_at and reason record when and why that day counted.
+CREATE TABLE IF NOT EXISTS user_daily_activity (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    activity_date DATE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    correct_answers INTEGER NOT NULL DEFAULT 0,
+    points INTEGER NOT NULL DEFAULT 0,
+    qualified_at TIMESTAMP WITH TIME ZONE,
+    reason TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (user_id, activity_date)
+);
+
+ALTER TABLE users RENAME COLUMN last_login_at TO last_active_at;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS daily_streak_lost_at;
+ALTER TABLE users DROP COLUMN IF EXISTS lost_daily_streak;
+ALTER TABLE users DROP COLUMN IF EXISTS streak_freezes;
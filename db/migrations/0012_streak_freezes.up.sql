@@ -0,0 +1,4 @@
+-- Streak freezes cover missed days, and a broken streak is kept for a while so that it can be repaired
+ALTER TABLE users ADD COLUMN IF NOT EXISTS streak_freezes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS lost_daily_streak INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS daily_streak_lost_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,31 @@
+-- The shop sells items for points. Streak freezes are kept on users.streak_freezes, everything
+-- else a user buys goes in their inventory.
+CREATE TABLE IF NOT EXISTS shop_items (
+    id VARCHAR(64) PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('frame', 'avatar', 'streak_freeze', 'hint_token')),
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price INTEGER NOT NULL CHECK (price >= 0),
+    profile_pic_id VARCHAR(100) NOT NULL DEFAULT '', -- The profile picture an avatar unlocks
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_items_profile_pic ON shop_items(profile_pic_id) WHERE profile_pic_id <> '';
+
+-- Items that have been bought can't be deleted from the catalog, only taken off sale
+CREATE TABLE IF NOT EXISTS user_inventory (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id VARCHAR(64) NOT NULL REFERENCES shop_items(id),
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    acquired_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, item_id)
+);
+
+INSERT INTO shop_items (id, kind, name, description, price) VALUES
+    ('streak_freeze', 'streak_freeze', 'Streak Freeze', 'Keeps your daily streak alive through a missed day', 100),
+    ('hint_token', 'hint_token', 'Hint Token', 'Redeem for a hint on a tough exercise', 50),
+    ('gold_frame', 'frame', 'Gold Frame', 'A gold frame for your profile picture', 500)
+ON CONFLICT (id) DO NOTHING;
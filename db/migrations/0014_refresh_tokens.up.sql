@@ -0,0 +1,19 @@
+-- Sessions now hold short lived access tokens, renewed with refresh tokens that are replaced on every use.
+-- Sessions and refresh tokens from the same sign in share a family_id, so a refresh token that is used
+-- twice can revoke all of them. Sessions from before this have no family and simply expire.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS family_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
+
+-- Used tokens are kept, with used_at set, until they expire
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id VARCHAR(64) NOT NULL,
+    token VARCHAR(255) UNIQUE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
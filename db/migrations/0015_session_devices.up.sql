@@ -0,0 +1,8 @@
+-- The device each session was signed in from, so users can see where they're signed in and sign out remotely
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
@@ -0,0 +1,8 @@
+-- Hashes are no use as plaintext tokens, so everyone signs in again
+DELETE FROM sessions;
+DELETE FROM refresh_tokens;
+DELETE FROM password_reset_tokens;
+
+ALTER TABLE sessions RENAME COLUMN token_hash TO token;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
+ALTER TABLE password_reset_tokens RENAME COLUMN token_hash TO token;
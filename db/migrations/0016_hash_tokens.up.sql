@@ -0,0 +1,10 @@
+-- Session, refresh and password reset tokens are stored as keyed hashes (see the tokenhash package)
+-- and looked up by hash. The plaintext tokens already stored can't be hashed here without the key,
+-- so they are dropped instead: everyone signs in again and open reset links stop working.
+DELETE FROM sessions;
+DELETE FROM refresh_tokens;
+DELETE FROM password_reset_tokens;
+
+ALTER TABLE sessions RENAME COLUMN token TO token_hash;
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+ALTER TABLE password_reset_tokens RENAME COLUMN token TO token_hash;
@@ -0,0 +1,3 @@
+INSERT INTO shop_items (id, kind, name, description, price) VALUES
+    ('hint_token', 'hint_token', 'Hint Token', 'Redeem for a hint on a tough exercise', 50)
+ON CONFLICT (id) DO UPDATE SET active = TRUE, updated_at = CURRENT_TIMESTAMP;
@@ -0,0 +1,6 @@
+-- Nothing redeems hint tokens yet, so they come out of the shop. Tokens already bought stay in their
+-- owners' inventories, and the item with them, since bought items can only be taken off sale.
+UPDATE shop_items SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = 'hint_token';
+
+DELETE FROM shop_items
+WHERE id = 'hint_token' AND NOT EXISTS (SELECT 1 FROM user_inventory WHERE item_id = 'hint_token');
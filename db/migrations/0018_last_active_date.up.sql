@@ -0,0 +1,7 @@
+-- The day a streak last counted is kept as a date in the zone it counted in. Placing the last_active_at
+-- instant on the calendar of the current zone let a change of zone add or skip a day.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active_date DATE;
+
+UPDATE users
+SET last_active_date = (last_active_at AT TIME ZONE timezone)::date
+WHERE last_active_at IS NOT NULL AND last_active_date IS NULL;
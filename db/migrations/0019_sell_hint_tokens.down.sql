@@ -0,0 +1,4 @@
+UPDATE shop_items SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = 'hint_token';
+
+DELETE FROM shop_items
+WHERE id = 'hint_token' AND NOT EXISTS (SELECT 1 FROM user_inventory WHERE item_id = 'hint_token');
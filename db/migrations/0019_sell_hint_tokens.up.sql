@@ -0,0 +1,4 @@
+-- Hint tokens can be redeemed for exercise hints now, so they go back on sale
+INSERT INTO shop_items (id, kind, name, description, price) VALUES
+    ('hint_token', 'hint_token', 'Hint Token', 'Redeem for a hint on a tough exercise', 50)
+ON CONFLICT (id) DO UPDATE SET active = TRUE, updated_at = CURRENT_TIMESTAMP;
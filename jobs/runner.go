@@ -0,0 +1,76 @@
+// Package jobs runs background tasks, such as clearing out expired rows, on a fixed interval.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is a task that runs every Interval. Run should log what it did; errors are logged by the Runner
+// and the job runs again at its next tick.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs jobs in the background until its context is cancelled
+type Runner struct {
+	logger *slog.Logger
+	jobs   []Job
+	wg     sync.WaitGroup
+}
+
+func NewRunner(logger *slog.Logger) *Runner {
+	return &Runner{logger: logger}
+}
+
+// Add schedules a job. Jobs must be added before Start.
+func (r *Runner) Add(job Job) {
+	r.jobs = append(r.jobs, job)
+}
+
+// Start runs every job once right away and then on its interval, each in its own goroutine,
+// until ctx is cancelled. Wait blocks until they have all stopped.
+func (r *Runner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.loop(ctx, job)
+		}()
+	}
+}
+
+// Wait blocks until every job has stopped, letting a run in progress finish first
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			r.logger.Debug("Stopped job", "job", job.Name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, job Job) {
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		r.logger.Error("Job failed", "job", job.Name, "error", err)
+		return
+	}
+
+	r.logger.Debug("Ran job", "job", job.Name, "duration", time.Since(start))
+}
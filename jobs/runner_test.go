@@ -0,0 +1,55 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/jobs"
+)
+
+func TestRunner(t *testing.T) {
+	runner := jobs.NewRunner(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var runs, failures atomic.Int32
+	runner.Add(jobs.Job{
+		Name:     "count",
+		Interval: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+	runner.Add(jobs.Job{
+		Name:     "fail",
+		Interval: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			failures.Add(1)
+			return errors.New("broken")
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for (runs.Load() < 3 || failures.Load() < 3) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if runs.Load() < 3 || failures.Load() < 3 {
+		t.Fatalf("expected both jobs to keep running, got %d runs and %d failures", runs.Load(), failures.Load())
+	}
+
+	cancel()
+	runner.Wait()
+
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Error("expected jobs to stop once the context is cancelled")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"os"
@@ -14,10 +15,17 @@ import (
 	"github.com/tylerolson/capstone-backend/api"
 	"github.com/tylerolson/capstone-backend/course"
 	"github.com/tylerolson/capstone-backend/db"
+	"github.com/tylerolson/capstone-backend/jobs"
+	"github.com/tylerolson/capstone-backend/services/achievements"
+	"github.com/tylerolson/capstone-backend/services/attempt"
+	"github.com/tylerolson/capstone-backend/services/idempotency"
 	"github.com/tylerolson/capstone-backend/services/points"
 	"github.com/tylerolson/capstone-backend/services/progress"
 	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/shop"
+	"github.com/tylerolson/capstone-backend/services/social"
 	"github.com/tylerolson/capstone-backend/services/user"
+	"github.com/tylerolson/capstone-backend/tokenhash"
 )
 
 func main() {
@@ -32,6 +40,15 @@ func main() {
 		logger.Warn(".env not found")
 	}
 
+	// Commands that don't need the database
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		if err := runValidate(os.Args[2:]); err != nil {
+			logger.Error("Command failed", "command", os.Args[1], "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Get database connection details from environment variables
 	dbUser := os.Getenv("POSTGRES_USER")
 	dbPassword := os.Getenv("POSTGRES_PASSWORD")
@@ -48,6 +65,26 @@ func main() {
 	}
 	defer database.Close()
 
+	// Subcommands run against the database and exit instead of starting the server
+	if len(os.Args) > 1 {
+		if err := runCommand(database, logger, os.Args[1:]); err != nil {
+			logger.Error("Command failed", "command", os.Args[1], "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Bring the schema up to date before anything touches it
+	if os.Getenv("SKIP_MIGRATIONS") != "true" {
+		if err := migrateUp(database, logger); err != nil {
+			logger.Error("Failed to migrate database", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Initialize services with database
 	userService := user.NewService(database)
 	progressService := progress.NewService(database)
@@ -56,16 +93,35 @@ func main() {
 	// Initialize the new points service
 	pointsService := points.NewService(database)
 
-	// Configure point values (OPTIONAL - uses defaults if not set)
-	pointsConfig := points.PointsConfig{
-		CorrectAnswerPoints:   10,
-		StreakBonusMultiplier: 2,   // 2 points per streak level
-		MaxStreakBonus:        50,  // Maximum 50 bonus points for streaks
-		LessonCompletionBonus: 100, // 100 points for completing a lesson
-		CourseCompletionBonus: 500, // 500 points for completing a course
-	}
+	// Configure point values (OPTIONAL - uses defaults if not set).
+	// Start from the defaults so the streak milestones and scoring policies stay on.
+	pointsConfig := points.DefaultPointsConfig
+	pointsConfig.CorrectAnswerPoints = 10
+	pointsConfig.StreakBonusMultiplier = 2   // 2 points per streak level
+	pointsConfig.MaxStreakBonus = 50         // Maximum 50 bonus points for streaks
+	pointsConfig.LessonCompletionBonus = 100 // 100 points for completing a lesson
+	pointsConfig.CourseCompletionBonus = 500 // 500 points for completing a course
+	// A day counts toward the daily streak after one correct answer
+	pointsConfig.DailyStreakRule = points.StreakRule{MinCorrectAnswers: 1}
 	pointsService.SetPointsConfig(pointsConfig)
 
+	achievementsService := achievements.NewService(database, pointsService, progressService)
+	attemptService := attempt.NewService(database, pointsService)
+
+	// Responses to requests with an Idempotency-Key are replayed for this long
+	idempotencyWindow := idempotency.DefaultWindow
+	if window := os.Getenv("IDEMPOTENCY_WINDOW"); window != "" {
+		parsed, err := time.ParseDuration(window)
+		if err != nil || parsed <= 0 {
+			logger.Error("Invalid IDEMPOTENCY_WINDOW", "value", window, "error", err)
+			os.Exit(1)
+		}
+		idempotencyWindow = parsed
+	}
+	idempotencyService := idempotency.NewService(database, idempotencyWindow)
+	socialService := social.NewService(database)
+	shopService := shop.NewService(database, pointsService)
+
 	postmarkAPIKey := os.Getenv("POSTMARK_API_KEY")
 	if postmarkAPIKey != "" {
 		logger.Info("Initializing Postmark client")
@@ -76,12 +132,43 @@ func main() {
 		logger.Warn("No Postmark API key provided - password reset emails will only be logged")
 	}
 
-	// Initialize course store with database
-	coursesStore := course.NewJSONStore("./data")
-	if err := coursesStore.LoadCourseDir(); err != nil {
-		logger.Error("Failed to load courses", "error", err)
+	// Without a stable secret, answers to lessons loaded before a restart or from another instance are graded
+	// against a different shuffle
+	shuffleSecret := os.Getenv("SHUFFLE_SECRET")
+	if shuffleSecret == "" {
+		logger.Error("SHUFFLE_SECRET is required, refusing to start")
 		os.Exit(1)
 	}
+	course.SetShuffleKey([]byte(shuffleSecret))
+
+	// Without a stable secret, tokens issued before a restart or by another instance stop matching
+	tokenSecret := os.Getenv("TOKEN_SECRET")
+	if tokenSecret == "" {
+		logger.Error("TOKEN_SECRET is required, refusing to start")
+		os.Exit(1)
+	}
+	tokenhash.SetKey([]byte(tokenSecret))
+
+	// Courses come from the JSON files in ./data unless COURSE_STORE=postgres
+	var coursesStore course.Service
+	if os.Getenv("COURSE_STORE") == "postgres" {
+		logger.Info("Serving courses from the database")
+		coursesStore = course.NewDBStore(database)
+	} else {
+		jsonStore := course.NewJSONStore("./data")
+		if err := jsonStore.LoadCourseDir(); err != nil {
+			logCourseProblems(logger, err)
+			logger.Error("Failed to load courses, refusing to start")
+			os.Exit(1)
+		}
+		coursesStore = jsonStore
+
+		// Pick up edits to the course files without a restart
+		if os.Getenv("WATCH_COURSES") == "true" {
+			logger.Info("Watching course files for changes")
+			go jsonStore.Watch(ctx, time.Second, logger)
+		}
+	}
 
 	if err := api.EnsureProfilePicDirectory(); err != nil {
 		logger.Error("Error creating profile pictures directory", "error", err)
@@ -95,10 +182,47 @@ func main() {
 		progressService,
 		sessionService,
 		pointsService,
+		achievementsService,
+		attemptService,
+		idempotencyService,
+		socialService,
+		shopService,
 		database,
 		logger,
 	)
 
+	// Sessions in use stay signed in without refreshing their token
+	if os.Getenv("SLIDING_SESSIONS") == "true" {
+		logger.Info("Sliding session expiry is on")
+		server.SlidingSessions = true
+	}
+
+	// Other instances' logouts and role changes are only seen once cached identities expire
+	if ttl := os.Getenv("IDENTITY_CACHE_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed < 0 {
+			logger.Error("Invalid IDENTITY_CACHE_TTL", "value", ttl, "error", err)
+			os.Exit(1)
+		}
+		server.SetIdentityCache(api.DefaultIdentityCacheSize, parsed)
+	}
+
+	// Clear out expired sessions, reset tokens and idempotency keys in the background
+	cleanupInterval := defaultCleanupInterval
+	if interval := os.Getenv("CLEANUP_INTERVAL"); interval != "" {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil || parsed <= 0 {
+			logger.Error("Invalid CLEANUP_INTERVAL", "value", interval, "error", err)
+			os.Exit(1)
+		}
+		cleanupInterval = parsed
+	}
+	runner := jobs.NewRunner(logger)
+	for _, job := range cleanupJobs(cleanupInterval, sessionService, userService, idempotencyService, logger) {
+		runner.Add(job)
+	}
+	runner.Start(ctx)
+
 	// Create an HTTP server with adjusted timeouts
 	srv := &http.Server{
 		Addr:              ":8080",
@@ -110,6 +234,11 @@ func main() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
+		cancel()
+
+		// Let a job that's running finish before its database goes away
+		runner.Wait()
+		logger.Info("Stopped background jobs")
 
 		if err := database.Close(); err != nil {
 			logger.Error("DB close error", "error", err)
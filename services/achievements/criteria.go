@@ -0,0 +1,135 @@
+package achievements
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+)
+
+// Snapshot is the user state that criteria are evaluated against
+type Snapshot struct {
+	TotalPoints      int
+	MaxDailyStreak   int
+	TotalAttempts    int
+	CorrectAttempts  int
+	CompletedLessons map[string]int // course ID -> completed lessons
+	CompletedCourses map[string]bool
+}
+
+// TriggeredBy reports whether an event of the given trigger can change the outcome of the criteria
+func (c Criteria) TriggeredBy(trigger Trigger) bool {
+	switch c.Type {
+	case CriteriaLessonsCompleted:
+		return trigger == TriggerLessonCompleted
+	case CriteriaDailyStreak:
+		return trigger == TriggerDailyStreak
+	case CriteriaAccuracy:
+		return trigger == TriggerAttempt
+	case CriteriaCourseCompleted:
+		return trigger == TriggerCourseCompleted
+	case CriteriaTotalPoints:
+		return trigger == TriggerPoints
+	default:
+		return false
+	}
+}
+
+// Met reports whether the snapshot satisfies the criteria
+func (c Criteria) Met(snapshot *Snapshot) (bool, error) {
+	switch c.Type {
+	case CriteriaLessonsCompleted:
+		completed := 0
+		for courseID, count := range snapshot.CompletedLessons {
+			if c.CourseID == "" || c.CourseID == courseID {
+				completed += count
+			}
+		}
+		return completed >= c.Count, nil
+
+	case CriteriaDailyStreak:
+		return snapshot.MaxDailyStreak >= c.Days, nil
+
+	case CriteriaAccuracy:
+		if snapshot.TotalAttempts == 0 || snapshot.TotalAttempts < c.MinAttempts {
+			return false, nil
+		}
+		accuracy := float64(snapshot.CorrectAttempts) / float64(snapshot.TotalAttempts) * 100
+		return accuracy >= c.Percent, nil
+
+	case CriteriaCourseCompleted:
+		return snapshot.CompletedCourses[c.CourseID], nil
+
+	case CriteriaTotalPoints:
+		return snapshot.TotalPoints >= c.Points, nil
+
+	default:
+		return false, fmt.Errorf("%w: %q", ErrUnknownCriteria, c.Type)
+	}
+}
+
+// triggered filters achievements down to the ones any of the triggers can affect
+func triggered(candidates []*Achievement, triggers []Trigger) []*Achievement {
+	matched := make([]*Achievement, 0, len(candidates))
+	for _, a := range candidates {
+		for _, trigger := range triggers {
+			if a.Criteria.TriggeredBy(trigger) {
+				matched = append(matched, a)
+				break
+			}
+		}
+	}
+
+	return matched
+}
+
+// buildSnapshot gathers the user state needed to evaluate criteria from the points and progress services
+func buildSnapshot(pointsService points.Service, progressService progress.Service, userID int) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		CompletedLessons: make(map[string]int),
+		CompletedCourses: make(map[string]bool),
+	}
+
+	userPoints, err := pointsService.GetUserTotalPoints(userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.TotalPoints = userPoints.TotalPoints
+
+	// Only the max streak is used, which doesn't depend on the zone days are counted in
+	streak, err := pointsService.GetDailyStreak(userID, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.MaxDailyStreak = streak.MaxStreak
+
+	accuracy, err := pointsService.GetAccuracyStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.TotalAttempts = accuracy.TotalAttempts
+	snapshot.CorrectAttempts = accuracy.CorrectAttempts
+
+	lessons, err := progressService.ListLessonProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, lesson := range lessons {
+		if lesson.Status == progress.StatusCompleted {
+			snapshot.CompletedLessons[lesson.CourseID]++
+		}
+	}
+
+	courses, err := progressService.ListCourseProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, course := range courses {
+		if course.Status == progress.StatusCompleted {
+			snapshot.CompletedCourses[course.CourseID] = true
+		}
+	}
+
+	return snapshot, nil
+}
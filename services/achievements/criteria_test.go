@@ -0,0 +1,59 @@
+package achievements_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/services/achievements"
+)
+
+func TestCriteriaMet(t *testing.T) {
+	snapshot := &achievements.Snapshot{
+		TotalPoints:      450,
+		MaxDailyStreak:   7,
+		TotalAttempts:    40,
+		CorrectAttempts:  38,
+		CompletedLessons: map[string]int{"algorithms": 3, "data_structures": 2},
+		CompletedCourses: map[string]bool{"programming_basics": true},
+	}
+
+	tests := []struct {
+		name     string
+		criteria achievements.Criteria
+		want     bool
+	}{
+		{"lessons across courses", achievements.Criteria{Type: achievements.CriteriaLessonsCompleted, Count: 5}, true},
+		{"lessons within a course", achievements.Criteria{Type: achievements.CriteriaLessonsCompleted, Count: 3, CourseID: "data_structures"}, false},
+		{"streak reached", achievements.Criteria{Type: achievements.CriteriaDailyStreak, Days: 7}, true},
+		{"streak not reached", achievements.Criteria{Type: achievements.CriteriaDailyStreak, Days: 30}, false},
+		{"accuracy reached", achievements.Criteria{Type: achievements.CriteriaAccuracy, Percent: 90, MinAttempts: 40}, true},
+		{"accuracy needs more attempts", achievements.Criteria{Type: achievements.CriteriaAccuracy, Percent: 90, MinAttempts: 50}, false},
+		{"course finished", achievements.Criteria{Type: achievements.CriteriaCourseCompleted, CourseID: "programming_basics"}, true},
+		{"course not finished", achievements.Criteria{Type: achievements.CriteriaCourseCompleted, CourseID: "algorithms"}, false},
+		{"points reached", achievements.Criteria{Type: achievements.CriteriaTotalPoints, Points: 450}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.criteria.Met(snapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Met() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownCriteria(t *testing.T) {
+	criteria := achievements.Criteria{Type: "speedrun"}
+
+	if _, err := criteria.Met(&achievements.Snapshot{}); !errors.Is(err, achievements.ErrUnknownCriteria) {
+		t.Errorf("expected ErrUnknownCriteria, got %v", err)
+	}
+
+	if criteria.TriggeredBy(achievements.TriggerPoints) {
+		t.Error("unknown criteria should never be triggered")
+	}
+}
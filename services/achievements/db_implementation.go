@@ -0,0 +1,189 @@
+package achievements
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+)
+
+// errInvalidCriteria marks an achievement row whose criteria don't decode
+var errInvalidCriteria = errors.New("invalid criteria")
+
+type service struct {
+	db              *sql.DB
+	pointsService   points.Service
+	progressService progress.Service
+}
+
+func NewService(database *sql.DB, pointsService points.Service, progressService progress.Service) Service {
+	return &service{
+		db:              database,
+		pointsService:   pointsService,
+		progressService: progressService,
+	}
+}
+
+func (s *service) ListAchievements() ([]*Achievement, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, description, criteria
+		FROM achievements
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievements: %v", err)
+	}
+	defer rows.Close()
+
+	achievements := make([]*Achievement, 0)
+	for rows.Next() {
+		achievement, err := scanAchievement(rows)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, achievement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating achievement rows: %v", err)
+	}
+
+	return achievements, nil
+}
+
+func (s *service) GetUserAchievements(userID int) ([]*UserAchievement, error) {
+	rows, err := s.db.Query(`
+		SELECT a.id, a.name, a.description, a.criteria, ua.earned_at
+		FROM user_achievements ua
+		JOIN achievements a ON a.id = ua.achievement_id
+		WHERE ua.user_id = $1
+		ORDER BY ua.earned_at, a.id`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user achievements: %v", err)
+	}
+	defer rows.Close()
+
+	earned := make([]*UserAchievement, 0)
+	for rows.Next() {
+		userAchievement := &UserAchievement{}
+		var criteria []byte
+
+		err := rows.Scan(
+			&userAchievement.ID,
+			&userAchievement.Name,
+			&userAchievement.Description,
+			&criteria,
+			&userAchievement.EarnedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user achievement row: %v", err)
+		}
+
+		if err := json.Unmarshal(criteria, &userAchievement.Criteria); err != nil {
+			return nil, fmt.Errorf("invalid criteria for achievement %d: %v", userAchievement.ID, err)
+		}
+
+		earned = append(earned, userAchievement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user achievement rows: %v", err)
+	}
+
+	return earned, nil
+}
+
+func (s *service) Evaluate(userID int, triggers ...Trigger) ([]*UserAchievement, error) {
+	rows, err := s.db.Query(`
+		SELECT a.id, a.name, a.description, a.criteria
+		FROM achievements a
+		WHERE NOT EXISTS (
+			SELECT 1 FROM user_achievements ua
+			WHERE ua.achievement_id = a.id AND ua.user_id = $1
+		)
+		ORDER BY a.id`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unearned achievements: %v", err)
+	}
+	defer rows.Close()
+
+	unearned := make([]*Achievement, 0)
+	for rows.Next() {
+		// One bad row shouldn't keep the user from earning every other achievement
+		achievement, err := scanAchievement(rows)
+		if errors.Is(err, errInvalidCriteria) {
+			log.Printf("Skipping achievement: %v", err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		unearned = append(unearned, achievement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating achievement rows: %v", err)
+	}
+
+	candidates := triggered(unearned, triggers)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	snapshot, err := buildSnapshot(s.pointsService, s.progressService, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build achievement snapshot: %v", err)
+	}
+
+	var awarded []*UserAchievement
+	for _, achievement := range candidates {
+		met, err := achievement.Criteria.Met(snapshot)
+		if err != nil {
+			log.Printf("Skipping achievement %d: %v", achievement.ID, err)
+			continue
+		}
+		if !met {
+			continue
+		}
+
+		// The unique (user_id, achievement_id) constraint keeps awarding idempotent,
+		// no row comes back if a concurrent request already awarded it
+		userAchievement := &UserAchievement{Achievement: *achievement}
+		err = s.db.QueryRow(`
+			INSERT INTO user_achievements (user_id, achievement_id)
+			VALUES ($1, $2)
+			ON CONFLICT (user_id, achievement_id) DO NOTHING
+			RETURNING earned_at`,
+			userID, achievement.ID).Scan(&userAchievement.EarnedAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to award achievement %d: %v", achievement.ID, err)
+		}
+
+		awarded = append(awarded, userAchievement)
+	}
+
+	return awarded, nil
+}
+
+func scanAchievement(rows *sql.Rows) (*Achievement, error) {
+	achievement := &Achievement{}
+	var criteria []byte
+
+	if err := rows.Scan(&achievement.ID, &achievement.Name, &achievement.Description, &criteria); err != nil {
+		return nil, fmt.Errorf("failed to scan achievement row: %v", err)
+	}
+
+	if err := json.Unmarshal(criteria, &achievement.Criteria); err != nil {
+		return nil, fmt.Errorf("%w for achievement %d: %v", errInvalidCriteria, achievement.ID, err)
+	}
+
+	return achievement, nil
+}
@@ -0,0 +1,65 @@
+package achievements
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownCriteria = errors.New("unknown achievement criteria")
+)
+
+// CriteriaType is the kind of rule an achievement is earned by
+type CriteriaType string
+
+const (
+	CriteriaLessonsCompleted CriteriaType = "lessons_completed" // complete Count lessons, optionally within CourseID
+	CriteriaDailyStreak      CriteriaType = "daily_streak"      // reach a daily streak of Days
+	CriteriaAccuracy         CriteriaType = "accuracy"          // reach Percent accuracy over at least MinAttempts attempts
+	CriteriaCourseCompleted  CriteriaType = "course_completed"  // finish CourseID
+	CriteriaTotalPoints      CriteriaType = "total_points"      // earn Points in total
+)
+
+// Trigger is the event that caused achievements to be evaluated
+type Trigger string
+
+const (
+	TriggerPoints          Trigger = "points"
+	TriggerAttempt         Trigger = "attempt"
+	TriggerLessonCompleted Trigger = "lesson_completed"
+	TriggerCourseCompleted Trigger = "course_completed"
+	TriggerDailyStreak     Trigger = "daily_streak"
+)
+
+// Criteria is the declarative rule stored in achievements.criteria
+type Criteria struct {
+	Type        CriteriaType `json:"type"`
+	Count       int          `json:"count,omitempty"`
+	Days        int          `json:"days,omitempty"`
+	Percent     float64      `json:"percent,omitempty"`
+	MinAttempts int          `json:"minAttempts,omitempty"`
+	Points      int          `json:"points,omitempty"`
+	CourseID    string       `json:"courseId,omitempty"`
+}
+
+type Achievement struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Criteria    Criteria `json:"criteria"`
+}
+
+type UserAchievement struct {
+	Achievement
+	EarnedAt time.Time `json:"earnedAt"`
+}
+
+type Service interface {
+	ListAchievements() ([]*Achievement, error)
+	GetUserAchievements(userID int) ([]*UserAchievement, error)
+
+	// Evaluate checks the user's unearned achievements that the triggers can affect
+	// and awards the ones that are now met. Only newly earned achievements are returned.
+	// Achievements with criteria that can't be read are logged and skipped.
+	Evaluate(userID int, triggers ...Trigger) ([]*UserAchievement, error)
+}
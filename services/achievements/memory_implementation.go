@@ -0,0 +1,105 @@
+package achievements
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+)
+
+type memoryService struct {
+	mu              sync.Mutex
+	catalog         []*Achievement
+	earned          map[int]map[int]time.Time // user ID -> achievement ID -> earned at
+	pointsService   points.Service
+	progressService progress.Service
+}
+
+// NewMemoryService creates an achievements service over a fixed catalog that keeps
+// earned achievements in memory, for tests and local development
+func NewMemoryService(pointsService points.Service, progressService progress.Service, catalog []*Achievement) Service {
+	return &memoryService{
+		catalog:         catalog,
+		earned:          make(map[int]map[int]time.Time),
+		pointsService:   pointsService,
+		progressService: progressService,
+	}
+}
+
+func (s *memoryService) ListAchievements() ([]*Achievement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	achievements := make([]*Achievement, 0, len(s.catalog))
+	for _, a := range s.catalog {
+		achievement := *a
+		achievements = append(achievements, &achievement)
+	}
+
+	return achievements, nil
+}
+
+func (s *memoryService) GetUserAchievements(userID int) ([]*UserAchievement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	earned := make([]*UserAchievement, 0)
+	for _, a := range s.catalog {
+		if earnedAt, ok := s.earned[userID][a.ID]; ok {
+			earned = append(earned, &UserAchievement{Achievement: *a, EarnedAt: earnedAt})
+		}
+	}
+
+	sort.SliceStable(earned, func(i, j int) bool {
+		return earned[i].EarnedAt.Before(earned[j].EarnedAt)
+	})
+
+	return earned, nil
+}
+
+func (s *memoryService) Evaluate(userID int, triggers ...Trigger) ([]*UserAchievement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unearned := make([]*Achievement, 0)
+	for _, a := range s.catalog {
+		if _, ok := s.earned[userID][a.ID]; !ok {
+			unearned = append(unearned, a)
+		}
+	}
+
+	candidates := triggered(unearned, triggers)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	snapshot, err := buildSnapshot(s.pointsService, s.progressService, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var awarded []*UserAchievement
+	for _, achievement := range candidates {
+		met, err := achievement.Criteria.Met(snapshot)
+		if err != nil {
+			log.Printf("Skipping achievement %d: %v", achievement.ID, err)
+			continue
+		}
+		if !met {
+			continue
+		}
+
+		if s.earned[userID] == nil {
+			s.earned[userID] = make(map[int]time.Time)
+		}
+		earnedAt := time.Now()
+		s.earned[userID][achievement.ID] = earnedAt
+
+		awarded = append(awarded, &UserAchievement{Achievement: *achievement, EarnedAt: earnedAt})
+	}
+
+	return awarded, nil
+}
@@ -0,0 +1,49 @@
+package achievements_test
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/achievements"
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestPostgresSkipsInvalidCriteria(t *testing.T) {
+	database := dbtest.Open(t)
+
+	username := dbtest.UniqueName("achievementuser")
+	userService := user.NewService(database)
+	testUser, err := userService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Points criteria the new user already meets, and the same with a count that isn't a number
+	var ids []int
+	for _, criteria := range []string{`{"type": "total_points", "points": 0}`, `{"type": "total_points", "points": "lots"}`} {
+		var id int
+		err := database.QueryRow(`INSERT INTO achievements (name, description, criteria) VALUES ($1, 'Test', $2) RETURNING id`,
+			dbtest.UniqueName("achievement"), criteria).Scan(&id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	t.Cleanup(func() {
+		userService.DeleteUser(username)
+		database.Exec(`DELETE FROM user_achievements WHERE achievement_id = ANY($1)`, pq.Array(ids))
+		database.Exec(`DELETE FROM achievements WHERE id = ANY($1)`, pq.Array(ids))
+	})
+
+	service := achievements.NewService(database, points.NewService(database), progress.NewService(database))
+	awarded, err := service.Evaluate(testUser.ID, achievements.TriggerPoints)
+	if err != nil {
+		t.Fatalf("expected the invalid criteria to be skipped, got %v", err)
+	}
+	if len(awarded) != 1 || awarded[0].ID != ids[0] {
+		t.Errorf("expected only achievement %d to be awarded, got %+v", ids[0], awarded)
+	}
+}
@@ -0,0 +1,79 @@
+package attempt
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+)
+
+type service struct {
+	db     *sql.DB
+	points points.Service
+}
+
+// NewService creates an attempt service that scores with the config of pointsService
+func NewService(database *sql.DB, pointsService points.Service) Service {
+	return &service{db: database, points: pointsService}
+}
+
+func (s *service) Submit(attempt *progress.ExerciseAttempt, loc *time.Location) (*Result, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := progress.InsertExerciseAttempt(tx, attempt); err != nil {
+		return nil, fmt.Errorf("failed to record exercise attempt: %v", err)
+	}
+
+	if err := points.CountAttempt(tx, attempt.UserID, attempt.IsCorrect); err != nil {
+		return nil, err
+	}
+
+	config := s.points.GetPointsConfig()
+	transaction, err := points.AwardAnswer(tx, config, attempt.UserID, attempt.CourseID,
+		attempt.LessonID, attempt.ExerciseID, attempt.AttemptNumber, attempt.IsCorrect)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := points.RecordActivity(tx, config, attempt.UserID, loc, attempt.IsCorrect, earnedPoints(transaction))
+	if err != nil {
+		return nil, err
+	}
+
+	lessonPoints, err := points.QueryLessonPoints(tx, attempt.UserID, attempt.CourseID, attempt.LessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	accuracy, err := points.QueryAccuracyStats(tx, attempt.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return &Result{
+		Attempt:      attempt,
+		Transaction:  transaction,
+		LessonPoints: lessonPoints,
+		Accuracy:     accuracy,
+		Activity:     activity,
+	}, nil
+}
+
+// earnedPoints returns the points a correct answer transaction awarded, 0 for wrong answers
+func earnedPoints(transaction *points.PointTransaction) int {
+	if transaction == nil {
+		return 0
+	}
+
+	return transaction.Points
+}
@@ -0,0 +1,25 @@
+package attempt
+
+import (
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+)
+
+// Result is everything that changed because of an attempt
+type Result struct {
+	Attempt      *progress.ExerciseAttempt
+	Transaction  *points.PointTransaction // nil for wrong answers
+	LessonPoints *points.LessonPoints
+	Accuracy     *points.AccuracyStats
+	Activity     *points.ActivityResult // The day's activity and whether it now counts toward the daily streak
+}
+
+// Service processes graded exercise attempts. Recording the attempt, updating accuracy, streaks, daily
+// activity and points either all happen or none do, so the ledger never drifts from the attempts.
+type Service interface {
+	// Submit records an attempt whose IsCorrect is already set and scores it. Daily activity is
+	// recorded under the day in loc, the user's timezone.
+	Submit(attempt *progress.ExerciseAttempt, loc *time.Location) (*Result, error)
+}
@@ -0,0 +1,63 @@
+package attempt
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+)
+
+type memoryService struct {
+	mu       sync.Mutex
+	progress progress.Service
+	points   points.Service
+}
+
+// NewMemoryService creates an attempt service on top of in-memory progress and points services, for tests
+// and local development. Attempts are processed one at a time, but a failed step isn't rolled back.
+func NewMemoryService(progressService progress.Service, pointsService points.Service) Service {
+	return &memoryService{progress: progressService, points: pointsService}
+}
+
+func (s *memoryService) Submit(attempt *progress.ExerciseAttempt, loc *time.Location) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.progress.RecordExerciseAttempt(attempt); err != nil {
+		return nil, err
+	}
+
+	if err := s.points.UpdateAccuracyStats(attempt.UserID, attempt.IsCorrect); err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.points.AwardPointsForCorrectAnswer(attempt.UserID, attempt.CourseID, attempt.LessonID,
+		attempt.ExerciseID, attempt.AttemptNumber, attempt.IsCorrect)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := s.points.RecordActivity(attempt.UserID, loc, attempt.IsCorrect, earnedPoints(transaction))
+	if err != nil {
+		return nil, err
+	}
+
+	lessonPoints, err := s.points.GetLessonPoints(attempt.UserID, attempt.CourseID, attempt.LessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	accuracy, err := s.points.GetAccuracyStats(attempt.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Attempt:      attempt,
+		Transaction:  transaction,
+		LessonPoints: lessonPoints,
+		Accuracy:     accuracy,
+		Activity:     activity,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package attempt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/attempt"
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestMemoryService(t *testing.T) {
+	testService(t, attempt.NewMemoryService(progress.NewMemoryService(), points.NewMemoryService()), 1)
+}
+
+func TestPostgresService(t *testing.T) {
+	database := dbtest.Open(t)
+
+	username := dbtest.UniqueName("attemptuser")
+	userService := user.NewService(database)
+	testUser, err := userService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { userService.DeleteUser(username) })
+
+	progressService := progress.NewService(database)
+	if _, err := progressService.GetOrCreateCourseProgress(testUser.ID, "testCourse"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := progressService.GetOrCreateLessonProgress(testUser.ID, "testCourse", "Lesson1"); err != nil {
+		t.Fatal(err)
+	}
+
+	testService(t, attempt.NewService(database, points.NewService(database)), testUser.ID)
+}
+
+// testService runs the same checks against every attempt.Service implementation
+func testService(t *testing.T, service attempt.Service, userID int) {
+	submit := func(exerciseID string, isCorrect bool) *attempt.Result {
+		t.Helper()
+
+		result, err := service.Submit(&progress.ExerciseAttempt{
+			UserID:     userID,
+			CourseID:   "testCourse",
+			LessonID:   "Lesson1",
+			ExerciseID: exerciseID,
+			Answer:     `"answer"`,
+			IsCorrect:  isCorrect,
+		}, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	t.Run("WrongAnswer", func(t *testing.T) {
+		result := submit("ex1", false)
+		if result.Attempt.AttemptNumber != 1 {
+			t.Errorf("expected attempt 1, got %d", result.Attempt.AttemptNumber)
+		}
+		if result.Transaction != nil {
+			t.Errorf("expected no transaction for a wrong answer, got %+v", result.Transaction)
+		}
+		if result.Accuracy.TotalAttempts != 1 || result.Accuracy.CorrectAttempts != 0 {
+			t.Errorf("unexpected accuracy after a wrong answer: %+v", result.Accuracy)
+		}
+		if result.Activity.DayCounted || result.Activity.Activity.Attempts != 1 {
+			t.Errorf("expected a wrong answer not to count toward the daily streak, got %+v", result.Activity.Activity)
+		}
+	})
+
+	t.Run("CorrectOnRetry", func(t *testing.T) {
+		result := submit("ex1", true)
+		if result.Attempt.AttemptNumber != 2 {
+			t.Errorf("expected attempt 2, got %d", result.Attempt.AttemptNumber)
+		}
+		if result.Transaction == nil || result.Transaction.ScoringPolicy != points.PolicyDiminishing {
+			t.Fatalf("expected a diminishing transaction, got %+v", result.Transaction)
+		}
+		if want := points.DefaultPointsConfig.CorrectAnswerPoints / 2; result.Transaction.Points != want {
+			t.Errorf("expected %d points, got %d", want, result.Transaction.Points)
+		}
+		if result.Accuracy.TotalAttempts != 2 || result.Accuracy.CorrectAttempts != 1 {
+			t.Errorf("unexpected accuracy: %+v", result.Accuracy)
+		}
+		if result.LessonPoints.CurrentStreak != 1 || result.LessonPoints.TotalPoints != result.Transaction.Points {
+			t.Errorf("unexpected lesson points: %+v", result.LessonPoints)
+		}
+		if !result.Activity.DayCounted || result.Activity.Activity.Points != result.Transaction.Points {
+			t.Errorf("expected the correct answer to count the day, got %+v", result.Activity.Activity)
+		}
+	})
+}
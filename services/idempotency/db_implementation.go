@@ -0,0 +1,121 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type service struct {
+	db     *sql.DB
+	window time.Duration
+}
+
+// NewService creates an idempotency service that keeps responses for window
+func NewService(database *sql.DB, window time.Duration) Service {
+	return &service{db: database, window: window}
+}
+
+// maxClaimAttempts bounds how often Begin retries when the record it lost the claim to is gone
+// before it can be read
+const maxClaimAttempts = 3
+
+func (s *service) Begin(userID int, key, requestHash string) (*Record, error) {
+	for range maxClaimAttempts {
+		record, err := s.claim(userID, key, requestHash)
+		if err == sql.ErrNoRows {
+			// The key was released or purged after the claim failed, so try again
+			continue
+		}
+
+		return record, err
+	}
+
+	return nil, fmt.Errorf("failed to claim idempotency key after %d attempts", maxClaimAttempts)
+}
+
+// claim tries to claim a key once, returning the record that holds it when that fails
+func (s *service) claim(userID int, key, requestHash string) (*Record, error) {
+	// Claim the key, taking over a record whose window or lease has passed
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP`
+
+	result, err := s.db.Exec(query, userID, key, requestHash, claimExpiry(time.Now(), s.window))
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if claimed == 1 {
+		return nil, nil
+	}
+
+	record := &Record{UserID: userID, Key: key}
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+	query = `
+		SELECT request_hash, status_code, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`
+
+	err = s.db.QueryRow(query, userID, key).Scan(&record.RequestHash, &statusCode, &contentType, &record.Body,
+		&record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	record.ContentType = contentType.String
+
+	return record, nil
+}
+
+func (s *service) Complete(userID int, key string, statusCode int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_body = $5, expires_at = $6
+		WHERE user_id = $1 AND key = $2`
+
+	result, err := s.db.Exec(query, userID, key, statusCode, contentType, body, time.Now().Add(s.window))
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *service) Release(userID int, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL`
+	_, err := s.db.Exec(query, userID, key)
+
+	return err
+}
+
+func (s *service) PurgeExpired(ctx context.Context) (int, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
@@ -0,0 +1,52 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// MaxKeyLength is the longest Idempotency-Key that is accepted
+	MaxKeyLength = 255
+	// DefaultWindow is how long responses are kept for replay when no window is configured
+	DefaultWindow = 24 * time.Hour
+	// ClaimLease is how long a request holds its key before a retry may take it over, for when the
+	// process died before completing or releasing it
+	ClaimLease = time.Minute
+)
+
+var ErrNotFound = errors.New("idempotency key not found")
+
+// Record is a request made with an idempotency key and, once it finished, the response it got
+type Record struct {
+	UserID      int
+	Key         string
+	RequestHash string
+	StatusCode  int // 0 while the request is still being processed
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time // When the claim lease runs out, or once completed, when the response stops being kept
+}
+
+func (r *Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+type Service interface {
+	// Begin claims a key for a request for ClaimLease. It returns nil when the key was free (or its record
+	// expired), otherwise the record of the earlier request so its response can be replayed.
+	Begin(userID int, key, requestHash string) (*Record, error)
+	// Complete stores the response to a claimed key, keeping it for the window
+	Complete(userID int, key string, statusCode int, contentType string, body []byte) error
+	// Release frees a claimed key without storing a response, so the request can be retried
+	Release(userID int, key string) error
+	// PurgeExpired deletes the records past their window and returns how many there were
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// claimExpiry returns when a claim made at now lapses if it isn't completed
+func claimExpiry(now time.Time, window time.Duration) time.Time {
+	return now.Add(min(ClaimLease, window))
+}
@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type recordKey struct {
+	userID int
+	key    string
+}
+
+type memoryService struct {
+	mu      sync.Mutex
+	window  time.Duration
+	records map[recordKey]*Record
+}
+
+// NewMemoryService creates an idempotency service that keeps responses in memory for window,
+// for tests and local development
+func NewMemoryService(window time.Duration) Service {
+	return &memoryService{
+		window:  window,
+		records: make(map[recordKey]*Record),
+	}
+}
+
+func (s *memoryService) Begin(userID int, key, requestHash string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if record, ok := s.records[recordKey{userID, key}]; ok && now.Before(record.ExpiresAt) {
+		found := *record
+		return &found, nil
+	}
+
+	s.records[recordKey{userID, key}] = &Record{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   claimExpiry(now, s.window),
+	}
+
+	return nil, nil
+}
+
+func (s *memoryService) Complete(userID int, key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[recordKey{userID, key}]
+	if !ok {
+		return ErrNotFound
+	}
+
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Body = append([]byte(nil), body...)
+	record.ExpiresAt = time.Now().Add(s.window)
+
+	return nil
+}
+
+func (s *memoryService) Release(userID int, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[recordKey{userID, key}]; ok && !record.Completed() {
+		delete(s.records, recordKey{userID, key})
+	}
+
+	return nil
+}
+
+func (s *memoryService) PurgeExpired(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	purged := 0
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,156 @@
+package idempotency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/idempotency"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestMemoryService(t *testing.T) {
+	testService(t, idempotency.NewMemoryService(time.Hour), idempotency.NewMemoryService(-time.Second), 1)
+}
+
+func TestPostgresService(t *testing.T) {
+	database := dbtest.Open(t)
+
+	username := dbtest.UniqueName("idempotencyuser")
+	userService := user.NewService(database)
+	testUser, err := userService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { userService.DeleteUser(username) })
+
+	testService(t, idempotency.NewService(database, time.Hour), idempotency.NewService(database, -time.Second), testUser.ID)
+}
+
+// testService runs the same checks against every idempotency.Service implementation.
+// expiring keeps its records for a negative window, so they have always expired.
+func testService(t *testing.T, service, expiring idempotency.Service, userID int) {
+	t.Run("Claim And Replay", func(t *testing.T) {
+		record, err := service.Begin(userID, "key-1", "hash-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record != nil {
+			t.Fatalf("expected a free key, got %+v", record)
+		}
+
+		record, err = service.Begin(userID, "key-1", "hash-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil || record.Completed() {
+			t.Fatalf("expected an in-progress record, got %+v", record)
+		}
+
+		if err := service.Complete(userID, "key-1", 201, "application/json", []byte(`{"ok":true}`)); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err = service.Begin(userID, "key-1", "hash-2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil || record.RequestHash != "hash-1" || record.StatusCode != 201 ||
+			record.ContentType != "application/json" || string(record.Body) != `{"ok":true}` {
+			t.Errorf("unexpected stored record: %+v", record)
+		}
+		if record != nil && !record.ExpiresAt.After(time.Now().Add(idempotency.ClaimLease)) {
+			t.Errorf("expected the response to be kept for the window, not the claim lease, got %v", record.ExpiresAt)
+		}
+	})
+
+	t.Run("Claims Are Leased", func(t *testing.T) {
+		record, err := service.Begin(userID, "key-6", "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record, err = service.Begin(userID, "key-6", "hash"); err != nil || record == nil {
+			t.Fatalf("expected an in-progress record, got %+v, %v", record, err)
+		}
+		if record.ExpiresAt.After(time.Now().Add(idempotency.ClaimLease)) {
+			t.Errorf("expected the claim to lapse after its lease, got %v", record.ExpiresAt)
+		}
+
+		// A claim whose lease has run out, as when the server died mid-request, can be taken over
+		if _, err := expiring.Begin(userID, "key-7", "hash"); err != nil {
+			t.Fatal(err)
+		}
+		if record, err := expiring.Begin(userID, "key-7", "hash"); err != nil || record != nil {
+			t.Errorf("expected a lapsed claim to be taken over, got %+v, %v", record, err)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		if _, err := service.Begin(userID, "key-2", "hash"); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Release(userID, "key-2"); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := service.Begin(userID, "key-2", "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record != nil {
+			t.Errorf("expected a released key to be free, got %+v", record)
+		}
+	})
+
+	t.Run("Complete Unknown Key", func(t *testing.T) {
+		if err := service.Complete(userID, "missing", 200, "", nil); err != idempotency.ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Expired Keys Are Reclaimed", func(t *testing.T) {
+		if _, err := expiring.Begin(userID, "key-3", "hash-1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := expiring.Complete(userID, "key-3", 200, "", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := expiring.Begin(userID, "key-3", "hash-2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record != nil {
+			t.Errorf("expected an expired key to be free, got %+v", record)
+		}
+	})
+
+	t.Run("Purge Expired", func(t *testing.T) {
+		if _, err := service.Begin(userID, "key-4", "hash"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := expiring.Begin(userID, "key-5", "hash"); err != nil {
+			t.Fatal(err)
+		}
+
+		purged, err := expiring.PurgeExpired(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if purged < 1 {
+			t.Errorf("expected the expired record to be purged, got %d", purged)
+		}
+
+		if _, err := service.PurgeExpired(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		record, err := service.Begin(userID, "key-4", "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil {
+			t.Error("expected records in their window to be kept")
+		}
+	})
+}
@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/lib/pq"
+	"github.com/tylerolson/capstone-backend/db"
 )
 
 type service struct {
@@ -23,19 +26,40 @@ func (s *service) SetPointsConfig(config PointsConfig) {
 	s.config = config
 }
 
-func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, exerciseID string, isCorrect bool) (*PointTransaction, error) {
+func (s *service) GetPointsConfig() PointsConfig {
+	return s.config
+}
+
+func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, exerciseID string, attemptNumber int, isCorrect bool) (*PointTransaction, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback()
+
+	transaction, err := AwardAnswer(tx, s.config, userID, courseID, lessonID, exerciseID, attemptNumber, isCorrect)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return transaction, nil
+}
+
+// AwardAnswer updates the lesson streak after an answer and scores correct answers, recording the transaction.
+// It can run inside a caller's transaction.
+func AwardAnswer(q db.Querier, config PointsConfig, userID int, courseID, lessonID, exerciseID string, attemptNumber int, isCorrect bool) (*PointTransaction, error) {
+	// Answers of one user are scored one at a time, otherwise two correct answers to the same exercise
+	// could both read a history without either and both score as the first
+	if err := lockUser(q, userID); err != nil {
+		return nil, err
+	}
 
 	if !isCorrect {
-		_, err = tx.Exec(`
+		_, err := q.Exec(`
 			UPDATE user_lesson_progress
 			SET current_streak = 0
 			WHERE user_id = $1 AND course_id = $2 AND lesson_id = $3`,
@@ -44,15 +68,11 @@ func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, ex
 			return nil, fmt.Errorf("failed to reset streak: %v", err)
 		}
 
-		if err = tx.Commit(); err != nil {
-			return nil, fmt.Errorf("failed to commit transaction: %v", err)
-		}
-
 		return nil, nil
 	}
 
 	var currentStreak int
-	err = tx.QueryRow(`
+	err := q.QueryRow(`
 		SELECT current_streak
 		FROM user_lesson_progress
 		WHERE user_id = $1 AND course_id = $2 AND lesson_id = $3`,
@@ -65,25 +85,27 @@ func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, ex
 		}
 	}
 
-	newStreak := currentStreak + 1
-
-	streakBonus := 0
-	if newStreak > 1 {
-		streakBonus = newStreak * s.config.StreakBonusMultiplier
-		if streakBonus > s.config.MaxStreakBonus {
-			streakBonus = s.config.MaxStreakBonus
-		}
+	history := answerHistory{attemptNumber: attemptNumber}
+	err = q.QueryRow(`
+		SELECT
+			COALESCE(SUM(points), 0),
+			COALESCE(BOOL_OR(exercise_id = $4), false),
+			EXISTS(
+				SELECT 1 FROM user_revealed_answers
+				WHERE user_id = $1 AND course_id = $2 AND lesson_id = $3 AND exercise_id = $4
+			)
+		FROM user_point_transactions
+		WHERE user_id = $1 AND course_id = $2 AND lesson_id = $3 AND transaction_type = $5`,
+		userID, courseID, lessonID, exerciseID, TransactionTypeCorrectAnswer).
+		Scan(&history.lessonPoints, &history.answeredCorrect, &history.revealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer history: %v", err)
 	}
 
-	basePoints := s.config.CorrectAnswerPoints
-	totalPoints := basePoints + streakBonus
-
-	description := fmt.Sprintf("Correct answer (+%d points)", basePoints)
-	if streakBonus > 0 {
-		description += fmt.Sprintf(" with streak bonus of %d consecutive correct answers (+%d points)", newStreak, streakBonus)
-	}
+	newStreak := currentStreak + 1
+	totalPoints, policy, description := scoreCorrectAnswer(config, newStreak, history)
 
-	_, err = tx.Exec(`
+	_, err = q.Exec(`
 		UPDATE user_lesson_progress
 		SET current_streak = $1, 
 			max_streak = GREATEST(max_streak, $1),
@@ -95,7 +117,16 @@ func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, ex
 		return nil, fmt.Errorf("failed to update streak: %v", err)
 	}
 
-	_, err = tx.Exec(`
+	_, err = q.Exec(`
+		UPDATE user_course_progress
+		SET total_course_points = total_course_points + $1
+		WHERE user_id = $2 AND course_id = $3`,
+		totalPoints, userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update course points: %v", err)
+	}
+
+	_, err = q.Exec(`
 		UPDATE user_exercise_attempts
 		SET streak_at_attempt = $1, 
 			points_earned = $2
@@ -109,7 +140,7 @@ func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, ex
 		return nil, fmt.Errorf("failed to update exercise attempt: %v", err)
 	}
 
-	_, err = tx.Exec(`
+	_, err = q.Exec(`
 		UPDATE users
 		SET total_points = total_points + $1
 		WHERE id = $2`,
@@ -120,21 +151,17 @@ func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, ex
 
 	var transactionID int
 	var createdAt time.Time
-	err = tx.QueryRow(`
+	err = q.QueryRow(`
 		INSERT INTO user_point_transactions 
-		(user_id, course_id, lesson_id, exercise_id, transaction_type, points, description)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		(user_id, course_id, lesson_id, exercise_id, transaction_type, points, description, scoring_policy)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at`,
-		userID, courseID, lessonID, exerciseID, TransactionTypeCorrectAnswer, totalPoints, description).
+		userID, courseID, lessonID, exerciseID, TransactionTypeCorrectAnswer, totalPoints, description, policy).
 		Scan(&transactionID, &createdAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert point transaction: %v", err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %v", err)
-	}
-
 	return &PointTransaction{
 		ID:              transactionID,
 		UserID:          userID,
@@ -145,9 +172,23 @@ func (s *service) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, ex
 		Points:          totalPoints,
 		Description:     description,
 		CreatedAt:       createdAt,
+		ScoringPolicy:   policy,
 	}, nil
 }
 
+func (s *service) MarkAnswerRevealed(userID int, courseID, lessonID, exerciseID string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO user_revealed_answers (user_id, course_id, lesson_id, exercise_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		userID, courseID, lessonID, exerciseID)
+	if err != nil {
+		return fmt.Errorf("failed to record revealed answer: %v", err)
+	}
+
+	return nil
+}
+
 func (s *service) AwardLessonCompletionBonus(userID int, courseID, lessonID string) (*PointTransaction, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -182,6 +223,7 @@ func (s *service) AwardLessonCompletionBonus(userID int, courseID, lessonID stri
 			return nil, fmt.Errorf("failed to check for existing bonus: %v", err)
 		}
 		if exists {
+			tx.Rollback()
 			return nil, nil
 		}
 	}
@@ -209,6 +251,15 @@ func (s *service) AwardLessonCompletionBonus(userID int, courseID, lessonID stri
 		return nil, fmt.Errorf("failed to update lesson points: %v", err)
 	}
 
+	_, err = tx.Exec(`
+		UPDATE user_course_progress
+		SET total_course_points = total_course_points + $1
+		WHERE user_id = $2 AND course_id = $3`,
+		bonusPoints, userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update course points: %v", err)
+	}
+
 	var transactionID int
 	var createdAt time.Time
 	err = tx.QueryRow(`
@@ -272,6 +323,7 @@ func (s *service) AwardCourseCompletionBonus(userID int, courseID string) (*Poin
 			return nil, fmt.Errorf("failed to check for existing bonus: %v", err)
 		}
 		if exists {
+			tx.Rollback()
 			return nil, nil
 		}
 	}
@@ -303,8 +355,8 @@ func (s *service) AwardCourseCompletionBonus(userID int, courseID string) (*Poin
 	var createdAt time.Time
 	err = tx.QueryRow(`
 		INSERT INTO user_point_transactions 
-		(user_id, course_id, transaction_type, points, description)
-		VALUES ($1, $2, $3, $4, $5)
+		(user_id, course_id, lesson_id, transaction_type, points, description)
+		VALUES ($1, $2, '', $3, $4, $5)
 		RETURNING id, created_at`,
 		userID, courseID, TransactionTypeCourseCompleted, bonusPoints, description).
 		Scan(&transactionID, &createdAt)
@@ -364,13 +416,18 @@ func (s *service) GetUserTotalPoints(userID int) (*UserPoints, error) {
 }
 
 func (s *service) GetLessonPoints(userID int, courseID, lessonID string) (*LessonPoints, error) {
+	return QueryLessonPoints(s.db, userID, courseID, lessonID)
+}
+
+// QueryLessonPoints reads the points and streak of a lesson. It can run inside a caller's transaction.
+func QueryLessonPoints(q db.Querier, userID int, courseID, lessonID string) (*LessonPoints, error) {
 	lessonPoints := &LessonPoints{
 		UserID:   userID,
 		CourseID: courseID,
 		LessonID: lessonID,
 	}
 
-	err := s.db.QueryRow(`
+	err := q.QueryRow(`
 		SELECT 
 			total_lesson_points, 
 			current_streak, 
@@ -413,7 +470,8 @@ func (s *service) GetRecentTransactions(userID int, limit int) ([]*PointTransact
 			transaction_type, 
 			points, 
 			description, 
-			created_at
+			created_at,
+			COALESCE(scoring_policy, '')
 		FROM user_point_transactions
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -439,6 +497,7 @@ func (s *service) GetRecentTransactions(userID int, limit int) ([]*PointTransact
 			&transaction.Points,
 			&transaction.Description,
 			&transaction.CreatedAt,
+			&transaction.ScoringPolicy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction row: %v", err)
@@ -458,192 +517,434 @@ func (s *service) GetRecentTransactions(userID int, limit int) ([]*PointTransact
 	return transactions, nil
 }
 
-// UpdateDailyStreak updates a user's daily login streak and awards points if applicable
-func (s *service) UpdateDailyStreak(userID int) (*PointTransaction, error) {
+// UpdateDailyStreak counts today toward a user's daily streak and awards points if applicable
+func (s *service) UpdateDailyStreak(userID int, loc *time.Location) (*PointTransaction, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback()
 
-	var lastLoginDate sql.NullTime
-	var currentStreak, maxStreak int
+	_, transaction, err := countStreakDay(tx, s.config, userID, time.Now(), loc)
+	if err != nil {
+		return nil, err
+	}
 
-	// Get current streak info
-	err = tx.QueryRow(`
-		SELECT daily_streak, max_daily_streak, last_login_date
-		FROM users
-		WHERE id = $1`,
-		userID).Scan(&currentStreak, &maxStreak, &lastLoginDate)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user daily streak: %v", err)
-	}
-
-	// Get current date in user's timezone (simplified - using UTC)
-	today := time.Now().UTC().Truncate(24 * time.Hour)
-
-	// Check if this is a new day login
-	var newStreak int
-	isMilestoneReached := false
-	milestoneValue := 0
-
-	if !lastLoginDate.Valid || lastLoginDate.Time.Before(today) {
-		// If last login was before today, check if it was yesterday
-		if lastLoginDate.Valid {
-			yesterday := today.AddDate(0, 0, -1)
-			if lastLoginDate.Time.Year() == yesterday.Year() &&
-				lastLoginDate.Time.Month() == yesterday.Month() &&
-				lastLoginDate.Time.Day() == yesterday.Day() {
-				// Consecutive day login
-				newStreak = currentStreak + 1
-			} else {
-				// Streak broken
-				newStreak = 1
-			}
-		} else {
-			// First login
-			newStreak = 1
-		}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
 
-		// Check if a milestone was reached
-		for _, milestone := range s.config.DailyStreakMilestones {
-			if currentStreak < milestone && newStreak >= milestone {
-				isMilestoneReached = true
-				milestoneValue = milestone
-				break
-			}
+	return transaction, nil
+}
+
+// streakStateQuery selects the daily streak columns that scanStreakState reads
+const streakStateQuery = `
+	SELECT daily_streak, max_daily_streak, streak_freezes, last_active_at, last_active_date, lost_daily_streak, daily_streak_lost_at
+	FROM users
+	WHERE id = $1`
+
+// loadStreakState reads a user's daily streak, locking the row so concurrent requests change it one at a time
+func loadStreakState(q db.Querier, userID int) (dailyStreakState, error) {
+	return scanStreakState(q.QueryRow(streakStateQuery+`
+		FOR UPDATE`,
+		userID))
+}
+
+func scanStreakState(row *sql.Row) (dailyStreakState, error) {
+	var state dailyStreakState
+	var lastActiveAt, lastActiveDate, lostAt sql.NullTime
+	err := row.Scan(&state.streak, &state.maxStreak, &state.freezes, &lastActiveAt, &lastActiveDate, &state.lostStreak, &lostAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return state, ErrNoUser
 		}
+		return state, fmt.Errorf("failed to get user daily streak: %v", err)
+	}
+
+	state.lastActive = lastActiveAt.Time
+	if lastActiveDate.Valid {
+		state.lastDate = streakDate(lastActiveDate.Time, lastActiveDate.Time.Location())
+	}
+	state.lostAt = lostAt.Time
 
-		// Update max streak if needed
-		if newStreak > maxStreak {
-			maxStreak = newStreak
+	return state, nil
+}
+
+// saveStreakState writes back a daily streak read with loadStreakState
+func saveStreakState(q db.Querier, userID int, state dailyStreakState) error {
+	var lastActiveAt, lostAt sql.NullTime
+	if !state.lastActive.IsZero() {
+		lastActiveAt = sql.NullTime{Time: state.lastActive, Valid: true}
+	}
+	// Written as text so the session's timezone can't move the date
+	var lastActiveDate sql.NullString
+	if !state.lastDate.IsZero() {
+		lastActiveDate = sql.NullString{String: state.lastDate.Format(time.DateOnly), Valid: true}
+	}
+	if !state.lostAt.IsZero() {
+		lostAt = sql.NullTime{Time: state.lostAt, Valid: true}
+	}
+
+	_, err := q.Exec(`
+		UPDATE users
+		SET daily_streak = $1,
+			max_daily_streak = $2,
+			streak_freezes = $3,
+			last_active_at = $4,
+			last_active_date = $5,
+			lost_daily_streak = $6,
+			daily_streak_lost_at = $7
+		WHERE id = $8`,
+		state.streak, state.maxStreak, state.freezes, lastActiveAt, lastActiveDate, state.lostStreak, lostAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update daily streak: %v", err)
+	}
+
+	return nil
+}
+
+// lockUser locks a user's row until the transaction ends
+func lockUser(q db.Querier, userID int) error {
+	var id int
+	err := q.QueryRow(`
+		SELECT id
+		FROM users
+		WHERE id = $1
+		FOR UPDATE`,
+		userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNoUser
 		}
+		return fmt.Errorf("failed to lock user: %v", err)
+	}
 
-		// Update user streak in database
-		_, err = tx.Exec(`
+	return nil
+}
+
+// recordSystemTransaction adds a transaction that isn't tied to a course to the ledger and the user's total
+func recordSystemTransaction(q db.Querier, userID int, transaction *PointTransaction) error {
+	if transaction.Points != 0 {
+		_, err := q.Exec(`
 			UPDATE users
-			SET daily_streak = $1, 
-				max_daily_streak = $2, 
-				last_login_date = $3
-			WHERE id = $4`,
-			newStreak, maxStreak, today, userID)
+			SET total_points = total_points + $1
+			WHERE id = $2`,
+			transaction.Points, userID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to update daily streak: %v", err)
+			return fmt.Errorf("failed to update user total points: %v", err)
 		}
-	} else {
-		// Already logged in today, no streak update
-		return nil, nil
 	}
 
-	// Award points for streak continuation or milestone
-	var bonusPoints int
-	var description string
+	transaction.UserID = userID
+	transaction.CourseID = "system" // Use "system" to indicate it's not related to a specific course
+	err := q.QueryRow(`
+		INSERT INTO user_point_transactions
+		(user_id, course_id, lesson_id, transaction_type, points, description)
+		VALUES ($1, 'system', '', $2, $3, $4)
+		RETURNING id, created_at`,
+		userID, transaction.TransactionType, transaction.Points, transaction.Description).
+		Scan(&transaction.ID, &transaction.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert point transaction: %v", err)
+	}
 
-	if isMilestoneReached {
-		// Award milestone bonus
-		bonusPoints = milestoneValue * s.config.MilestoneBonusMultiplier
-		description = fmt.Sprintf("%d day streak milestone reached! (+%d points)", milestoneValue, bonusPoints)
-	} else if newStreak > 1 {
-		// Award normal daily streak bonus
-		bonusPoints = s.config.DailyStreakBonusPoints
-		description = fmt.Sprintf("Daily login streak: %d days (+%d points)", newStreak, bonusPoints)
-	} else {
-		// No bonus for first day
-		if err = tx.Commit(); err != nil {
-			return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	return nil
+}
+
+// countStreakDay counts the day of now toward a user's daily streak, using up freezes to cover missed days.
+// It returns what happened to the streak and the streak bonus, if any.
+func countStreakDay(q db.Querier, config PointsConfig, userID int, now time.Time, loc *time.Location) (streakDay, *PointTransaction, error) {
+	state, err := loadStreakState(q, userID)
+	if err != nil {
+		return streakDay{}, nil, err
+	}
+
+	day := state.countDay(config, now, loc)
+	if !day.counted {
+		return day, nil, nil
+	}
+
+	if err := saveStreakState(q, userID, state); err != nil {
+		return streakDay{}, nil, err
+	}
+
+	var bonus *PointTransaction
+	for _, transaction := range streakDayTransactions(config, day, state.streak) {
+		if err := recordSystemTransaction(q, userID, transaction); err != nil {
+			return streakDay{}, nil, err
+		}
+		if transaction.TransactionType == TransactionTypeDailyStreakBonus {
+			bonus = transaction
 		}
-		return nil, nil
 	}
 
-	// Update user's total points
-	_, err = tx.Exec(`
-		UPDATE users
-		SET total_points = total_points + $1
-		WHERE id = $2`,
-		bonusPoints, userID)
+	return day, bonus, nil
+}
+
+// GetDailyStreak retrieves a user's daily streak information
+func (s *service) GetDailyStreak(userID int, loc *time.Location) (*DailyStreakInfo, error) {
+	state, err := scanStreakState(s.db.QueryRow(streakStateQuery, userID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user total points: %v", err)
+		return nil, err
 	}
 
-	// Record point transaction
-	var transactionID int
-	var createdAt time.Time
-	err = tx.QueryRow(`
-		INSERT INTO user_point_transactions
-		(user_id, course_id, transaction_type, points, description)
-		VALUES ($1, 'system', $2, $3, $4)
-		RETURNING id, created_at`,
-		userID, TransactionTypeDailyStreakBonus, bonusPoints, description).
-		Scan(&transactionID, &createdAt)
+	return newDailyStreakInfo(s.config, userID, state, loc), nil
+}
+
+// BuyStreakFreeze spends points on a streak freeze
+func (s *service) BuyStreakFreeze(userID int, cost int) (*PointTransaction, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert point transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	if err = tx.Commit(); err != nil {
+	transaction, _, err := BuyStreakFreeze(tx, s.config, userID, cost)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
-	return &PointTransaction{
-		ID:              transactionID,
-		UserID:          userID,
-		CourseID:        "system", // Use "system" to indicate it's not related to a specific course
-		TransactionType: TransactionTypeDailyStreakBonus,
-		Points:          bonusPoints,
-		Description:     description,
-		CreatedAt:       createdAt,
-	}, nil
+	return transaction, nil
 }
 
-// GetDailyStreak retrieves a user's daily streak information
-func (s *service) GetDailyStreak(userID int) (*DailyStreakInfo, error) {
-	var streak DailyStreakInfo
-	streak.UserID = userID
+// BuyStreakFreeze spends cost points on a streak freeze and returns the purchase and the freezes the
+// user now holds. It can run inside a caller's transaction.
+func BuyStreakFreeze(q db.Querier, config PointsConfig, userID int, cost int) (*PointTransaction, int, error) {
+	state, err := loadStreakState(q, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if state.freezes >= config.MaxStreakFreezes {
+		return nil, 0, ErrFreezeLimit
+	}
 
-	var lastLoginDate sql.NullTime
+	transaction := &PointTransaction{
+		TransactionType: TransactionTypeStreakFreezePurchase,
+		Points:          -cost,
+		Description:     fmt.Sprintf("Bought a streak freeze (-%d points)", cost),
+	}
+	if err := SpendPoints(q, userID, transaction); err != nil {
+		return nil, 0, err
+	}
 
-	err := s.db.QueryRow(`
-		SELECT daily_streak, max_daily_streak, last_login_date
-		FROM users
-		WHERE id = $1`,
-		userID).Scan(&streak.CurrentStreak, &streak.MaxStreak, &lastLoginDate)
+	state.freezes++
+	if err := saveStreakState(q, userID, state); err != nil {
+		return nil, 0, err
+	}
+
+	return transaction, state.freezes, nil
+}
+
+// RepairDailyStreak spends points to restore a recently broken daily streak
+func (s *service) RepairDailyStreak(userID int, loc *time.Location) (*PointTransaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	state, err := loadStreakState(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !state.repair(s.config, time.Now(), loc) {
+		return nil, ErrNothingToRepair
+	}
+
+	transaction := &PointTransaction{
+		TransactionType: TransactionTypeStreakRepair,
+		Points:          -s.config.StreakRepairCost,
+		Description:     fmt.Sprintf("Repaired a %d day streak (-%d points)", state.streak, s.config.StreakRepairCost),
+	}
+	if err := SpendPoints(tx, userID, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := saveStreakState(tx, userID, state); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return transaction, nil
+}
+
+// SpendPoints takes points off a user's total for a shop purchase
+func (s *service) SpendPoints(userID int, cost int, description string) (*PointTransaction, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user daily streak: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	transaction := &PointTransaction{
+		TransactionType: TransactionTypeShopPurchase,
+		Points:          -cost,
+		Description:     description,
+	}
+	if err := SpendPoints(tx, userID, transaction); err != nil {
+		return nil, err
 	}
 
-	if lastLoginDate.Valid {
-		streak.LastLoginDate = lastLoginDate.Time
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
-	// Calculate next milestone
-	streak.NextMilestone = 0
-	for _, milestone := range s.config.DailyStreakMilestones {
-		if streak.CurrentStreak < milestone {
-			streak.NextMilestone = milestone
-			streak.DaysToMilestone = milestone - streak.CurrentStreak
-			break
+	return transaction, nil
+}
+
+// SpendPoints records a purchase, whose transaction holds the negative points, failing with
+// ErrInsufficientPoints when the user can't afford it. It can run inside a caller's transaction.
+func SpendPoints(q db.Querier, userID int, transaction *PointTransaction) error {
+	var totalPoints int
+	err := q.QueryRow(`
+		SELECT total_points
+		FROM users
+		WHERE id = $1
+		FOR UPDATE`,
+		userID).Scan(&totalPoints)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNoUser
 		}
+		return fmt.Errorf("failed to get user total points: %v", err)
+	}
+
+	if totalPoints+transaction.Points < 0 {
+		return ErrInsufficientPoints
 	}
 
-	return &streak, nil
+	return recordSystemTransaction(q, userID, transaction)
 }
 
-// UpdateAccuracyStats updates a user's accuracy statistics
-func (s *service) UpdateAccuracyStats(userID int, isCorrect bool) error {
-	// Start a transaction
+// RecordActivity adds an attempt to the user's activity for today
+func (s *service) RecordActivity(userID int, loc *time.Location, isCorrect bool, points int) (*ActivityResult, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
+	defer tx.Rollback()
+
+	result, err := RecordActivity(tx, s.config, userID, loc, isCorrect, points)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return result, nil
+}
+
+// RecordActivity adds an attempt and the correct answer points it earned to the user's activity for
+// today in loc, and counts the day toward the daily streak once it meets config.DailyStreakRule.
+// It can run inside a caller's transaction.
+func RecordActivity(q db.Querier, config PointsConfig, userID int, loc *time.Location, isCorrect bool, points int) (*ActivityResult, error) {
+	now := time.Now()
+	activity := &DailyActivity{UserID: userID, Date: activityDate(now, loc)}
+
+	correct := 0
+	if isCorrect {
+		correct = 1
+	}
+
+	// The upsert locks the day's row, so concurrent attempts can't both qualify it
+	var qualifiedAt sql.NullTime
+	err := q.QueryRow(`
+		INSERT INTO user_daily_activity (user_id, activity_date, attempts, correct_answers, points)
+		VALUES ($1, $2, 1, $3, $4)
+		ON CONFLICT (user_id, activity_date) DO UPDATE
+		SET attempts = user_daily_activity.attempts + 1,
+			correct_answers = user_daily_activity.correct_answers + EXCLUDED.correct_answers,
+			points = user_daily_activity.points + EXCLUDED.points
+		RETURNING attempts, correct_answers, points, qualified_at, reason`,
+		userID, activity.Date, correct, points).
+		Scan(&activity.Attempts, &activity.CorrectAnswers, &activity.Points, &qualifiedAt, &activity.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record daily activity: %v", err)
+	}
+
+	result := &ActivityResult{Activity: activity}
+	if qualifiedAt.Valid {
+		activity.QualifiedAt = &qualifiedAt.Time
+		return result, nil
+	}
+
+	reason, ok := config.DailyStreakRule.qualifies(activity)
+	if !ok {
+		return result, nil
+	}
+
+	_, err = q.Exec(`
+		UPDATE user_daily_activity
+		SET qualified_at = $1, reason = $2
+		WHERE user_id = $3 AND activity_date = $4`,
+		now, reason, userID, activity.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to qualify daily activity: %v", err)
+	}
+	activity.QualifiedAt = &now
+	activity.Reason = reason
+
+	day, bonus, err := countStreakDay(q, config, userID, now, loc)
+	if err != nil {
+		return nil, err
+	}
+	result.DayCounted, result.FreezesUsed, result.StreakBonus = day.counted, day.freezesUsed, bonus
+
+	return result, nil
+}
+
+// ListDailyActivity returns a user's most recent days of activity
+func (s *service) ListDailyActivity(userID int, limit int) ([]*DailyActivity, error) {
+	rows, err := s.db.Query(`
+		SELECT activity_date, attempts, correct_answers, points, qualified_at, reason
+		FROM user_daily_activity
+		WHERE user_id = $1
+		ORDER BY activity_date DESC
+		LIMIT $2`,
+		userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily activity: %v", err)
+	}
+	defer rows.Close()
+
+	activity := make([]*DailyActivity, 0)
+	for rows.Next() {
+		day := &DailyActivity{UserID: userID}
+		var date time.Time
+		var qualifiedAt sql.NullTime
+		if err := rows.Scan(&date, &day.Attempts, &day.CorrectAnswers, &day.Points, &qualifiedAt, &day.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan daily activity: %v", err)
 		}
-	}()
+		day.Date = date.Format(time.DateOnly)
+		if qualifiedAt.Valid {
+			day.QualifiedAt = &qualifiedAt.Time
+		}
+		activity = append(activity, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily activity rows: %v", err)
+	}
+
+	return activity, nil
+}
 
+// UpdateAccuracyStats updates a user's accuracy statistics
+func (s *service) UpdateAccuracyStats(userID int, isCorrect bool) error {
+	return CountAttempt(s.db, userID, isCorrect)
+}
+
+// CountAttempt adds an attempt to a user's accuracy statistics. It can run inside a caller's transaction.
+func CountAttempt(q db.Querier, userID int, isCorrect bool) error {
 	// Update total attempts and correct attempts
 	var updateSQL string
 	if isCorrect {
@@ -659,25 +960,24 @@ func (s *service) UpdateAccuracyStats(userID int, isCorrect bool) error {
 			WHERE id = $1`
 	}
 
-	_, err = tx.Exec(updateSQL, userID)
-	if err != nil {
+	if _, err := q.Exec(updateSQL, userID); err != nil {
 		return fmt.Errorf("failed to update accuracy stats: %v", err)
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
-	}
-
 	return nil
 }
 
 // GetAccuracyStats retrieves a user's accuracy statistics
 func (s *service) GetAccuracyStats(userID int) (*AccuracyStats, error) {
+	return QueryAccuracyStats(s.db, userID)
+}
+
+// QueryAccuracyStats reads a user's accuracy statistics. It can run inside a caller's transaction.
+func QueryAccuracyStats(q db.Querier, userID int) (*AccuracyStats, error) {
 	var stats AccuracyStats
 	stats.UserID = userID
 
-	err := s.db.QueryRow(`
+	err := q.QueryRow(`
 		SELECT total_attempts, correct_attempts
 		FROM users
 		WHERE id = $1`,
@@ -687,11 +987,323 @@ func (s *service) GetAccuracyStats(userID int) (*AccuracyStats, error) {
 	}
 
 	// Calculate accuracy rate
-	if stats.TotalAttempts > 0 {
-		stats.AccuracyRate = float64(stats.CorrectAttempts) / float64(stats.TotalAttempts) * 100
-	} else {
-		stats.AccuracyRate = 0
-	}
+	stats.AccuracyRate = accuracyRate(stats.TotalAttempts, stats.CorrectAttempts)
 
 	return &stats, nil
 }
+
+// ResetStreaks clears a user's daily streak, along with any broken one waiting for repair, and every
+// lesson streak. Max streaks and freezes are kept.
+func (s *service) ResetStreaks(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users
+		SET daily_streak = 0,
+			lost_daily_streak = 0,
+			daily_streak_lost_at = NULL
+		WHERE id = $1`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to reset daily streak: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNoUser
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_lesson_progress
+		SET current_streak = 0
+		WHERE user_id = $1`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to reset lesson streaks: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// AdjustPoints adds (or with a negative amount removes) points by hand and records why.
+// A user's total can't go below zero.
+func (s *service) AdjustPoints(userID int, points int, reason string) (*PointTransaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var totalPoints int
+	err = tx.QueryRow(`
+		SELECT total_points
+		FROM users
+		WHERE id = $1
+		FOR UPDATE`,
+		userID).Scan(&totalPoints)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoUser
+		}
+		return nil, fmt.Errorf("failed to get user total points: %v", err)
+	}
+
+	if totalPoints+points < 0 {
+		return nil, ErrInsufficientPoints
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET total_points = total_points + $1
+		WHERE id = $2`,
+		points, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user total points: %v", err)
+	}
+
+	transaction := &PointTransaction{
+		UserID:          userID,
+		CourseID:        "system",
+		TransactionType: TransactionTypeAdminAdjustment,
+		Points:          points,
+		Description:     reason,
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO user_point_transactions
+		(user_id, course_id, lesson_id, transaction_type, points, description)
+		VALUES ($1, 'system', '', $2, $3, $4)
+		RETURNING id, created_at`,
+		userID, TransactionTypeAdminAdjustment, points, reason).
+		Scan(&transaction.ID, &transaction.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert point transaction: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return transaction, nil
+}
+
+// reconcileQueries find the totals that disagree with the ledger and repair one of them. The repair
+// only applies while the total still holds the value that was read, so a concurrent award isn't lost.
+var reconcileQueries = []struct {
+	aggregate  string
+	find       string
+	repair     string
+	repairArgs func(d *Drift) []any
+}{
+	{
+		aggregate: AggregateUser,
+		find: `
+			SELECT u.id, '', '', u.total_points, COALESCE(SUM(t.points), 0)
+			FROM users u
+			LEFT JOIN user_point_transactions t ON t.user_id = u.id
+			GROUP BY u.id, u.total_points
+			HAVING u.total_points <> COALESCE(SUM(t.points), 0)
+			ORDER BY u.id`,
+		repair: `
+			UPDATE users
+			SET total_points = $1
+			WHERE id = $2 AND total_points = $3`,
+		repairArgs: func(d *Drift) []any { return []any{d.Ledger, d.UserID, d.Stored} },
+	},
+	{
+		aggregate: AggregateCourse,
+		find: `
+			SELECT c.user_id, c.course_id, '', c.total_course_points, COALESCE(SUM(t.points), 0)
+			FROM user_course_progress c
+			LEFT JOIN user_point_transactions t ON t.user_id = c.user_id AND t.course_id = c.course_id
+			GROUP BY c.user_id, c.course_id, c.total_course_points
+			HAVING c.total_course_points <> COALESCE(SUM(t.points), 0)
+			ORDER BY c.user_id, c.course_id`,
+		repair: `
+			UPDATE user_course_progress
+			SET total_course_points = $1
+			WHERE user_id = $2 AND course_id = $3 AND total_course_points = $4`,
+		repairArgs: func(d *Drift) []any { return []any{d.Ledger, d.UserID, d.CourseID, d.Stored} },
+	},
+	{
+		aggregate: AggregateLesson,
+		find: `
+			SELECT l.user_id, l.course_id, l.lesson_id, l.total_lesson_points, COALESCE(SUM(t.points), 0)
+			FROM user_lesson_progress l
+			LEFT JOIN user_point_transactions t
+				ON t.user_id = l.user_id AND t.course_id = l.course_id AND t.lesson_id = l.lesson_id
+			GROUP BY l.user_id, l.course_id, l.lesson_id, l.total_lesson_points
+			HAVING l.total_lesson_points <> COALESCE(SUM(t.points), 0)
+			ORDER BY l.user_id, l.course_id, l.lesson_id`,
+		repair: `
+			UPDATE user_lesson_progress
+			SET total_lesson_points = $1
+			WHERE user_id = $2 AND course_id = $3 AND lesson_id = $4 AND total_lesson_points = $5`,
+		repairArgs: func(d *Drift) []any { return []any{d.Ledger, d.UserID, d.CourseID, d.LessonID, d.Stored} },
+	},
+}
+
+func (s *service) Reconcile(dryRun bool) (*ReconcileReport, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	report := &ReconcileReport{DryRun: dryRun, Drift: make([]*Drift, 0)}
+	for _, queries := range reconcileQueries {
+		drift, err := findDrift(tx, queries.aggregate, queries.find)
+		if err != nil {
+			return nil, err
+		}
+
+		if !dryRun {
+			for _, d := range drift {
+				result, err := tx.Exec(queries.repair, queries.repairArgs(d)...)
+				if err != nil {
+					return nil, fmt.Errorf("failed to repair %s points: %v", queries.aggregate, err)
+				}
+				rows, err := result.RowsAffected()
+				if err != nil {
+					return nil, err
+				}
+				d.Repaired = rows == 1
+			}
+		}
+
+		report.Drift = append(report.Drift, drift...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return report, nil
+}
+
+func findDrift(q db.Querier, aggregate, query string) ([]*Drift, error) {
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s points: %v", aggregate, err)
+	}
+	defer rows.Close()
+
+	var drift []*Drift
+	for rows.Next() {
+		d := &Drift{Aggregate: aggregate}
+		if err := rows.Scan(&d.UserID, &d.CourseID, &d.LessonID, &d.Stored, &d.Ledger); err != nil {
+			return nil, fmt.Errorf("failed to scan %s points: %v", aggregate, err)
+		}
+		drift = append(drift, d)
+	}
+
+	return drift, rows.Err()
+}
+
+// leaderboardScores ranks the users who earned points since $1 (all time when NULL) in course $2 (every course
+// when empty), out of the users in $3 (everyone when NULL). Points spent, of the types in $5, don't count.
+const leaderboardScores = `
+	WITH scores AS (
+		SELECT user_id, SUM(points) AS points
+		FROM user_point_transactions
+		WHERE ($1::timestamptz IS NULL OR created_at >= $1) AND ($2 = '' OR course_id = $2)
+			AND ($3::int[] IS NULL OR user_id = ANY($3)) AND NOT (transaction_type = ANY($5))
+		GROUP BY user_id
+		HAVING SUM(points) > 0
+	), ranked AS (
+		SELECT user_id, points, RANK() OVER (ORDER BY points DESC) AS rank
+		FROM scores
+	)`
+
+func (s *service) GetLeaderboard(query LeaderboardQuery) (*Leaderboard, error) {
+	leaderboard, err := newLeaderboard(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	var since sql.NullTime
+	if leaderboard.Since != nil {
+		since = sql.NullTime{Time: *leaderboard.Since, Valid: true}
+	}
+
+	rows, err := s.db.Query(leaderboardScores+`
+		SELECT rank, user_id, points
+		FROM ranked
+		ORDER BY rank, user_id
+		LIMIT $4`,
+		since, query.CourseID, pq.Array(query.UserIDs), query.Limit, pq.Array(SpendingTransactionTypes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry LeaderboardEntry
+		if err := rows.Scan(&entry.Rank, &entry.UserID, &entry.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %v", err)
+		}
+		leaderboard.Entries = append(leaderboard.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read leaderboard: %v", err)
+	}
+
+	// The caller's own entry, placed after everyone who earned points when they haven't
+	me := LeaderboardEntry{UserID: query.UserID}
+	err = s.db.QueryRow(leaderboardScores+`
+		SELECT COALESCE(MAX(rank) FILTER (WHERE user_id = $4), COUNT(*) + 1), COALESCE(MAX(points) FILTER (WHERE user_id = $4), 0)
+		FROM ranked`,
+		since, query.CourseID, pq.Array(query.UserIDs), query.UserID, pq.Array(SpendingTransactionTypes)).
+		Scan(&me.Rank, &me.Points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard rank: %v", err)
+	}
+	leaderboard.Me = &me
+
+	return leaderboard, nil
+}
+
+func (s *service) GetActivity(query ActivityQuery) ([]*PointTransaction, error) {
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, user_id, course_id, COALESCE(lesson_id, ''), COALESCE(exercise_id, ''),
+			transaction_type, points, description, created_at, COALESCE(scoring_policy, '')
+		FROM user_point_transactions
+		WHERE NOT (transaction_type = ANY($1))
+			AND ($2::int[] IS NULL OR user_id = ANY($2))
+			AND NOT (user_id = ANY($3::int[]))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4`,
+		pq.Array(append([]string{TransactionTypeAdminAdjustment}, SpendingTransactionTypes...)), pq.Array(query.UserIDs), pq.Array(append([]int{}, query.ExcludeUserIDs...)), query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activity: %v", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]*PointTransaction, 0)
+	for rows.Next() {
+		transaction := &PointTransaction{}
+		err := rows.Scan(&transaction.ID, &transaction.UserID, &transaction.CourseID, &transaction.LessonID, &transaction.ExerciseID,
+			&transaction.TransactionType, &transaction.Points, &transaction.Description, &transaction.CreatedAt, &transaction.ScoringPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan activity: %v", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
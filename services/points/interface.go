@@ -1,6 +1,17 @@
 package points
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNoUser             = errors.New("user does not exist")
+	ErrInsufficientPoints = errors.New("not enough points")
+	ErrFreezeLimit        = errors.New("already holding the most streak freezes allowed")
+	ErrNothingToRepair    = errors.New("no broken streak to repair")
+)
 
 // Define transaction types
 const (
@@ -8,6 +19,30 @@ const (
 	TransactionTypeLessonCompleted  = "lesson_completed"
 	TransactionTypeCourseCompleted  = "course_completed"
 	TransactionTypeDailyStreakBonus = "daily_streak_bonus"
+	TransactionTypeAdminAdjustment  = "admin_adjustment"
+
+	// Streak freezes and repairs. Freezes are earned and used without points changing hands.
+	TransactionTypeStreakFreezeEarned   = "streak_freeze_earned"
+	TransactionTypeStreakFreezePurchase = "streak_freeze_purchase"
+	TransactionTypeStreakFreezeUsed     = "streak_freeze_used"
+	TransactionTypeStreakRepair         = "streak_repair"
+
+	TransactionTypeShopPurchase = "shop_purchase"
+)
+
+// SpendingTransactionTypes are points users spent rather than earned. They come off the total but
+// don't lower leaderboard scores or show up as activity.
+var SpendingTransactionTypes = []string{TransactionTypeStreakFreezePurchase, TransactionTypeStreakRepair, TransactionTypeShopPurchase}
+
+// ScoringPolicy names the rule that decided how many points a correct answer was worth
+type ScoringPolicy string
+
+const (
+	PolicyStandard       ScoringPolicy = "standard"        // full points and streak bonus
+	PolicyFirstCorrect   ScoringPolicy = "first_correct"   // the exercise was already answered correctly
+	PolicyDiminishing    ScoringPolicy = "diminishing"     // points divided by the attempt number
+	PolicyAnswerRevealed ScoringPolicy = "answer_revealed" // the answer was revealed before it was given
+	PolicyLessonCap      ScoringPolicy = "lesson_cap"      // the lesson's correct answer points are capped
 )
 
 // PointsConfig defines the point values for different actions
@@ -20,6 +55,21 @@ type PointsConfig struct {
 	DailyStreakBonusPoints   int
 	DailyStreakMilestones    []int // Milestones for extra bonuses (e.g., [7, 30, 365])
 	MilestoneBonusMultiplier int   // Multiplier for milestone bonuses
+	DailyStreakRule          StreakRule
+
+	// Streak freezes cover missed days automatically. They are earned at milestones or bought,
+	// and a broken streak can be bought back for a while after it lapses.
+	MilestoneFreezes   int // Freezes earned at each daily streak milestone
+	MaxStreakFreezes   int // Most freezes a user can hold
+	StreakFreezeCost   int
+	StreakRepairCost   int
+	StreakRepairWindow time.Duration
+
+	// Scoring policies that stop points being farmed by replaying exercises
+	FirstCorrectOnly    bool // Only the first correct answer to an exercise earns points
+	DiminishingReturns  bool // Divide the points by the attempt number
+	NoPointsAfterReveal bool // Answers given after revealing the answer earn nothing
+	LessonPointCap      int  // Most correct answer points per lesson, 0 for no cap
 }
 
 // Default point configuration
@@ -32,6 +82,23 @@ var DefaultPointsConfig = PointsConfig{
 	DailyStreakBonusPoints:   20,
 	DailyStreakMilestones:    []int{7, 30, 100, 365},
 	MilestoneBonusMultiplier: 5,
+	DailyStreakRule:          StreakRule{MinCorrectAnswers: 1},
+	MilestoneFreezes:         1,
+	MaxStreakFreezes:         2,
+	StreakFreezeCost:         100,
+	StreakRepairCost:         200,
+	StreakRepairWindow:       48 * time.Hour,
+	FirstCorrectOnly:         true,
+	DiminishingReturns:       true,
+	NoPointsAfterReveal:      true,
+	LessonPointCap:           500,
+}
+
+// StreakRule decides which days count toward the daily streak. A day counts once every minimum is
+// met; with no minimums any attempt counts.
+type StreakRule struct {
+	MinCorrectAnswers int // Correct answers needed that day
+	MinPoints         int // Correct answer points needed that day
 }
 
 // PointTransaction represents a points transaction record
@@ -45,6 +112,8 @@ type PointTransaction struct {
 	Points          int       `json:"points"`
 	Description     string    `json:"description"`
 	CreatedAt       time.Time `json:"createdAt"`
+	// ScoringPolicy is set on correct answer transactions
+	ScoringPolicy ScoringPolicy `json:"scoringPolicy,omitempty"`
 }
 
 // UserPoints represents a user's total points and streaks
@@ -67,14 +136,42 @@ type LessonPoints struct {
 	LastAttemptAt time.Time `json:"lastAttemptAt"`
 }
 
-// DailyStreakInfo represents a user's daily streak information
+// DailyStreakInfo represents a user's daily streak information, with days counted in Timezone.
+// CurrentStreak is 0 once the user has missed more days than their freezes cover.
 type DailyStreakInfo struct {
 	UserID          int       `json:"userId"`
 	CurrentStreak   int       `json:"currentStreak"`
 	MaxStreak       int       `json:"maxStreak"`
-	LastLoginDate   time.Time `json:"lastLoginDate"`
+	Timezone        string    `json:"timezone"`
+	LastActiveAt    time.Time `json:"lastActiveAt"`
+	LastActiveDate  time.Time `json:"lastActiveDate"` // Midnight of the last day that counted, in Timezone
 	NextMilestone   int       `json:"nextMilestone,omitempty"`
 	DaysToMilestone int       `json:"daysToMilestone,omitempty"`
+	Freezes         int       `json:"freezes"`
+	// A broken streak that RepairDailyStreak can still restore, for RepairCost points until RepairDeadline
+	RepairableStreak int        `json:"repairableStreak,omitempty"`
+	RepairDeadline   *time.Time `json:"repairDeadline,omitempty"`
+	RepairCost       int        `json:"repairCost,omitempty"`
+}
+
+// DailyActivity is what a user did on one day in their timezone. QualifiedAt and Reason are set
+// once the day counted toward the daily streak.
+type DailyActivity struct {
+	UserID         int        `json:"userId"`
+	Date           string     `json:"date"` // YYYY-MM-DD
+	Attempts       int        `json:"attempts"`
+	CorrectAnswers int        `json:"correctAnswers"`
+	Points         int        `json:"points"`
+	QualifiedAt    *time.Time `json:"qualifiedAt,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+}
+
+// ActivityResult is what recording an attempt did to the user's day and daily streak
+type ActivityResult struct {
+	Activity    *DailyActivity
+	DayCounted  bool              // This attempt made the day count toward the streak
+	FreezesUsed int               // Streak freezes used up to cover days missed before this one
+	StreakBonus *PointTransaction // Set when counting the day earned a daily streak bonus
 }
 
 // AccuracyStats represents a user's accuracy statistics
@@ -85,11 +182,99 @@ type AccuracyStats struct {
 	AccuracyRate    float64 `json:"accuracyRate"` // Percentage (0-100)
 }
 
+// Aggregates that are kept alongside the transaction ledger
+const (
+	AggregateUser   = "user"
+	AggregateCourse = "course"
+	AggregateLesson = "lesson"
+)
+
+// Drift is a stored points total that disagrees with the sum of its transactions
+type Drift struct {
+	Aggregate string `json:"aggregate"`
+	UserID    int    `json:"userId"`
+	CourseID  string `json:"courseId,omitempty"`
+	LessonID  string `json:"lessonId,omitempty"`
+	Stored    int    `json:"stored"`
+	Ledger    int    `json:"ledger"`
+	// Repaired is false on a dry run, or when the total changed while reconciling
+	Repaired bool `json:"repaired"`
+}
+
+func (d Drift) String() string {
+	name := fmt.Sprintf("%s user=%d", d.Aggregate, d.UserID)
+	if d.CourseID != "" {
+		name += " course=" + d.CourseID
+	}
+	if d.LessonID != "" {
+		name += " lesson=" + d.LessonID
+	}
+
+	return fmt.Sprintf("%s: stored %d, ledger %d (%+d)", name, d.Stored, d.Ledger, d.Ledger-d.Stored)
+}
+
+// ReconcileReport lists every total that drifted from the ledger
+type ReconcileReport struct {
+	DryRun bool     `json:"dryRun"`
+	Drift  []*Drift `json:"drift"`
+}
+
+// LeaderboardPeriod is the window of transactions a leaderboard is ranked on
+type LeaderboardPeriod string
+
+const (
+	PeriodDaily   LeaderboardPeriod = "daily"
+	PeriodWeekly  LeaderboardPeriod = "weekly"
+	PeriodMonthly LeaderboardPeriod = "monthly"
+	PeriodAllTime LeaderboardPeriod = "all_time"
+)
+
+var ErrInvalidPeriod = errors.New("invalid leaderboard period")
+
+// LeaderboardQuery selects a leaderboard. An empty CourseID ranks points from every course.
+type LeaderboardQuery struct {
+	Period   LeaderboardPeriod
+	CourseID string
+	Limit    int
+	// UserIDs limits the ranking to these users, such as the caller and their friends. Nil ranks everyone.
+	UserIDs []int
+	// UserID is the caller, whose own entry is returned even when it falls outside the top Limit
+	UserID int
+}
+
+// LeaderboardEntry is one user's place on a leaderboard. Users with the same points share a rank.
+type LeaderboardEntry struct {
+	Rank   int `json:"rank"`
+	UserID int `json:"userId"`
+	Points int `json:"points"`
+}
+
+type Leaderboard struct {
+	Period   LeaderboardPeriod  `json:"period"`
+	CourseID string             `json:"courseId,omitempty"`
+	Since    *time.Time         `json:"since,omitempty"`
+	Entries  []LeaderboardEntry `json:"entries"`
+	Me       *LeaderboardEntry  `json:"me,omitempty"`
+}
+
+// ActivityQuery selects the most recent point transactions of UserIDs, or of everyone but
+// ExcludeUserIDs when UserIDs is nil
+type ActivityQuery struct {
+	UserIDs        []int
+	ExcludeUserIDs []int
+	Limit          int
+}
+
 // Service defines the points service interface
 type Service interface {
 	// Existing methods
 	SetPointsConfig(config PointsConfig)
-	AwardPointsForCorrectAnswer(userID int, courseID, lessonID, exerciseID string, isCorrect bool) (*PointTransaction, error)
+	GetPointsConfig() PointsConfig
+	// AwardPointsForCorrectAnswer scores the attemptNumber-th answer to an exercise. Correct answers are always
+	// recorded as a transaction, with no points when a scoring policy rules them out.
+	AwardPointsForCorrectAnswer(userID int, courseID, lessonID, exerciseID string, attemptNumber int, isCorrect bool) (*PointTransaction, error)
+	// MarkAnswerRevealed remembers that the user was shown the answer to an exercise
+	MarkAnswerRevealed(userID int, courseID, lessonID, exerciseID string) error
 	AwardLessonCompletionBonus(userID int, courseID, lessonID string) (*PointTransaction, error)
 	AwardCourseCompletionBonus(userID int, courseID string) (*PointTransaction, error)
 	ResetLessonStreak(userID int, courseID, lessonID string) error
@@ -97,11 +282,40 @@ type Service interface {
 	GetLessonPoints(userID int, courseID, lessonID string) (*LessonPoints, error)
 	GetRecentTransactions(userID int, limit int) ([]*PointTransaction, error)
 
-	// New methods for daily streak
-	UpdateDailyStreak(userID int) (*PointTransaction, error)
-	GetDailyStreak(userID int) (*DailyStreakInfo, error)
+	// New methods for daily streak. Days start at midnight in loc, the user's own timezone.
+	// UpdateDailyStreak counts today toward the streak; RecordActivity calls it once a day qualifies.
+	UpdateDailyStreak(userID int, loc *time.Location) (*PointTransaction, error)
+	GetDailyStreak(userID int, loc *time.Location) (*DailyStreakInfo, error)
+	// RecordActivity adds an attempt and the correct answer points it earned to today's activity,
+	// counting the day toward the streak when it first meets the config's DailyStreakRule
+	RecordActivity(userID int, loc *time.Location, isCorrect bool, points int) (*ActivityResult, error)
+	// ListDailyActivity returns the user's most recent days of activity, newest first
+	ListDailyActivity(userID int, limit int) ([]*DailyActivity, error)
+
+	// Spending points. Every purchase is a negative transaction in the ledger.
+	// SpendPoints takes cost points off a user's total for a shop purchase, failing with
+	// ErrInsufficientPoints when they don't have enough
+	SpendPoints(userID int, cost int, description string) (*PointTransaction, error)
+	// BuyStreakFreeze spends cost points on a freeze, up to MaxStreakFreezes
+	BuyStreakFreeze(userID int, cost int) (*PointTransaction, error)
+	// RepairDailyStreak spends StreakRepairCost points to restore a streak that broke less than
+	// StreakRepairWindow ago
+	RepairDailyStreak(userID int, loc *time.Location) (*PointTransaction, error)
 
 	// New methods for accuracy tracking
 	UpdateAccuracyStats(userID int, isCorrect bool) error
 	GetAccuracyStats(userID int) (*AccuracyStats, error)
+
+	// Admin tools
+	ResetStreaks(userID int) error
+	AdjustPoints(userID int, points int, reason string) (*PointTransaction, error)
+	// Reconcile recomputes the user, course and lesson point totals from the transaction ledger.
+	// Drifted totals are overwritten with the ledger's unless dryRun is set.
+	Reconcile(dryRun bool) (*ReconcileReport, error)
+
+	// GetLeaderboard ranks users by the points they earned in a period. Only users who earned
+	// points are ranked; a caller without any is placed after everyone else.
+	GetLeaderboard(query LeaderboardQuery) (*Leaderboard, error)
+	// GetActivity returns the newest transactions first, leaving out admin adjustments
+	GetActivity(query ActivityQuery) ([]*PointTransaction, error)
 }
@@ -0,0 +1,636 @@
+package points
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryUser struct {
+	totalPoints     int
+	dailyStreak     dailyStreakState
+	totalAttempts   int
+	correctAttempts int
+	updatedAt       time.Time
+}
+
+type lessonKey struct {
+	userID   int
+	courseID string
+	lessonID string
+}
+
+type memoryLesson struct {
+	totalPoints   int
+	currentStreak int
+	maxStreak     int
+	lastAttemptAt time.Time
+}
+
+type exerciseKey struct {
+	lessonKey
+	exerciseID string
+}
+
+type activityKey struct {
+	userID int
+	date   string
+}
+
+type memoryService struct {
+	mu           sync.RWMutex
+	config       PointsConfig
+	nextID       int
+	users        map[int]*memoryUser
+	lessons      map[lessonKey]*memoryLesson
+	revealed     map[exerciseKey]bool
+	activity     map[activityKey]*DailyActivity
+	transactions []*PointTransaction
+}
+
+// NewMemoryService creates a points service that keeps everything in memory, for tests and local development
+func NewMemoryService() Service {
+	return &memoryService{
+		config:   DefaultPointsConfig,
+		nextID:   1,
+		users:    make(map[int]*memoryUser),
+		lessons:  make(map[lessonKey]*memoryLesson),
+		revealed: make(map[exerciseKey]bool),
+		activity: make(map[activityKey]*DailyActivity),
+	}
+}
+
+func (s *memoryService) SetPointsConfig(config PointsConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config = config
+}
+
+func (s *memoryService) GetPointsConfig() PointsConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.config
+}
+
+func (s *memoryService) AwardPointsForCorrectAnswer(userID int, courseID, lessonID, exerciseID string, attemptNumber int, isCorrect bool) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lesson := s.lesson(userID, courseID, lessonID)
+
+	if !isCorrect {
+		lesson.currentStreak = 0
+		return nil, nil
+	}
+
+	key := lessonKey{userID: userID, courseID: courseID, lessonID: lessonID}
+	history := answerHistory{
+		attemptNumber: attemptNumber,
+		revealed:      s.revealed[exerciseKey{key, exerciseID}],
+	}
+	for _, transaction := range s.transactions {
+		if transaction.UserID == userID && transaction.CourseID == courseID && transaction.LessonID == lessonID &&
+			transaction.TransactionType == TransactionTypeCorrectAnswer {
+			history.lessonPoints += transaction.Points
+			history.answeredCorrect = history.answeredCorrect || transaction.ExerciseID == exerciseID
+		}
+	}
+
+	newStreak := lesson.currentStreak + 1
+	totalPoints, policy, description := scoreCorrectAnswer(s.config, newStreak, history)
+
+	lesson.currentStreak = newStreak
+	lesson.maxStreak = max(lesson.maxStreak, newStreak)
+	lesson.totalPoints += totalPoints
+	lesson.lastAttemptAt = time.Now()
+
+	s.user(userID).totalPoints += totalPoints
+
+	return s.record(&PointTransaction{
+		UserID:          userID,
+		CourseID:        courseID,
+		LessonID:        lessonID,
+		ExerciseID:      exerciseID,
+		TransactionType: TransactionTypeCorrectAnswer,
+		Points:          totalPoints,
+		Description:     description,
+		ScoringPolicy:   policy,
+	}), nil
+}
+
+func (s *memoryService) MarkAnswerRevealed(userID int, courseID, lessonID, exerciseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revealed[exerciseKey{lessonKey{userID: userID, courseID: courseID, lessonID: lessonID}, exerciseID}] = true
+	return nil
+}
+
+func (s *memoryService) AwardLessonCompletionBonus(userID int, courseID, lessonID string) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hasTransaction(userID, courseID, lessonID, TransactionTypeLessonCompleted) {
+		return nil, nil
+	}
+
+	bonusPoints := s.config.LessonCompletionBonus
+	description := fmt.Sprintf("Lesson completion bonus (+%d points)", bonusPoints)
+
+	s.lesson(userID, courseID, lessonID).totalPoints += bonusPoints
+	s.user(userID).totalPoints += bonusPoints
+
+	return s.recordTransaction(userID, courseID, lessonID, "", TransactionTypeLessonCompleted, bonusPoints, description), nil
+}
+
+func (s *memoryService) AwardCourseCompletionBonus(userID int, courseID string) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hasTransaction(userID, courseID, "", TransactionTypeCourseCompleted) {
+		return nil, nil
+	}
+
+	bonusPoints := s.config.CourseCompletionBonus
+	description := fmt.Sprintf("Course completion bonus (+%d points)", bonusPoints)
+
+	s.user(userID).totalPoints += bonusPoints
+
+	return s.recordTransaction(userID, courseID, "", "", TransactionTypeCourseCompleted, bonusPoints, description), nil
+}
+
+func (s *memoryService) ResetLessonStreak(userID int, courseID, lessonID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lesson, ok := s.lessons[lessonKey{userID: userID, courseID: courseID, lessonID: lessonID}]; ok {
+		lesson.currentStreak = 0
+	}
+
+	return nil
+}
+
+func (s *memoryService) GetUserTotalPoints(userID int) (*UserPoints, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userID)
+	userPoints := &UserPoints{
+		UserID:      userID,
+		TotalPoints: user.totalPoints,
+		UpdatedAt:   user.updatedAt,
+	}
+
+	for key, lesson := range s.lessons {
+		if key.userID == userID {
+			userPoints.CurrentStreak = max(userPoints.CurrentStreak, lesson.currentStreak)
+			userPoints.MaxStreak = max(userPoints.MaxStreak, lesson.maxStreak)
+		}
+	}
+
+	return userPoints, nil
+}
+
+func (s *memoryService) GetLessonPoints(userID int, courseID, lessonID string) (*LessonPoints, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lessonPoints := &LessonPoints{
+		UserID:        userID,
+		CourseID:      courseID,
+		LessonID:      lessonID,
+		LastAttemptAt: time.Now(),
+	}
+
+	if lesson, ok := s.lessons[lessonKey{userID: userID, courseID: courseID, lessonID: lessonID}]; ok {
+		lessonPoints.TotalPoints = lesson.totalPoints
+		lessonPoints.CurrentStreak = lesson.currentStreak
+		lessonPoints.MaxStreak = lesson.maxStreak
+		lessonPoints.LastAttemptAt = lesson.lastAttemptAt
+	}
+
+	return lessonPoints, nil
+}
+
+func (s *memoryService) GetRecentTransactions(userID int, limit int) ([]*PointTransaction, error) {
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var transactions []*PointTransaction
+	for i := len(s.transactions) - 1; i >= 0 && len(transactions) < limit; i-- {
+		if s.transactions[i].UserID == userID {
+			transaction := *s.transactions[i]
+			transactions = append(transactions, &transaction)
+		}
+	}
+
+	return transactions, nil
+}
+
+func (s *memoryService) UpdateDailyStreak(userID int, loc *time.Location) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, transaction := s.countStreakDay(userID, time.Now(), loc)
+	return transaction, nil
+}
+
+// countStreakDay counts the day of now toward the user's daily streak, returning what happened to the
+// streak and the bonus if any. Callers must hold the write lock.
+func (s *memoryService) countStreakDay(userID int, now time.Time, loc *time.Location) (streakDay, *PointTransaction) {
+	user := s.user(userID)
+
+	day := user.dailyStreak.countDay(s.config, now, loc)
+	if !day.counted {
+		return day, nil
+	}
+
+	var bonus *PointTransaction
+	for _, transaction := range streakDayTransactions(s.config, day, user.dailyStreak.streak) {
+		user.totalPoints += transaction.Points
+		transaction.UserID = userID
+		transaction.CourseID = "system"
+		recorded := s.record(transaction)
+		if recorded.TransactionType == TransactionTypeDailyStreakBonus {
+			bonus = recorded
+		}
+	}
+
+	return day, bonus
+}
+
+func (s *memoryService) GetDailyStreak(userID int, loc *time.Location) (*DailyStreakInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return newDailyStreakInfo(s.config, userID, s.user(userID).dailyStreak, loc), nil
+}
+
+func (s *memoryService) SpendPoints(userID int, cost int, description string) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userID)
+	if user.totalPoints < cost {
+		return nil, ErrInsufficientPoints
+	}
+
+	user.totalPoints -= cost
+
+	return s.recordTransaction(userID, "system", "", "", TransactionTypeShopPurchase, -cost, description), nil
+}
+
+func (s *memoryService) BuyStreakFreeze(userID int, cost int) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userID)
+	if user.dailyStreak.freezes >= s.config.MaxStreakFreezes {
+		return nil, ErrFreezeLimit
+	}
+	if user.totalPoints < cost {
+		return nil, ErrInsufficientPoints
+	}
+
+	user.dailyStreak.freezes++
+	user.totalPoints -= cost
+
+	description := fmt.Sprintf("Bought a streak freeze (-%d points)", cost)
+	return s.recordTransaction(userID, "system", "", "", TransactionTypeStreakFreezePurchase, -cost, description), nil
+}
+
+func (s *memoryService) RepairDailyStreak(userID int, loc *time.Location) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userID)
+	state := user.dailyStreak
+	if !state.repair(s.config, time.Now(), loc) {
+		return nil, ErrNothingToRepair
+	}
+	if user.totalPoints < s.config.StreakRepairCost {
+		return nil, ErrInsufficientPoints
+	}
+
+	user.dailyStreak = state
+	user.totalPoints -= s.config.StreakRepairCost
+
+	description := fmt.Sprintf("Repaired a %d day streak (-%d points)", state.streak, s.config.StreakRepairCost)
+	return s.recordTransaction(userID, "system", "", "", TransactionTypeStreakRepair, -s.config.StreakRepairCost, description), nil
+}
+
+func (s *memoryService) RecordActivity(userID int, loc *time.Location, isCorrect bool, points int) (*ActivityResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	key := activityKey{userID: userID, date: activityDate(now, loc)}
+	activity, ok := s.activity[key]
+	if !ok {
+		activity = &DailyActivity{UserID: userID, Date: key.date}
+		s.activity[key] = activity
+	}
+
+	activity.Attempts++
+	if isCorrect {
+		activity.CorrectAnswers++
+	}
+	activity.Points += points
+
+	result := &ActivityResult{}
+	if activity.QualifiedAt == nil {
+		if reason, ok := s.config.DailyStreakRule.qualifies(activity); ok {
+			activity.QualifiedAt = &now
+			activity.Reason = reason
+			day, bonus := s.countStreakDay(userID, now, loc)
+			result.DayCounted, result.FreezesUsed, result.StreakBonus = day.counted, day.freezesUsed, bonus
+		}
+	}
+
+	day := *activity
+	result.Activity = &day
+	return result, nil
+}
+
+func (s *memoryService) ListDailyActivity(userID int, limit int) ([]*DailyActivity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	activity := make([]*DailyActivity, 0)
+	for key, day := range s.activity {
+		if key.userID == userID {
+			day := *day
+			activity = append(activity, &day)
+		}
+	}
+
+	slices.SortFunc(activity, func(a, b *DailyActivity) int {
+		return cmp.Compare(b.Date, a.Date)
+	})
+	if len(activity) > limit {
+		activity = activity[:limit]
+	}
+
+	return activity, nil
+}
+
+func (s *memoryService) UpdateAccuracyStats(userID int, isCorrect bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userID)
+	user.totalAttempts++
+	if isCorrect {
+		user.correctAttempts++
+	}
+
+	return nil
+}
+
+func (s *memoryService) GetAccuracyStats(userID int) (*AccuracyStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userID)
+	return &AccuracyStats{
+		UserID:          userID,
+		TotalAttempts:   user.totalAttempts,
+		CorrectAttempts: user.correctAttempts,
+		AccuracyRate:    accuracyRate(user.totalAttempts, user.correctAttempts),
+	}, nil
+}
+
+func (s *memoryService) ResetStreaks(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streak := &s.user(userID).dailyStreak
+	streak.streak, streak.lostStreak, streak.lostAt = 0, 0, time.Time{}
+	for key, lesson := range s.lessons {
+		if key.userID == userID {
+			lesson.currentStreak = 0
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryService) AdjustPoints(userID int, points int, reason string) (*PointTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userID)
+	if user.totalPoints+points < 0 {
+		return nil, ErrInsufficientPoints
+	}
+
+	user.totalPoints += points
+
+	return s.recordTransaction(userID, "system", "", "", TransactionTypeAdminAdjustment, points, reason), nil
+}
+
+// user returns the points state for a user, creating it if missing. Callers must hold the write lock.
+func (s *memoryService) user(userID int) *memoryUser {
+	user, ok := s.users[userID]
+	if !ok {
+		user = &memoryUser{updatedAt: time.Now()}
+		s.users[userID] = user
+	}
+
+	return user
+}
+
+// lesson returns the points state for a lesson, creating it if missing. Callers must hold the write lock.
+func (s *memoryService) lesson(userID int, courseID, lessonID string) *memoryLesson {
+	key := lessonKey{userID: userID, courseID: courseID, lessonID: lessonID}
+	lesson, ok := s.lessons[key]
+	if !ok {
+		lesson = &memoryLesson{lastAttemptAt: time.Now()}
+		s.lessons[key] = lesson
+	}
+
+	return lesson
+}
+
+func (s *memoryService) hasTransaction(userID int, courseID, lessonID, transactionType string) bool {
+	for _, transaction := range s.transactions {
+		if transaction.UserID == userID && transaction.CourseID == courseID &&
+			transaction.LessonID == lessonID && transaction.TransactionType == transactionType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordTransaction appends to the ledger and returns a copy. Callers must hold the write lock.
+func (s *memoryService) recordTransaction(userID int, courseID, lessonID, exerciseID, transactionType string, points int, description string) *PointTransaction {
+	return s.record(&PointTransaction{
+		UserID:          userID,
+		CourseID:        courseID,
+		LessonID:        lessonID,
+		ExerciseID:      exerciseID,
+		TransactionType: transactionType,
+		Points:          points,
+		Description:     description,
+	})
+}
+
+// record assigns an ID and time to a transaction, appends it to the ledger and returns a copy.
+// Callers must hold the write lock.
+func (s *memoryService) record(transaction *PointTransaction) *PointTransaction {
+	transaction.ID = s.nextID
+	transaction.CreatedAt = time.Now()
+	s.nextID++
+	s.transactions = append(s.transactions, transaction)
+	s.user(transaction.UserID).updatedAt = transaction.CreatedAt
+
+	recorded := *transaction
+	return &recorded
+}
+
+// Reconcile checks the user and lesson totals; the memory service doesn't keep course totals
+func (s *memoryService) Reconcile(dryRun bool) (*ReconcileReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userLedger := make(map[int]int)
+	lessonLedger := make(map[lessonKey]int)
+	for _, transaction := range s.transactions {
+		userLedger[transaction.UserID] += transaction.Points
+		if transaction.LessonID != "" {
+			lessonLedger[lessonKey{userID: transaction.UserID, courseID: transaction.CourseID, lessonID: transaction.LessonID}] += transaction.Points
+		}
+	}
+
+	report := &ReconcileReport{DryRun: dryRun, Drift: make([]*Drift, 0)}
+
+	userIDs := make([]int, 0, len(s.users))
+	for userID := range s.users {
+		userIDs = append(userIDs, userID)
+	}
+	slices.Sort(userIDs)
+	for _, userID := range userIDs {
+		user := s.users[userID]
+		if user.totalPoints != userLedger[userID] {
+			report.Drift = append(report.Drift, &Drift{
+				Aggregate: AggregateUser,
+				UserID:    userID,
+				Stored:    user.totalPoints,
+				Ledger:    userLedger[userID],
+				Repaired:  !dryRun,
+			})
+			if !dryRun {
+				user.totalPoints = userLedger[userID]
+			}
+		}
+	}
+
+	keys := make([]lessonKey, 0, len(s.lessons))
+	for key := range s.lessons {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b lessonKey) int {
+		return cmp.Or(cmp.Compare(a.userID, b.userID), cmp.Compare(a.courseID, b.courseID), cmp.Compare(a.lessonID, b.lessonID))
+	})
+	for _, key := range keys {
+		lesson := s.lessons[key]
+		if lesson.totalPoints != lessonLedger[key] {
+			report.Drift = append(report.Drift, &Drift{
+				Aggregate: AggregateLesson,
+				UserID:    key.userID,
+				CourseID:  key.courseID,
+				LessonID:  key.lessonID,
+				Stored:    lesson.totalPoints,
+				Ledger:    lessonLedger[key],
+				Repaired:  !dryRun,
+			})
+			if !dryRun {
+				lesson.totalPoints = lessonLedger[key]
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (s *memoryService) GetLeaderboard(query LeaderboardQuery) (*Leaderboard, error) {
+	leaderboard, err := newLeaderboard(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scores := make(map[int]int)
+	for _, transaction := range s.transactions {
+		if leaderboard.Since != nil && transaction.CreatedAt.Before(*leaderboard.Since) {
+			continue
+		}
+		if query.CourseID != "" && transaction.CourseID != query.CourseID {
+			continue
+		}
+		if query.UserIDs != nil && !slices.Contains(query.UserIDs, transaction.UserID) {
+			continue
+		}
+		if slices.Contains(SpendingTransactionTypes, transaction.TransactionType) {
+			continue
+		}
+		scores[transaction.UserID] += transaction.Points
+	}
+
+	var entries []LeaderboardEntry
+	for userID, points := range scores {
+		if points > 0 {
+			entries = append(entries, LeaderboardEntry{UserID: userID, Points: points})
+		}
+	}
+	rankEntries(entries)
+
+	leaderboard.Me = &LeaderboardEntry{UserID: query.UserID, Rank: len(entries) + 1}
+	for i, entry := range entries {
+		if i < query.Limit {
+			leaderboard.Entries = append(leaderboard.Entries, entry)
+		}
+		if entry.UserID == query.UserID {
+			me := entry
+			leaderboard.Me = &me
+		}
+	}
+
+	return leaderboard, nil
+}
+
+func (s *memoryService) GetActivity(query ActivityQuery) ([]*PointTransaction, error) {
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	transactions := make([]*PointTransaction, 0)
+	for i := len(s.transactions) - 1; i >= 0 && len(transactions) < query.Limit; i-- {
+		transaction := s.transactions[i]
+		if transaction.TransactionType == TransactionTypeAdminAdjustment ||
+			slices.Contains(SpendingTransactionTypes, transaction.TransactionType) ||
+			(query.UserIDs != nil && !slices.Contains(query.UserIDs, transaction.UserID)) ||
+			slices.Contains(query.ExcludeUserIDs, transaction.UserID) {
+			continue
+		}
+
+		found := *transaction
+		transactions = append(transactions, &found)
+	}
+
+	return transactions, nil
+}
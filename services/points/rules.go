@@ -0,0 +1,394 @@
+package points
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scoring rules shared by the database and memory implementations
+
+// correctAnswerAward returns the points and description for a correct answer
+// that brings the lesson streak to newStreak
+func correctAnswerAward(config PointsConfig, newStreak int) (int, string) {
+	streakBonus := 0
+	if newStreak > 1 {
+		streakBonus = newStreak * config.StreakBonusMultiplier
+		if streakBonus > config.MaxStreakBonus {
+			streakBonus = config.MaxStreakBonus
+		}
+	}
+
+	basePoints := config.CorrectAnswerPoints
+	totalPoints := basePoints + streakBonus
+
+	description := fmt.Sprintf("Correct answer (+%d points)", basePoints)
+	if streakBonus > 0 {
+		description += fmt.Sprintf(" with streak bonus of %d consecutive correct answers (+%d points)", newStreak, streakBonus)
+	}
+
+	return totalPoints, description
+}
+
+// answerHistory is what the scoring policies look at besides the answer itself
+type answerHistory struct {
+	attemptNumber   int
+	answeredCorrect bool // an earlier attempt was correct
+	revealed        bool
+	lessonPoints    int // correct answer points already earned in the lesson
+}
+
+// applyScoringPolicies reduces the points for a correct answer according to the enabled policies.
+// It returns the points left and the policy that decided them.
+func applyScoringPolicies(config PointsConfig, points int, history answerHistory) (int, ScoringPolicy, string) {
+	switch {
+	case config.NoPointsAfterReveal && history.revealed:
+		return 0, PolicyAnswerRevealed, "Correct answer after the answer was revealed (+0 points)"
+	case config.FirstCorrectOnly && history.answeredCorrect:
+		return 0, PolicyFirstCorrect, "Exercise already answered correctly (+0 points)"
+	}
+
+	policy := PolicyStandard
+	description := ""
+	if config.DiminishingReturns && history.attemptNumber > 1 {
+		points /= history.attemptNumber
+		policy = PolicyDiminishing
+		description = fmt.Sprintf("Correct answer on attempt %d (+%d points)", history.attemptNumber, points)
+	}
+
+	if config.LessonPointCap > 0 && history.lessonPoints+points > config.LessonPointCap {
+		points = max(config.LessonPointCap-history.lessonPoints, 0)
+		policy = PolicyLessonCap
+		description = fmt.Sprintf("Lesson point cap of %d reached (+%d points)", config.LessonPointCap, points)
+	}
+
+	return points, policy, description
+}
+
+// scoreCorrectAnswer works out the award for a correct answer that brings the lesson streak to newStreak
+func scoreCorrectAnswer(config PointsConfig, newStreak int, history answerHistory) (int, ScoringPolicy, string) {
+	points, description := correctAnswerAward(config, newStreak)
+
+	points, policy, policyDescription := applyScoringPolicies(config, points, history)
+	if policy != PolicyStandard {
+		description = policyDescription
+	}
+
+	return points, policy, description
+}
+
+// DailyStreakDays returns how many calendar days separate lastActiveDate, the day the streak last
+// counted in the zone it was counted in, from the day now falls on in loc. Comparing dates rather
+// than instants keeps a change of zone from moving the last counted day.
+func DailyStreakDays(lastActiveDate, now time.Time, loc *time.Location) int {
+	return int(streakDate(now, loc).Sub(streakDate(lastActiveDate, lastActiveDate.Location())).Hours() / 24)
+}
+
+// streakDate returns the date t falls on in loc, as midnight UTC so that DST shifts don't skew day counts
+func streakDate(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// dailyStreakState is a user's stored daily streak
+type dailyStreakState struct {
+	streak     int
+	maxStreak  int
+	freezes    int
+	lastActive time.Time // When the last day counted, zero if none has
+	lastDate   time.Time // The day that last counted, in the zone it counted in, as midnight UTC
+	lostStreak int       // A broken streak that may still be repaired
+	lostAt     time.Time // When lostStreak lapsed
+}
+
+// streakDay is what counting a day did to the daily streak
+type streakDay struct {
+	counted       bool // false when the day had already counted
+	previous      int  // The streak before the day counted
+	freezesUsed   int
+	freezesEarned int
+}
+
+// lapsesAt returns when the streak breaks: midnight in loc after the last missed day its freezes cover
+func (s *dailyStreakState) lapsesAt(loc *time.Location) time.Time {
+	year, month, day := s.lastDate.Date()
+	return time.Date(year, month, day+2+s.freezes, 0, 0, 0, 0, loc)
+}
+
+// current returns the streak as of now, 0 once it has lapsed
+func (s *dailyStreakState) current(now time.Time, loc *time.Location) int {
+	if s.lastDate.IsZero() || !now.Before(s.lapsesAt(loc)) {
+		return 0
+	}
+
+	return s.streak
+}
+
+// countDay counts the day of now toward the streak, counting days in loc. Freezes cover missed days
+// when there are enough of them, otherwise the streak starts over and the broken one is kept for repair.
+func (s *dailyStreakState) countDay(config PointsConfig, now time.Time, loc *time.Location) streakDay {
+	day := streakDay{counted: true, previous: s.streak}
+
+	switch days := DailyStreakDays(s.lastDate, now, loc); {
+	case s.lastDate.IsZero():
+		s.streak = 1
+	case days <= 0:
+		return streakDay{previous: s.streak}
+	case days == 1:
+		// Consecutive day
+		s.streak++
+	case s.streak > 0 && days-1 <= s.freezes:
+		day.freezesUsed = days - 1
+		s.freezes -= day.freezesUsed
+		s.streak++
+	default:
+		// Streak broken
+		if s.streak > 0 {
+			s.lostStreak, s.lostAt = s.streak, s.lapsesAt(loc)
+		}
+		s.streak = 1
+	}
+
+	if dailyStreakMilestone(config, day.previous, s.streak) > 0 {
+		day.freezesEarned = max(min(config.MilestoneFreezes, config.MaxStreakFreezes-s.freezes), 0)
+		s.freezes += day.freezesEarned
+	}
+	s.maxStreak = max(s.maxStreak, s.streak)
+	s.lastActive = now
+	s.lastDate = streakDate(now, loc)
+
+	return day
+}
+
+// repairDeadline returns when a broken streak stops being repairable. reset is true when the streak
+// has started over since it broke, and false when it lapsed without another day counting.
+func (s *dailyStreakState) repairDeadline(config PointsConfig, now time.Time, loc *time.Location) (deadline time.Time, reset bool, ok bool) {
+	if s.streak > 0 && !s.lastDate.IsZero() {
+		lapsedAt := s.lapsesAt(loc)
+		if !now.Before(lapsedAt) {
+			return lapsedAt.Add(config.StreakRepairWindow), false, now.Before(lapsedAt.Add(config.StreakRepairWindow))
+		}
+	}
+
+	if s.lostStreak > 0 && now.Before(s.lostAt.Add(config.StreakRepairWindow)) {
+		return s.lostAt.Add(config.StreakRepairWindow), true, true
+	}
+
+	return time.Time{}, false, false
+}
+
+// repair restores a broken streak. The missed days are bridged rather than counted, and a streak
+// that started over since is added on top. It returns false when there is nothing to repair.
+func (s *dailyStreakState) repair(config PointsConfig, now time.Time, loc *time.Location) bool {
+	_, reset, ok := s.repairDeadline(config, now, loc)
+	if !ok {
+		return false
+	}
+
+	if reset {
+		s.streak += s.lostStreak
+	} else {
+		// Count yesterday so that today continues the streak
+		s.lastDate = streakDate(now, loc).AddDate(0, 0, -1)
+	}
+	s.maxStreak = max(s.maxStreak, s.streak)
+	s.lostStreak, s.lostAt = 0, time.Time{}
+
+	return true
+}
+
+// streakDayTransactions are the ledger entries for counting a day, in order. Only the bonus has points.
+func streakDayTransactions(config PointsConfig, day streakDay, streak int) []*PointTransaction {
+	var transactions []*PointTransaction
+	if day.freezesUsed > 0 {
+		transactions = append(transactions, &PointTransaction{
+			TransactionType: TransactionTypeStreakFreezeUsed,
+			Description:     fmt.Sprintf("Streak freezes used: %d (kept a %d day streak)", day.freezesUsed, day.previous),
+		})
+	}
+	if bonusPoints, description := dailyStreakAward(config, day.previous, streak); bonusPoints > 0 {
+		transactions = append(transactions, &PointTransaction{
+			TransactionType: TransactionTypeDailyStreakBonus,
+			Points:          bonusPoints,
+			Description:     description,
+		})
+	}
+	if day.freezesEarned > 0 {
+		transactions = append(transactions, &PointTransaction{
+			TransactionType: TransactionTypeStreakFreezeEarned,
+			Description:     fmt.Sprintf("Streak freezes earned: %d (%d day streak)", day.freezesEarned, streak),
+		})
+	}
+
+	return transactions
+}
+
+// newDailyStreakInfo describes a user's daily streak as seen from loc
+func newDailyStreakInfo(config PointsConfig, userID int, state dailyStreakState, loc *time.Location) *DailyStreakInfo {
+	now := time.Now()
+	streak := &DailyStreakInfo{
+		UserID:        userID,
+		CurrentStreak: state.current(now, loc),
+		MaxStreak:     state.maxStreak,
+		Timezone:      loc.String(),
+		Freezes:       state.freezes,
+	}
+	if !state.lastDate.IsZero() {
+		streak.LastActiveAt = state.lastActive.In(loc)
+		streak.LastActiveDate = time.Date(state.lastDate.Year(), state.lastDate.Month(), state.lastDate.Day(), 0, 0, 0, 0, loc)
+	}
+	if deadline, reset, ok := state.repairDeadline(config, now, loc); ok {
+		streak.RepairableStreak = state.streak
+		if reset {
+			streak.RepairableStreak += state.lostStreak
+		}
+		streak.RepairDeadline = &deadline
+		streak.RepairCost = config.StreakRepairCost
+	}
+	streak.NextMilestone, streak.DaysToMilestone = nextDailyStreakMilestone(config, streak.CurrentStreak)
+
+	return streak
+}
+
+// activityDate is the day in loc that activity at t is recorded under
+func activityDate(t time.Time, loc *time.Location) string {
+	return streakDate(t, loc).Format(time.DateOnly)
+}
+
+// qualifies reports whether a day's activity meets the rule, and if so describes why
+func (r StreakRule) qualifies(activity *DailyActivity) (string, bool) {
+	if activity.CorrectAnswers < r.MinCorrectAnswers || activity.Points < r.MinPoints {
+		return "", false
+	}
+
+	var reasons []string
+	if r.MinCorrectAnswers > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d/%d correct answers", activity.CorrectAnswers, r.MinCorrectAnswers))
+	}
+	if r.MinPoints > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d/%d points", activity.Points, r.MinPoints))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "attempted an exercise")
+	}
+
+	return strings.Join(reasons, " and "), true
+}
+
+// dailyStreakMilestone returns the milestone passed going from previousStreak to newStreak, or 0
+func dailyStreakMilestone(config PointsConfig, previousStreak, newStreak int) int {
+	for _, milestone := range config.DailyStreakMilestones {
+		if previousStreak < milestone && newStreak >= milestone {
+			return milestone
+		}
+	}
+
+	return 0
+}
+
+// dailyStreakAward returns the bonus points and description for reaching newStreak from previousStreak.
+// No points are awarded for the first day of a streak.
+func dailyStreakAward(config PointsConfig, previousStreak, newStreak int) (int, string) {
+	if milestone := dailyStreakMilestone(config, previousStreak, newStreak); milestone > 0 {
+		bonusPoints := milestone * config.MilestoneBonusMultiplier
+		return bonusPoints, fmt.Sprintf("%d day streak milestone reached! (+%d points)", milestone, bonusPoints)
+	}
+
+	if newStreak > 1 {
+		bonusPoints := config.DailyStreakBonusPoints
+		return bonusPoints, fmt.Sprintf("Daily streak: %d days (+%d points)", newStreak, bonusPoints)
+	}
+
+	return 0, ""
+}
+
+// nextDailyStreakMilestone returns the next milestone above the current streak and the days left to it
+func nextDailyStreakMilestone(config PointsConfig, currentStreak int) (int, int) {
+	for _, milestone := range config.DailyStreakMilestones {
+		if currentStreak < milestone {
+			return milestone, milestone - currentStreak
+		}
+	}
+
+	return 0, 0
+}
+
+// accuracyRate returns the percentage of correct attempts
+func accuracyRate(totalAttempts, correctAttempts int) float64 {
+	if totalAttempts == 0 {
+		return 0
+	}
+
+	return float64(correctAttempts) / float64(totalAttempts) * 100
+}
+
+// Valid reports whether p is one of the known periods
+func (p LeaderboardPeriod) Valid() bool {
+	switch p {
+	case PeriodDaily, PeriodWeekly, PeriodMonthly, PeriodAllTime:
+		return true
+	}
+
+	return false
+}
+
+// Start returns when the period containing now began, in UTC. Weeks start on Monday.
+// It returns false for all time.
+func (p LeaderboardPeriod) Start(now time.Time) (time.Time, bool) {
+	year, month, day := now.UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	switch p {
+	case PeriodDaily:
+		return today, true
+	case PeriodWeekly:
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		return today.AddDate(0, 0, -daysSinceMonday), true
+	case PeriodMonthly:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), true
+	}
+
+	return time.Time{}, false
+}
+
+// newLeaderboard checks a query, filling in the defaults, and returns the empty leaderboard it selects
+func newLeaderboard(query *LeaderboardQuery) (*Leaderboard, error) {
+	if query.Period == "" {
+		query.Period = PeriodAllTime
+	}
+	if !query.Period.Valid() {
+		return nil, ErrInvalidPeriod
+	}
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+
+	leaderboard := &Leaderboard{
+		Period:   query.Period,
+		CourseID: query.CourseID,
+		Entries:  make([]LeaderboardEntry, 0),
+	}
+	if since, ok := query.Period.Start(time.Now()); ok {
+		leaderboard.Since = &since
+	}
+
+	return leaderboard, nil
+}
+
+// rankEntries sorts entries by points, breaking ties by user ID, and ranks them so that
+// users with the same points share a rank and the next rank skips ahead (1, 1, 3)
+func rankEntries(entries []LeaderboardEntry) {
+	slices.SortFunc(entries, func(a, b LeaderboardEntry) int {
+		return cmp.Or(cmp.Compare(b.Points, a.Points), cmp.Compare(a.UserID, b.UserID))
+	})
+
+	for i := range entries {
+		if i > 0 && entries[i].Points == entries[i-1].Points {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+}
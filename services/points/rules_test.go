@@ -0,0 +1,97 @@
+package points
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreakFreezes(t *testing.T) {
+	config := DefaultPointsConfig
+	config.DailyStreakMilestones = []int{3}
+	day := func(n int) time.Time { return time.Date(2024, time.May, n, 12, 0, 0, 0, time.UTC) }
+
+	var state dailyStreakState
+	for n := 1; n <= 3; n++ {
+		state.countDay(config, day(n), time.UTC)
+	}
+	if state.streak != 3 || state.freezes != config.MilestoneFreezes {
+		t.Fatalf("expected the milestone to earn a freeze, got %+v", state)
+	}
+	if state.current(day(5), time.UTC) != 3 || state.current(day(6), time.UTC) != 0 {
+		t.Errorf("expected a freeze to keep the streak alive for one missed day, got %+v", state)
+	}
+
+	// Missing the 4th uses the freeze
+	counted := state.countDay(config, day(5), time.UTC)
+	if counted.freezesUsed != 1 || state.streak != 4 || state.freezes != 0 {
+		t.Errorf("expected the freeze to cover the missed day, got %+v and %+v", counted, state)
+	}
+
+	// Missing the 6th and 7th with no freezes left breaks it
+	state.countDay(config, day(8), time.UTC)
+	if state.streak != 1 || state.lostStreak != 4 || !state.lostAt.Equal(time.Date(2024, time.May, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the streak to start over and keep the broken one, got %+v", state)
+	}
+
+	transactions := streakDayTransactions(config, streakDay{counted: true, previous: 2, freezesUsed: 1, freezesEarned: 1}, 3)
+	if len(transactions) != 3 || transactions[0].TransactionType != TransactionTypeStreakFreezeUsed ||
+		transactions[1].TransactionType != TransactionTypeDailyStreakBonus || transactions[2].TransactionType != TransactionTypeStreakFreezeEarned {
+		t.Errorf("expected freeze used, bonus and freeze earned transactions, got %+v", transactions)
+	}
+}
+
+func TestStreakRepair(t *testing.T) {
+	config := DefaultPointsConfig
+	day := func(n, hour int) time.Time { return time.Date(2024, time.May, n, hour, 0, 0, 0, time.UTC) }
+
+	// A 5 day streak ending on the 5th lapses at midnight on the 7th
+	lapsed := dailyStreakState{streak: 5, maxStreak: 5, lastActive: day(5, 12), lastDate: day(5, 0)}
+	if lapsed.repair(config, day(6, 12), time.UTC) {
+		t.Error("expected a streak that hasn't lapsed not to need repair")
+	}
+
+	repaired := lapsed
+	if !repaired.repair(config, day(8, 12), time.UTC) {
+		t.Fatal("expected the lapsed streak to be repairable")
+	}
+	if repaired.current(day(8, 12), time.UTC) != 5 {
+		t.Errorf("expected the streak to be back, got %+v", repaired)
+	}
+	repaired.countDay(config, day(8, 13), time.UTC)
+	if repaired.streak != 6 {
+		t.Errorf("expected today to continue the repaired streak, got %+v", repaired)
+	}
+
+	if tooLate := lapsed; tooLate.repair(config, day(9, 0), time.UTC) {
+		t.Error("expected the repair window to have closed")
+	}
+
+	// Counting a day after the streak broke starts a new one that the repair adds to
+	restarted := lapsed
+	restarted.countDay(config, day(8, 12), time.UTC)
+	if !restarted.repair(config, day(8, 13), time.UTC) || restarted.streak != 6 || restarted.lostStreak != 0 {
+		t.Errorf("expected the broken streak to be added back, got %+v", restarted)
+	}
+}
+
+func TestStreakDayAcrossZoneChange(t *testing.T) {
+	config := DefaultPointsConfig
+	honolulu, err := time.LoadLocation("Pacific/Honolulu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 01:00 UTC on the 17th is still the 16th in Honolulu, but the day counted was the 17th
+	var state dailyStreakState
+	state.countDay(config, time.Date(2026, time.October, 17, 1, 0, 0, 0, time.UTC), time.UTC)
+	if day := state.countDay(config, time.Date(2026, time.October, 17, 11, 0, 0, 0, time.UTC), honolulu); day.counted || state.streak != 1 {
+		t.Errorf("expected the same day not to count again in the new zone, got %+v", state)
+	}
+
+	// 23:00 on the 16th in Honolulu is already the 17th in UTC, but the day counted was the 16th
+	state = dailyStreakState{}
+	state.countDay(config, time.Date(2026, time.October, 16, 23, 0, 0, 0, honolulu), honolulu)
+	if day := state.countDay(config, time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC), time.UTC); !day.counted || state.streak != 2 {
+		t.Errorf("expected the next day to continue the streak in the new zone, got %+v", state)
+	}
+}
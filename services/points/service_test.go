@@ -0,0 +1,621 @@
+package points_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/progress"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestMemoryService(t *testing.T) {
+	testService(t, points.NewMemoryService(), 1)
+}
+
+func TestPostgresService(t *testing.T) {
+	database := dbtest.Open(t)
+
+	username := dbtest.UniqueName("pointsuser")
+	userService := user.NewService(database)
+	testUser, err := userService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { userService.DeleteUser(username) })
+
+	// The database implementation keeps lesson streaks and bonuses on the progress rows
+	progressService := progress.NewService(database)
+	if _, err := progressService.GetOrCreateCourseProgress(testUser.ID, "testCourse"); err != nil {
+		t.Fatal(err)
+	}
+	for _, lessonID := range []string{"Lesson1", "Lesson2"} {
+		if _, err := progressService.GetOrCreateLessonProgress(testUser.ID, "testCourse", lessonID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := points.NewService(database)
+	testService(t, service, testUser.ID)
+
+	t.Run("ConcurrentFirstCorrect", func(t *testing.T) {
+		transactions := make([]*points.PointTransaction, 8)
+		var wg sync.WaitGroup
+		for i := range transactions {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				transaction, err := service.AwardPointsForCorrectAnswer(testUser.ID, "testCourse", "Concurrent", "exercise1", 1, true)
+				if err != nil {
+					t.Error(err)
+				}
+				transactions[i] = transaction
+			}()
+		}
+		wg.Wait()
+
+		scored := 0
+		for _, transaction := range transactions {
+			if transaction != nil && transaction.Points > 0 {
+				scored++
+			}
+		}
+		if scored != 1 {
+			t.Errorf("expected only one of the concurrent answers to score, got %d", scored)
+		}
+	})
+
+	t.Run("RepairDrift", func(t *testing.T) {
+		if _, err := database.Exec(`UPDATE users SET total_points = total_points + 7 WHERE id = $1`, testUser.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := database.Exec(`UPDATE user_course_progress SET total_course_points = 0 WHERE user_id = $1`, testUser.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := service.Reconcile(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		drift := userDrift(report, testUser.ID)
+		if len(drift) != 2 || drift[0].Aggregate != points.AggregateUser || drift[0].Stored-drift[0].Ledger != 7 ||
+			drift[1].Aggregate != points.AggregateCourse || drift[1].Repaired {
+			t.Fatalf("unexpected dry run drift: %v", drift)
+		}
+
+		report, err = service.Reconcile(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range userDrift(report, testUser.ID) {
+			if !d.Repaired {
+				t.Errorf("expected %v to be repaired", d)
+			}
+		}
+
+		report, err = service.Reconcile(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if drift := userDrift(report, testUser.ID); len(drift) != 0 {
+			t.Errorf("expected no drift after repairing, got %v", drift)
+		}
+	})
+}
+
+// testService runs the same checks against every points.Service implementation
+func testService(t *testing.T, service points.Service, userID int) {
+	config := points.DefaultPointsConfig
+	courseID := "testCourse"
+	lessonID := "Lesson1"
+
+	t.Run("AwardPointsForCorrectAnswer", func(t *testing.T) {
+		first, err := service.AwardPointsForCorrectAnswer(userID, courseID, lessonID, "ex1", 1, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil || first.Points != config.CorrectAnswerPoints {
+			t.Fatalf("expected %d points, got %+v", config.CorrectAnswerPoints, first)
+		}
+
+		second, err := service.AwardPointsForCorrectAnswer(userID, courseID, lessonID, "ex2", 1, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := config.CorrectAnswerPoints + 2*config.StreakBonusMultiplier; second == nil || second.Points != want {
+			t.Fatalf("expected %d points with streak bonus, got %+v", want, second)
+		}
+
+		wrong, err := service.AwardPointsForCorrectAnswer(userID, courseID, lessonID, "ex3", 1, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wrong != nil {
+			t.Errorf("expected no transaction for a wrong answer, got %+v", wrong)
+		}
+
+		lessonPoints, err := service.GetLessonPoints(userID, courseID, lessonID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lessonPoints.CurrentStreak != 0 || lessonPoints.MaxStreak != 2 {
+			t.Errorf("expected streak reset with max 2, got %+v", lessonPoints)
+		}
+	})
+
+	t.Run("CompletionBonuses", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			lessonBonus, err := service.AwardLessonCompletionBonus(userID, courseID, lessonID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i == 0 && (lessonBonus == nil || lessonBonus.Points != config.LessonCompletionBonus) {
+				t.Errorf("expected lesson bonus of %d, got %+v", config.LessonCompletionBonus, lessonBonus)
+			}
+			if i == 1 && lessonBonus != nil {
+				t.Errorf("expected lesson bonus to be awarded once, got %+v", lessonBonus)
+			}
+
+			courseBonus, err := service.AwardCourseCompletionBonus(userID, courseID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i == 0 && (courseBonus == nil || courseBonus.Points != config.CourseCompletionBonus) {
+				t.Errorf("expected course bonus of %d, got %+v", config.CourseCompletionBonus, courseBonus)
+			}
+			if i == 1 && courseBonus != nil {
+				t.Errorf("expected course bonus to be awarded once, got %+v", courseBonus)
+			}
+		}
+	})
+
+	t.Run("GetUserTotalPoints", func(t *testing.T) {
+		want := 2*config.CorrectAnswerPoints + 2*config.StreakBonusMultiplier +
+			config.LessonCompletionBonus + config.CourseCompletionBonus
+
+		userPoints, err := service.GetUserTotalPoints(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if userPoints.TotalPoints != want {
+			t.Errorf("expected %d total points, got %d", want, userPoints.TotalPoints)
+		}
+	})
+
+	t.Run("GetRecentTransactions", func(t *testing.T) {
+		transactions, err := service.GetRecentTransactions(userID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 4 {
+			t.Fatalf("expected 4 transactions, got %d", len(transactions))
+		}
+		if transactions[0].TransactionType != points.TransactionTypeCourseCompleted {
+			t.Errorf("expected newest transaction first, got %+v", transactions[0])
+		}
+	})
+
+	t.Run("RecordActivity", func(t *testing.T) {
+		result, err := service.RecordActivity(userID, time.UTC, false, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.DayCounted || result.Activity.QualifiedAt != nil {
+			t.Errorf("expected a wrong answer not to count the day, got %+v", result.Activity)
+		}
+
+		result, err = service.RecordActivity(userID, time.UTC, true, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.DayCounted || result.StreakBonus != nil || result.Activity.Reason == "" {
+			t.Errorf("expected a correct answer to count the first day without a bonus, got %+v", result)
+		}
+
+		result, err = service.RecordActivity(userID, time.UTC, true, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.DayCounted {
+			t.Error("expected the day to count only once")
+		}
+
+		activity, err := service.ListDailyActivity(userID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(activity) != 1 || activity[0].Attempts != 3 || activity[0].CorrectAnswers != 2 || activity[0].Points != 15 {
+			t.Errorf("unexpected daily activity: %+v", activity)
+		}
+	})
+
+	t.Run("UpdateDailyStreak", func(t *testing.T) {
+		losAngeles, err := time.LoadLocation("America/Los_Angeles")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2; i++ {
+			// The first day of a streak and counting the same day again are both worth nothing
+			transaction, err := service.UpdateDailyStreak(userID, losAngeles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transaction != nil {
+				t.Errorf("expected no daily streak bonus, got %+v", transaction)
+			}
+		}
+
+		streak, err := service.GetDailyStreak(userID, losAngeles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if streak.CurrentStreak != 1 || streak.NextMilestone != config.DailyStreakMilestones[0] {
+			t.Errorf("unexpected daily streak: %+v", streak)
+		}
+		if streak.Timezone != "America/Los_Angeles" || streak.LastActiveDate.Location() != losAngeles {
+			t.Errorf("expected the streak in Los Angeles time, got %+v", streak)
+		}
+
+		// Moving west, where it's the same day or still the day before, neither counts as a new day
+		// nor drops the streak
+		honolulu, err := time.LoadLocation("Pacific/Honolulu")
+		if err != nil {
+			t.Fatal(err)
+		}
+		transaction, err := service.UpdateDailyStreak(userID, honolulu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transaction != nil {
+			t.Errorf("expected no daily streak bonus after changing zone, got %+v", transaction)
+		}
+		streak, err = service.GetDailyStreak(userID, honolulu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if streak.CurrentStreak != 1 {
+			t.Errorf("expected the streak to survive a zone change, got %+v", streak)
+		}
+	})
+
+	t.Run("AdjustPoints", func(t *testing.T) {
+		before, err := service.GetUserTotalPoints(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		transaction, err := service.AdjustPoints(userID, -10, "Correction")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transaction.TransactionType != points.TransactionTypeAdminAdjustment || transaction.Points != -10 {
+			t.Errorf("unexpected adjustment: %+v", transaction)
+		}
+
+		if _, err := service.AdjustPoints(userID, -before.TotalPoints, "Too much"); !errors.Is(err, points.ErrInsufficientPoints) {
+			t.Errorf("expected ErrInsufficientPoints, got %v", err)
+		}
+
+		after, err := service.GetUserTotalPoints(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after.TotalPoints != before.TotalPoints-10 {
+			t.Errorf("expected %d total points, got %d", before.TotalPoints-10, after.TotalPoints)
+		}
+	})
+
+	t.Run("ResetStreaks", func(t *testing.T) {
+		if _, err := service.AwardPointsForCorrectAnswer(userID, courseID, lessonID, "ex4", 1, true); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := service.ResetStreaks(userID); err != nil {
+			t.Fatal(err)
+		}
+
+		lessonPoints, err := service.GetLessonPoints(userID, courseID, lessonID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		streak, err := service.GetDailyStreak(userID, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lessonPoints.CurrentStreak != 0 || streak.CurrentStreak != 0 {
+			t.Errorf("expected streaks to be reset, got lesson %d and daily %d", lessonPoints.CurrentStreak, streak.CurrentStreak)
+		}
+	})
+
+	t.Run("StreakFreezes", func(t *testing.T) {
+		total, err := service.GetUserTotalPoints(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.AdjustPoints(userID, -total.TotalPoints, "Start from nothing"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.BuyStreakFreeze(userID, config.StreakFreezeCost); !errors.Is(err, points.ErrInsufficientPoints) {
+			t.Errorf("expected ErrInsufficientPoints, got %v", err)
+		}
+
+		if _, err := service.AdjustPoints(userID, config.MaxStreakFreezes*config.StreakFreezeCost, "Freezes"); err != nil {
+			t.Fatal(err)
+		}
+		before, err := service.GetLeaderboard(points.LeaderboardQuery{UserID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for range config.MaxStreakFreezes {
+			transaction, err := service.BuyStreakFreeze(userID, config.StreakFreezeCost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transaction.TransactionType != points.TransactionTypeStreakFreezePurchase || transaction.Points != -config.StreakFreezeCost {
+				t.Errorf("unexpected purchase: %+v", transaction)
+			}
+		}
+		if _, err := service.BuyStreakFreeze(userID, config.StreakFreezeCost); !errors.Is(err, points.ErrFreezeLimit) {
+			t.Errorf("expected ErrFreezeLimit, got %v", err)
+		}
+
+		streak, err := service.GetDailyStreak(userID, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if streak.Freezes != config.MaxStreakFreezes {
+			t.Errorf("expected %d freezes, got %+v", config.MaxStreakFreezes, streak)
+		}
+
+		total, err = service.GetUserTotalPoints(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		after, err := service.GetLeaderboard(points.LeaderboardQuery{UserID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total.TotalPoints != 0 || after.Me.Points != before.Me.Points {
+			t.Errorf("expected purchases to spend the total but not lower the leaderboard, got %d total and %d then %d leaderboard points",
+				total.TotalPoints, before.Me.Points, after.Me.Points)
+		}
+
+		if _, err := service.RepairDailyStreak(userID, time.UTC); !errors.Is(err, points.ErrNothingToRepair) {
+			t.Errorf("expected ErrNothingToRepair, got %v", err)
+		}
+	})
+
+	t.Run("AccuracyStats", func(t *testing.T) {
+		for _, isCorrect := range []bool{true, true, true, false} {
+			if err := service.UpdateAccuracyStats(userID, isCorrect); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stats, err := service.GetAccuracyStats(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.TotalAttempts != 4 || stats.CorrectAttempts != 3 || stats.AccuracyRate != 75 {
+			t.Errorf("unexpected accuracy stats: %+v", stats)
+		}
+	})
+
+	t.Run("ScoringPolicies", func(t *testing.T) {
+		const lessonID = "Lesson2"
+
+		if err := service.MarkAnswerRevealed(userID, courseID, lessonID, "ex3"); err != nil {
+			t.Fatal(err)
+		}
+
+		capped := config
+		capped.LessonPointCap = 20
+
+		// Streak bonuses grow with every correct answer in the lesson: 10, 14, 16, 18, 20
+		tests := []struct {
+			exerciseID    string
+			attemptNumber int
+			config        points.PointsConfig
+			points        int
+			policy        points.ScoringPolicy
+		}{
+			{"ex1", 1, config, config.CorrectAnswerPoints, points.PolicyStandard},
+			{"ex1", 2, config, 0, points.PolicyFirstCorrect},
+			{"ex2", 3, config, 16 / 3, points.PolicyDiminishing},
+			{"ex3", 1, config, 0, points.PolicyAnswerRevealed},
+			{"ex4", 1, capped, 20 - config.CorrectAnswerPoints - 16/3, points.PolicyLessonCap},
+		}
+
+		for _, tt := range tests {
+			service.SetPointsConfig(tt.config)
+			transaction, err := service.AwardPointsForCorrectAnswer(userID, courseID, lessonID, tt.exerciseID, tt.attemptNumber, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transaction == nil || transaction.Points != tt.points || transaction.ScoringPolicy != tt.policy {
+				t.Errorf("%s attempt %d: expected %d points under %s, got %+v", tt.exerciseID, tt.attemptNumber, tt.points, tt.policy, transaction)
+			}
+		}
+		service.SetPointsConfig(config)
+
+		transactions, err := service.GetRecentTransactions(userID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 1 || transactions[0].ScoringPolicy != points.PolicyLessonCap {
+			t.Errorf("expected the policy to be stored with the transaction, got %+v", transactions)
+		}
+	})
+
+	t.Run("GetLeaderboard", func(t *testing.T) {
+		leaderboard, err := service.GetLeaderboard(points.LeaderboardQuery{Period: points.PeriodDaily, CourseID: courseID, UserID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leaderboard.Since == nil || leaderboard.Me == nil || leaderboard.Me.Points <= 0 || leaderboard.Me.Rank < 1 {
+			t.Errorf("expected the caller to be ranked on today's leaderboard, got %+v", leaderboard)
+		}
+
+		if _, err := service.GetLeaderboard(points.LeaderboardQuery{Period: "yearly", UserID: userID}); err != points.ErrInvalidPeriod {
+			t.Errorf("expected ErrInvalidPeriod, got %v", err)
+		}
+	})
+
+	t.Run("Reconcile", func(t *testing.T) {
+		report, err := service.Reconcile(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if drift := userDrift(report, userID); len(drift) != 0 {
+			t.Errorf("expected the totals to match the ledger, got %v", drift)
+		}
+	})
+}
+
+// userDrift returns the drift in a report that belongs to one user
+func userDrift(report *points.ReconcileReport, userID int) []*points.Drift {
+	var drift []*points.Drift
+	for _, d := range report.Drift {
+		if d.UserID == userID {
+			drift = append(drift, d)
+		}
+	}
+
+	return drift
+}
+
+func TestLeaderboardTies(t *testing.T) {
+	service := points.NewMemoryService()
+	for userID, amount := range map[int]int{1: 50, 2: 50, 3: 30} {
+		if _, err := service.AdjustPoints(userID, amount, "seed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := service.AwardPointsForCorrectAnswer(3, "algorithms", "recursion", "ex1", 1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	leaderboard, err := service.GetLeaderboard(points.LeaderboardQuery{Limit: 2, UserID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaderboard.Entries) != 2 || leaderboard.Entries[0].Rank != 1 || leaderboard.Entries[1].Rank != 1 {
+		t.Errorf("expected two users tied for first, got %+v", leaderboard.Entries)
+	}
+	if leaderboard.Period != points.PeriodAllTime || leaderboard.Since != nil {
+		t.Errorf("expected an all time leaderboard, got %s since %v", leaderboard.Period, leaderboard.Since)
+	}
+	if me := leaderboard.Me; me == nil || me.Rank != 3 || me.Points != 30+points.DefaultPointsConfig.CorrectAnswerPoints {
+		t.Errorf("expected the caller in third outside the top 2, got %+v", me)
+	}
+
+	leaderboard, err = service.GetLeaderboard(points.LeaderboardQuery{UserID: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me := leaderboard.Me; me == nil || me.Rank != 4 || me.Points != 0 {
+		t.Errorf("expected a caller without points to come last, got %+v", me)
+	}
+
+	leaderboard, err = service.GetLeaderboard(points.LeaderboardQuery{CourseID: "algorithms", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaderboard.Entries) != 1 || leaderboard.Entries[0].UserID != 3 || leaderboard.Me.Rank != 2 {
+		t.Errorf("expected only course points to count, got %+v", leaderboard)
+	}
+}
+
+func TestDailyStreakRule(t *testing.T) {
+	service := points.NewMemoryService()
+	config := points.DefaultPointsConfig
+	config.DailyStreakRule = points.StreakRule{MinCorrectAnswers: 2, MinPoints: 15}
+	service.SetPointsConfig(config)
+
+	result, err := service.RecordActivity(1, time.UTC, true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DayCounted {
+		t.Errorf("expected one correct answer worth 10 points not to count, got %+v", result.Activity)
+	}
+
+	result, err = service.RecordActivity(1, time.UTC, true, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DayCounted {
+		t.Fatalf("expected the day to count, got %+v", result.Activity)
+	}
+	if want := "2/2 correct answers and 15/15 points"; result.Activity.Reason != want {
+		t.Errorf("got reason %q, want %q", result.Activity.Reason, want)
+	}
+}
+
+func TestDailyStreakDays(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kiritimati, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Fatal(err)
+	}
+	honolulu, err := time.LoadLocation("Pacific/Honolulu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(month time.Month, day int) time.Time { return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name           string
+		lastActiveDate time.Time
+		now            time.Time
+		loc            *time.Location
+		want           int
+	}{
+		{"next day locally", date(time.May, 15), time.Date(2024, time.May, 16, 10, 0, 0, 0, losAngeles), losAngeles, 1},
+		{"same day locally but not in UTC", date(time.May, 15), time.Date(2024, time.May, 15, 20, 0, 0, 0, losAngeles), losAngeles, 0},
+		{"same day across the date line", date(time.May, 16), time.Date(2024, time.May, 15, 18, 0, 0, 0, losAngeles), kiritimati, 0},
+		{"missed a day", date(time.May, 15), time.Date(2024, time.May, 17, 10, 0, 0, 0, losAngeles), losAngeles, 2},
+		{"across a DST change", date(time.March, 9), time.Date(2024, time.March, 10, 23, 0, 0, 0, losAngeles), losAngeles, 1},
+		// Counted at 01:00 UTC, which was still the day before in Honolulu
+		{"zone changed since the day counted", date(time.October, 17), time.Date(2024, time.October, 17, 11, 0, 0, 0, time.UTC), honolulu, 0},
+	}
+
+	for _, tt := range tests {
+		if got := points.DailyStreakDays(tt.lastActiveDate, tt.now, tt.loc); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLeaderboardPeriodStart(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2024, time.May, 15, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period points.LeaderboardPeriod
+		want   time.Time
+	}{
+		{points.PeriodDaily, time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)},
+		{points.PeriodWeekly, time.Date(2024, time.May, 13, 0, 0, 0, 0, time.UTC)},
+		{points.PeriodMonthly, time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got, ok := tt.period.Start(now); !ok || !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.period, got, tt.want)
+		}
+	}
+
+	if _, ok := points.PeriodAllTime.Start(now); ok {
+		t.Error("expected all time to have no start")
+	}
+}
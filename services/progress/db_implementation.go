@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/tylerolson/capstone-backend/db"
 )
 
 type service struct {
@@ -69,8 +71,8 @@ func (s *service) GetOrCreateCourseProgress(userID int, courseID string) (*Cours
 
 func (s *service) UpdateCourseProgress(userID int, courseID string, status Status) error {
 	query := `
-		INSERT INTO user_course_progress (user_id, course_id, status)
-		VALUES ($1, $2, $3)
+		INSERT INTO user_course_progress (user_id, course_id, status, completed_at)
+		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (user_id, course_id) DO UPDATE
 		SET status = EXCLUDED.status,
 		    completed_at = CASE 
@@ -79,11 +81,50 @@ func (s *service) UpdateCourseProgress(userID int, courseID string, status Statu
 				ELSE user_course_progress.completed_at
 			END`
 
-	_, err := s.db.Exec(query, userID, courseID, status)
+	_, err := s.db.Exec(query, userID, courseID, status, completedAtFor(status))
 	return err
 
 }
 
+func (s *service) ListCourseProgress(userID int) ([]*CourseProgress, error) {
+	query := `
+		SELECT id, user_id, course_id, status, started_at, last_accessed_at, completed_at
+		FROM user_course_progress
+		WHERE user_id = $1
+		ORDER BY course_id`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list course progress: %v", err)
+	}
+	defer rows.Close()
+
+	courses := make([]*CourseProgress, 0)
+	for rows.Next() {
+		progress := &CourseProgress{}
+		err := rows.Scan(
+			&progress.ID,
+			&progress.UserID,
+			&progress.CourseID,
+			&progress.Status,
+			&progress.StartedAt,
+			&progress.LastAccessedAt,
+			&progress.CompletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan course progress: %v", err)
+		}
+
+		courses = append(courses, progress)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating course progress rows: %v", err)
+	}
+
+	return courses, nil
+}
+
 func (s *service) GetOrCreateLessonProgress(userID int, courseID string, lessonID string) (*LessonProgress, error) {
 	progress := &LessonProgress{}
 
@@ -168,8 +209,8 @@ func (s *service) GetLessonProgress(userID int, courseID, lessonID string) (*Les
 
 func (s *service) UpdateLessonProgress(userID int, courseID string, lessonID string, status Status) error {
 	query := `
-		INSERT INTO user_lesson_progress (user_id, course_id, lesson_id, status)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO user_lesson_progress (user_id, course_id, lesson_id, status, completed_at)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (user_id, course_id, lesson_id) DO UPDATE
 		SET status = EXCLUDED.status,
 		    completed_at = CASE 
@@ -178,11 +219,57 @@ func (s *service) UpdateLessonProgress(userID int, courseID string, lessonID str
 				ELSE user_lesson_progress.completed_at
 			END`
 
-	_, err := s.db.Exec(query, userID, courseID, lessonID, status)
+	_, err := s.db.Exec(query, userID, courseID, lessonID, status, completedAtFor(status))
 	return err
 }
 
+func (s *service) ListLessonProgress(userID int) ([]*LessonProgress, error) {
+	query := `
+		SELECT id, user_id, course_id, lesson_id, status, started_at, last_accessed_at, completed_at
+		FROM user_lesson_progress
+		WHERE user_id = $1
+		ORDER BY course_id, lesson_id`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lesson progress: %v", err)
+	}
+	defer rows.Close()
+
+	lessons := make([]*LessonProgress, 0)
+	for rows.Next() {
+		progress := &LessonProgress{}
+		err := rows.Scan(
+			&progress.ID,
+			&progress.UserID,
+			&progress.CourseID,
+			&progress.LessonID,
+			&progress.Status,
+			&progress.StartedAt,
+			&progress.LastAccessedAt,
+			&progress.CompletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lesson progress: %v", err)
+		}
+
+		lessons = append(lessons, progress)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lesson progress rows: %v", err)
+	}
+
+	return lessons, nil
+}
+
 func (s *service) RecordExerciseAttempt(attempt *ExerciseAttempt) error {
+	return InsertExerciseAttempt(s.db, attempt)
+}
+
+// InsertExerciseAttempt stores an attempt as the next attempt at its exercise and fills in its ID,
+// attempt number and time. It can run inside a caller's transaction.
+func InsertExerciseAttempt(q db.Querier, attempt *ExerciseAttempt) error {
 	// Convert answer to JSON string before storing
 	answerJSON, err := json.Marshal(attempt.Answer)
 	if err != nil {
@@ -196,9 +283,9 @@ func (s *service) RecordExerciseAttempt(attempt *ExerciseAttempt) error {
             SELECT COALESCE(MAX(attempt_number), 0) + 1 FROM user_exercise_attempts 
             WHERE user_id = $5 AND course_id = $6 AND lesson_id = $7 AND exercise_id = $8
         ), $9, $10)
-        RETURNING id, attempted_at`
+        RETURNING id, attempt_number, attempted_at`
 
-	return s.db.QueryRow(
+	return q.QueryRow(
 		query,
 		attempt.UserID,
 		attempt.CourseID,
@@ -210,5 +297,15 @@ func (s *service) RecordExerciseAttempt(attempt *ExerciseAttempt) error {
 		attempt.ExerciseID,
 		string(answerJSON), // Store as JSON string
 		attempt.IsCorrect,
-	).Scan(&attempt.ID, &attempt.AttemptedAt)
+	).Scan(&attempt.ID, &attempt.AttemptNumber, &attempt.AttemptedAt)
+}
+
+// completedAtFor is the completed_at value for a progress row inserted with status
+func completedAtFor(status Status) *time.Time {
+	if status != StatusCompleted {
+		return nil
+	}
+
+	now := time.Now()
+	return &now
 }
@@ -51,9 +51,11 @@ type ExerciseAttempt struct {
 type Service interface {
 	GetOrCreateCourseProgress(userID int, courseID string) (*CourseProgress, error)
 	UpdateCourseProgress(userID int, courseID string, status Status) error
+	ListCourseProgress(userID int) ([]*CourseProgress, error)
 
 	GetOrCreateLessonProgress(userID int, courseID string, lessonID string) (*LessonProgress, error)
 	UpdateLessonProgress(userID int, courseID string, lessonID string, status Status) error
+	ListLessonProgress(userID int) ([]*LessonProgress, error)
 
 	RecordExerciseAttempt(attempt *ExerciseAttempt) error
 }
@@ -0,0 +1,195 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type courseKey struct {
+	userID   int
+	courseID string
+}
+
+type lessonKey struct {
+	userID   int
+	courseID string
+	lessonID string
+}
+
+type memoryService struct {
+	mu       sync.RWMutex
+	nextID   int
+	courses  map[courseKey]*CourseProgress
+	lessons  map[lessonKey]*LessonProgress
+	attempts []*ExerciseAttempt
+}
+
+// NewMemoryService creates a progress service that keeps everything in memory, for tests and local development
+func NewMemoryService() Service {
+	return &memoryService{
+		nextID:  1,
+		courses: make(map[courseKey]*CourseProgress),
+		lessons: make(map[lessonKey]*LessonProgress),
+	}
+}
+
+func (s *memoryService) GetOrCreateCourseProgress(userID int, courseID string) (*CourseProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := s.courseProgress(userID, courseID, StatusNotStarted)
+	found := *progress
+	return &found, nil
+}
+
+func (s *memoryService) UpdateCourseProgress(userID int, courseID string, status Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := s.courseProgress(userID, courseID, status)
+	updateStatus(&progress.Progress, status)
+	return nil
+}
+
+func (s *memoryService) ListCourseProgress(userID int) ([]*CourseProgress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	courses := make([]*CourseProgress, 0)
+	for key, progress := range s.courses {
+		if key.userID == userID {
+			found := *progress
+			courses = append(courses, &found)
+		}
+	}
+
+	sort.Slice(courses, func(i, j int) bool {
+		return courses[i].CourseID < courses[j].CourseID
+	})
+
+	return courses, nil
+}
+
+func (s *memoryService) GetOrCreateLessonProgress(userID int, courseID string, lessonID string) (*LessonProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := s.lessonProgress(userID, courseID, lessonID, StatusNotStarted)
+	found := *progress
+	return &found, nil
+}
+
+func (s *memoryService) UpdateLessonProgress(userID int, courseID string, lessonID string, status Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := s.lessonProgress(userID, courseID, lessonID, status)
+	updateStatus(&progress.Progress, status)
+	return nil
+}
+
+func (s *memoryService) ListLessonProgress(userID int) ([]*LessonProgress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lessons := make([]*LessonProgress, 0)
+	for key, progress := range s.lessons {
+		if key.userID == userID {
+			found := *progress
+			lessons = append(lessons, &found)
+		}
+	}
+
+	sort.Slice(lessons, func(i, j int) bool {
+		if lessons[i].CourseID != lessons[j].CourseID {
+			return lessons[i].CourseID < lessons[j].CourseID
+		}
+		return lessons[i].LessonID < lessons[j].LessonID
+	})
+
+	return lessons, nil
+}
+
+func (s *memoryService) RecordExerciseAttempt(attempt *ExerciseAttempt) error {
+	// Match the database implementation, which stores the answer as JSON
+	answerJSON, err := json.Marshal(attempt.Answer)
+	if err != nil {
+		return fmt.Errorf("failed to marshal answer: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attemptNumber := 1
+	for _, a := range s.attempts {
+		if a.UserID == attempt.UserID && a.CourseID == attempt.CourseID && a.LessonID == attempt.LessonID && a.ExerciseID == attempt.ExerciseID {
+			attemptNumber = max(attemptNumber, a.AttemptNumber+1)
+		}
+	}
+
+	attempt.ID = s.nextID
+	attempt.AttemptNumber = attemptNumber
+	attempt.AttemptedAt = time.Now()
+	s.nextID++
+
+	stored := *attempt
+	stored.Answer = string(answerJSON)
+	s.attempts = append(s.attempts, &stored)
+
+	return nil
+}
+
+// courseProgress returns the stored course progress, creating it with status if it is missing.
+// Callers must hold the write lock.
+func (s *memoryService) courseProgress(userID int, courseID string, status Status) *CourseProgress {
+	key := courseKey{userID: userID, courseID: courseID}
+	progress, ok := s.courses[key]
+	if !ok {
+		progress = &CourseProgress{Progress: s.newProgress(userID, courseID, status)}
+		s.courses[key] = progress
+	}
+
+	return progress
+}
+
+// lessonProgress returns the stored lesson progress, creating it with status if it is missing.
+// Callers must hold the write lock.
+func (s *memoryService) lessonProgress(userID int, courseID, lessonID string, status Status) *LessonProgress {
+	key := lessonKey{userID: userID, courseID: courseID, lessonID: lessonID}
+	progress, ok := s.lessons[key]
+	if !ok {
+		progress = &LessonProgress{Progress: s.newProgress(userID, courseID, status), LessonID: lessonID}
+		s.lessons[key] = progress
+	}
+
+	return progress
+}
+
+func (s *memoryService) newProgress(userID int, courseID string, status Status) Progress {
+	now := time.Now()
+	progress := Progress{
+		ID:             s.nextID,
+		UserID:         userID,
+		CourseID:       courseID,
+		Status:         status,
+		StartedAt:      now,
+		LastAccessedAt: now,
+	}
+	s.nextID++
+
+	return progress
+}
+
+// updateStatus mirrors the database upsert: completed_at is set the first time a status becomes completed
+func updateStatus(progress *Progress, status Status) {
+	now := time.Now()
+	if status == StatusCompleted && (progress.Status != StatusCompleted || progress.CompletedAt == nil) {
+		progress.CompletedAt = &now
+	}
+
+	progress.Status = status
+	progress.LastAccessedAt = now
+}
@@ -0,0 +1,141 @@
+package progress_test
+
+import (
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/progress"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestMemoryService(t *testing.T) {
+	testService(t, progress.NewMemoryService(), 1)
+}
+
+func TestPostgresService(t *testing.T) {
+	database := dbtest.Open(t)
+
+	username := dbtest.UniqueName("progressuser")
+	userService := user.NewService(database)
+	testUser, err := userService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { userService.DeleteUser(username) })
+
+	testService(t, progress.NewService(database), testUser.ID)
+}
+
+// testService runs the same checks against every progress.Service implementation
+func testService(t *testing.T, service progress.Service, userID int) {
+	courseID := "testCourse"
+	lessonID := "Lesson1"
+
+	t.Run("GetOrCreateCourseProgress", func(t *testing.T) {
+		courseProgress, err := service.GetOrCreateCourseProgress(userID, courseID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if courseProgress.UserID != userID || courseProgress.CourseID != courseID || courseProgress.StartedAt.IsZero() {
+			t.Errorf("unexpected progress data: %+v", courseProgress)
+		}
+		if courseProgress.Status != progress.StatusNotStarted || courseProgress.CompletedAt != nil {
+			t.Errorf("expected a new course to be not started: %+v", courseProgress)
+		}
+	})
+
+	t.Run("UpdateCourseProgress", func(t *testing.T) {
+		if err := service.UpdateCourseProgress(userID, courseID, progress.StatusCompleted); err != nil {
+			t.Fatal(err)
+		}
+
+		updatedProgress, err := service.GetOrCreateCourseProgress(userID, courseID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if updatedProgress.Status != progress.StatusCompleted || updatedProgress.CompletedAt == nil || updatedProgress.CompletedAt.IsZero() {
+			t.Errorf("unexpected progress update: %+v", updatedProgress)
+		}
+	})
+
+	t.Run("GetOrCreateLessonProgress", func(t *testing.T) {
+		lessonProgress, err := service.GetOrCreateLessonProgress(userID, courseID, lessonID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lessonProgress.UserID != userID || lessonProgress.CourseID != courseID || lessonProgress.LessonID != lessonID || lessonProgress.StartedAt.IsZero() {
+			t.Errorf("unexpected lesson progress data: %+v", lessonProgress)
+		}
+	})
+
+	t.Run("UpdateLessonProgress", func(t *testing.T) {
+		if err := service.UpdateLessonProgress(userID, courseID, lessonID, progress.StatusCompleted); err != nil {
+			t.Fatal(err)
+		}
+
+		updatedLessonProgress, err := service.GetOrCreateLessonProgress(userID, courseID, lessonID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if updatedLessonProgress.Status != progress.StatusCompleted || updatedLessonProgress.CompletedAt == nil || updatedLessonProgress.CompletedAt.IsZero() {
+			t.Errorf("unexpected lesson progress update: %+v", updatedLessonProgress)
+		}
+	})
+
+	t.Run("UpdateLessonProgressWithoutExistingRow", func(t *testing.T) {
+		if err := service.UpdateLessonProgress(userID, courseID, "Lesson2", progress.StatusCompleted); err != nil {
+			t.Fatal(err)
+		}
+
+		lessonProgress, err := service.GetOrCreateLessonProgress(userID, courseID, "Lesson2")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lessonProgress.Status != progress.StatusCompleted || lessonProgress.CompletedAt == nil {
+			t.Errorf("expected completed_at to be set: %+v", lessonProgress)
+		}
+	})
+
+	t.Run("ListProgress", func(t *testing.T) {
+		courses, err := service.ListCourseProgress(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(courses) != 1 || courses[0].CourseID != courseID {
+			t.Errorf("unexpected course progress list: %+v", courses)
+		}
+
+		lessons, err := service.ListLessonProgress(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lessons) != 2 || lessons[0].LessonID != lessonID || lessons[1].LessonID != "Lesson2" {
+			t.Errorf("unexpected lesson progress list: %+v", lessons)
+		}
+	})
+
+	t.Run("RecordExerciseAttempt", func(t *testing.T) {
+		for i := 1; i <= 2; i++ {
+			attempt := &progress.ExerciseAttempt{
+				UserID:     userID,
+				CourseID:   courseID,
+				LessonID:   lessonID,
+				ExerciseID: "ex1",
+				Answer:     "B",
+				IsCorrect:  i == 2,
+			}
+			if err := service.RecordExerciseAttempt(attempt); err != nil {
+				t.Fatal(err)
+			}
+
+			if attempt.AttemptNumber != i {
+				t.Errorf("expected attempt number %d, got %d", i, attempt.AttemptNumber)
+			}
+		}
+	})
+}
@@ -1,11 +1,13 @@
 package session
 
 import (
-	"crypto/rand"
+	"context"
 	"database/sql"
-	"encoding/base64"
 	"fmt"
 	"time"
+
+	"github.com/tylerolson/capstone-backend/db"
+	"github.com/tylerolson/capstone-backend/tokenhash"
 )
 
 type service struct {
@@ -16,57 +18,324 @@ func NewService(database *sql.DB) Service {
 	return &service{db: database}
 }
 
-func (s *service) CreateSession(userID int) (*Session, error) {
-	bytes := make([]byte, TokenLength)
-	if _, err := rand.Read(bytes); err != nil {
+func (s *service) CreateSession(userID int, client ClientInfo) (*Session, error) {
+	familyID, err := newToken()
+	if err != nil {
 		return nil, err
 	}
 
-	token := base64.URLEncoding.EncodeToString(bytes)
-	expiresAt := time.Now().Add(TokenLifetime)
+	return createSession(s.db, userID, familyID, client)
+}
 
+// createSession issues an access token and a refresh token in a family
+func createSession(q db.Querier, userID int, familyID string, client ClientInfo) (*Session, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 	session := &Session{
-		UserID:    userID,
-		Token:     token,
-		ExpiresAt: expiresAt,
+		UserID:           userID,
+		Token:            token,
+		ExpiresAt:        now.Add(AccessTokenLifetime),
+		FamilyID:         familyID,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: now.Add(RefreshTokenLifetime),
 	}
 
 	query := `
-		INSERT INTO sessions (user_id, token, expires_at)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at`
+		INSERT INTO sessions (user_id, token_hash, expires_at, family_id, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, last_seen_at`
 
-	err := s.db.QueryRow(query, userID, token, session.ExpiresAt).Scan(&session.ID, &session.CreatedAt)
+	err = q.QueryRow(query, userID, tokenhash.Hash(token), session.ExpiresAt, familyID, client.UserAgent, client.IPAddress).
+		Scan(&session.ID, &session.CreatedAt, &session.LastSeenAt)
 	if err != nil {
 		return nil, err
 	}
 
+	_, err = q.Exec(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		userID, familyID, tokenhash.Hash(refreshToken), session.RefreshExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %v", err)
+	}
+
 	return session, nil
 }
 
+// Only a hash of the token is stored, so sessions read back don't have one
+const sessionColumns = `id, user_id, expires_at, created_at, COALESCE(family_id, ''), user_agent, ip_address, last_seen_at`
+
+func scanSession(row interface{ Scan(...any) error }, session *Session) error {
+	return row.Scan(&session.ID, &session.UserID, &session.ExpiresAt, &session.CreatedAt, &session.FamilyID,
+		&session.UserAgent, &session.IPAddress, &session.LastSeenAt)
+}
+
 func (s *service) GetSessionByToken(token string) (*Session, error) {
 	session := &Session{}
 	query := `
-		SELECT id, user_id, token, expires_at, created_at
+		SELECT ` + sessionColumns + `
 		FROM sessions
-		WHERE token = $1`
+		WHERE token_hash = $1`
 
-	err := s.db.QueryRow(query, token).Scan(&session.ID, &session.UserID, &session.Token, &session.ExpiresAt, &session.CreatedAt)
+	err := scanSession(s.db.QueryRow(query, tokenhash.Hash(token)), session)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("session not found")
+			return nil, ErrSessionNotFound
 		}
 
 		return nil, err
 	}
 
+	session.Token = token
 	return session, nil
 }
 
 func (s *service) DeleteSession(token string) error {
-	query := `DELETE FROM sessions WHERE token = $1`
-	_, err := s.db.Exec(query, token)
+	query := `
+		WITH deleted AS (
+			DELETE FROM sessions WHERE token_hash = $1 RETURNING family_id
+		)
+		DELETE FROM refresh_tokens WHERE family_id IN (SELECT family_id FROM deleted)`
+	_, err := s.db.Exec(query, tokenhash.Hash(token))
 
 	return err
 }
+
+func (s *service) Refresh(refreshToken string, client ClientInfo) (*Session, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		userID    int
+		familyID  string
+		expiresAt time.Time
+		usedAt    sql.NullTime
+	)
+	err = tx.QueryRow(`
+		SELECT user_id, family_id, expires_at, used_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE`,
+		tokenhash.Hash(refreshToken)).Scan(&userID, &familyID, &expiresAt, &usedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %v", err)
+	}
+
+	if usedAt.Valid {
+		if err := revokeFamily(tx, familyID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %v", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(expiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The used token is kept until it expires so that using it again can be caught
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE token_hash = $1`, tokenhash.Hash(refreshToken)); err != nil {
+		return nil, fmt.Errorf("failed to use refresh token: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE family_id = $1`, familyID); err != nil {
+		return nil, fmt.Errorf("failed to end old session: %v", err)
+	}
+
+	session, err := createSession(tx, userID, familyID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return session, nil
+}
+
+// revokeFamily signs out every session and refresh token from one sign in
+func revokeFamily(q db.Querier, familyID string) error {
+	if _, err := q.Exec(`DELETE FROM sessions WHERE family_id = $1`, familyID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+	if _, err := q.Exec(`DELETE FROM refresh_tokens WHERE family_id = $1`, familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %v", err)
+	}
+
+	return nil
+}
+
+func (s *service) ExtendSession(token string, expiresAt time.Time) error {
+	result, err := s.db.Exec(`UPDATE sessions SET expires_at = $2 WHERE token_hash = $1`, tokenhash.Hash(token), expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to extend session: %v", err)
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *service) TouchSession(token string) error {
+	if _, err := s.db.Exec(`UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP WHERE token_hash = $1`, tokenhash.Hash(token)); err != nil {
+		return fmt.Errorf("failed to touch session: %v", err)
+	}
+
+	return nil
+}
+
+func (s *service) ListSessions(userID int) ([]*Session, error) {
+	rows, err := s.db.Query(`
+		SELECT `+sessionColumns+`
+		FROM sessions s
+		WHERE user_id = $1
+			AND (expires_at > CURRENT_TIMESTAMP OR EXISTS (
+				SELECT 1 FROM refresh_tokens r
+				WHERE r.family_id = s.family_id AND r.used_at IS NULL AND r.expires_at > CURRENT_TIMESTAMP
+			))
+		ORDER BY last_seen_at DESC, id DESC`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*Session, 0)
+	for rows.Next() {
+		session := &Session{}
+		if err := scanSession(rows, session); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s *service) DeleteUserSession(userID, sessionID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var familyID string
+	err = tx.QueryRow(`
+		DELETE FROM sessions
+		WHERE id = $1 AND user_id = $2
+		RETURNING COALESCE(family_id, '')`,
+		sessionID, userID).Scan(&familyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to delete session: %v", err)
+	}
+
+	if familyID != "" {
+		if err := revokeFamily(tx, familyID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *service) DeleteUserSessions(userID int, keepToken string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Sessions without a family are older than refresh tokens, so there's nothing else to keep
+	var keepFamily string
+	if keepToken != "" {
+		err := tx.QueryRow(`SELECT COALESCE(family_id, '') FROM sessions WHERE token_hash = $1 AND user_id = $2`, tokenhash.Hash(keepToken), userID).Scan(&keepFamily)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, fmt.Errorf("failed to get session: %v", err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM sessions WHERE user_id = $1 AND token_hash <> $2`, userID, tokenhash.Hash(keepToken))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted sessions: %v", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM refresh_tokens WHERE user_id = $1 AND family_id <> $2`, userID, keepFamily); err != nil {
+		return 0, fmt.Errorf("failed to delete refresh tokens: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return int(deleted), nil
+}
+
+func (s *service) PurgeExpired(ctx context.Context) (int, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to purge refresh tokens: %v", err)
+	}
+	refreshTokens, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count purged refresh tokens: %v", err)
+	}
+
+	// The refresh tokens left haven't expired, so an unused one can still renew its session
+	result, err = tx.ExecContext(ctx, `
+		DELETE FROM sessions s
+		WHERE expires_at <= CURRENT_TIMESTAMP
+			AND NOT EXISTS (
+				SELECT 1 FROM refresh_tokens r
+				WHERE r.family_id = s.family_id AND r.used_at IS NULL
+			)`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to purge sessions: %v", err)
+	}
+	sessions, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count purged sessions: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return int(sessions), int(refreshTokens), nil
+}
@@ -1,12 +1,31 @@
 package session
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"time"
 )
 
 const (
-	TokenLength   = 32
-	TokenLifetime = 24 * time.Hour
+	TokenLength = 32
+	// AccessTokenLifetime is how long a session's token is accepted. Clients swap their refresh token
+	// for a new one before then, or the server can slide the expiry forward while the session is in use.
+	AccessTokenLifetime = 15 * time.Minute
+	// RefreshTokenLifetime is how long a refresh token can be used. Every refresh replaces it, so a user
+	// who comes back within this long stays signed in.
+	RefreshTokenLifetime = 30 * 24 * time.Hour
+	// LastSeenInterval is how stale a session's last seen time gets before a request updates it
+	LastSeenInterval = time.Minute
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused means a refresh token that was already swapped was used again, so it may
+	// have been stolen. Every session from the same sign in has been revoked.
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
 )
 
 type Session struct {
@@ -15,10 +34,58 @@ type Session struct {
 	Token     string    `json:"token"`
 	ExpiresAt time.Time `json:"expiresAt"`
 	CreatedAt time.Time `json:"createdAt"`
+	// FamilyID is shared by every session refreshed from the same sign in
+	FamilyID   string    `json:"-"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+
+	// The refresh token is only handed out by CreateSession and Refresh
+	RefreshToken     string    `json:"refreshToken,omitempty"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// ClientInfo is the device a session was started or refreshed from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
 }
 
 type Service interface {
-	CreateSession(userID int) (*Session, error)
+	// CreateSession signs a user in, starting a new family with an access token and a refresh token
+	CreateSession(userID int, client ClientInfo) (*Session, error)
 	GetSessionByToken(token string) (*Session, error)
+	// DeleteSession signs a session out, along with the refresh token that would renew it
 	DeleteSession(token string) error
+
+	// Refresh swaps a refresh token for a new session and refresh token in the same family, ending the
+	// old session. Using a refresh token twice revokes the whole family and returns ErrRefreshTokenReused.
+	Refresh(refreshToken string, client ClientInfo) (*Session, error)
+	// ExtendSession moves a session's expiry, for sliding expiry
+	ExtendSession(token string, expiresAt time.Time) error
+	// TouchSession records that a session was just used
+	TouchSession(token string) error
+
+	// ListSessions returns a user's signed in devices, the most recently used first: sessions that haven't
+	// expired or can still be refreshed. Their tokens are left out.
+	ListSessions(userID int) ([]*Session, error)
+	// DeleteUserSession signs out one of a user's sessions by ID, failing with ErrSessionNotFound if it isn't theirs
+	DeleteUserSession(userID, sessionID int) error
+	// DeleteUserSessions signs a user out everywhere except the session with keepToken, if given,
+	// and returns how many sessions were ended
+	DeleteUserSessions(userID int, keepToken string) (int, error)
+
+	// PurgeExpired deletes expired refresh tokens, then sessions that have expired and can't be refreshed,
+	// returning how many of each were deleted
+	PurgeExpired(ctx context.Context) (sessions int, refreshTokens int, err error)
+}
+
+// newToken returns a random, URL safe token
+func newToken() (string, error) {
+	bytes := make([]byte, TokenLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(bytes), nil
 }
@@ -0,0 +1,274 @@
+package session
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/tokenhash"
+)
+
+type memoryRefreshToken struct {
+	userID    int
+	familyID  string
+	expiresAt time.Time
+	used      bool
+}
+
+type memoryService struct {
+	mu            sync.RWMutex
+	nextID        int
+	sessions      map[string]*Session            // keyed by token hash
+	refreshTokens map[string]*memoryRefreshToken // keyed by token hash
+}
+
+// NewMemoryService creates a session service that keeps everything in memory, for tests and local development
+func NewMemoryService() Service {
+	return &memoryService{
+		nextID:        1,
+		sessions:      make(map[string]*Session),
+		refreshTokens: make(map[string]*memoryRefreshToken),
+	}
+}
+
+func (s *memoryService) CreateSession(userID int, client ClientInfo) (*Session, error) {
+	familyID, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createSession(userID, familyID, client)
+}
+
+// createSession issues an access token and a refresh token in a family. The caller holds the lock.
+func (s *memoryService) createSession(userID int, familyID string, client ClientInfo) (*Session, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         s.nextID,
+		UserID:     userID,
+		ExpiresAt:  now.Add(AccessTokenLifetime),
+		CreatedAt:  now,
+		FamilyID:   familyID,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastSeenAt: now,
+	}
+	s.nextID++
+	s.sessions[tokenhash.Hash(token)] = session
+	refresh := &memoryRefreshToken{
+		userID:    userID,
+		familyID:  familyID,
+		expiresAt: now.Add(RefreshTokenLifetime),
+	}
+
+	s.refreshTokens[tokenhash.Hash(refreshToken)] = refresh
+
+	created := *session
+	created.Token = token
+	created.RefreshToken = refreshToken
+	created.RefreshExpiresAt = refresh.expiresAt
+	return &created, nil
+}
+
+func (s *memoryService) GetSessionByToken(token string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[tokenhash.Hash(token)]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	found := *session
+	found.Token = token
+	return &found, nil
+}
+
+func (s *memoryService) DeleteSession(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[tokenhash.Hash(token)]
+	if !ok {
+		return nil
+	}
+
+	s.revokeFamily(session.FamilyID)
+	return nil
+}
+
+func (s *memoryService) Refresh(refreshToken string, client ClientInfo) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refresh, ok := s.refreshTokens[tokenhash.Hash(refreshToken)]
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if refresh.used {
+		s.revokeFamily(refresh.familyID)
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(refresh.expiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The used token is kept until it expires so that using it again can be caught
+	refresh.used = true
+	for hash, session := range s.sessions {
+		if session.FamilyID == refresh.familyID {
+			delete(s.sessions, hash)
+		}
+	}
+
+	return s.createSession(refresh.userID, refresh.familyID, client)
+}
+
+// revokeFamily signs out every session and refresh token from one sign in. The caller holds the lock.
+func (s *memoryService) revokeFamily(familyID string) {
+	for hash, session := range s.sessions {
+		if session.FamilyID == familyID {
+			delete(s.sessions, hash)
+		}
+	}
+	for hash, refresh := range s.refreshTokens {
+		if refresh.familyID == familyID {
+			delete(s.refreshTokens, hash)
+		}
+	}
+}
+
+func (s *memoryService) ExtendSession(token string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[tokenhash.Hash(token)]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+func (s *memoryService) TouchSession(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[tokenhash.Hash(token)]; ok {
+		session.LastSeenAt = time.Now()
+	}
+	return nil
+}
+
+func (s *memoryService) ListSessions(userID int) ([]*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	refreshable := make(map[string]bool)
+	for _, refresh := range s.refreshTokens {
+		if !refresh.used && now.Before(refresh.expiresAt) {
+			refreshable[refresh.familyID] = true
+		}
+	}
+
+	sessions := make([]*Session, 0)
+	for _, session := range s.sessions {
+		if session.UserID != userID || (!now.Before(session.ExpiresAt) && !refreshable[session.FamilyID]) {
+			continue
+		}
+		listed := *session
+		sessions = append(sessions, &listed)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+func (s *memoryService) DeleteUserSession(userID, sessionID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if session.ID == sessionID && session.UserID == userID {
+			s.revokeFamily(session.FamilyID)
+			return nil
+		}
+	}
+
+	return ErrSessionNotFound
+}
+
+func (s *memoryService) DeleteUserSessions(userID int, keepToken string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keepHash := tokenhash.Hash(keepToken)
+	var keepFamily string
+	if kept, ok := s.sessions[keepHash]; ok && kept.UserID == userID {
+		keepFamily = kept.FamilyID
+	}
+
+	deleted := 0
+	for hash, session := range s.sessions {
+		if session.UserID == userID && hash != keepHash {
+			delete(s.sessions, hash)
+			deleted++
+		}
+	}
+	for hash, refresh := range s.refreshTokens {
+		if refresh.userID == userID && refresh.familyID != keepFamily {
+			delete(s.refreshTokens, hash)
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *memoryService) PurgeExpired(ctx context.Context) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	refreshTokens := 0
+	refreshable := make(map[string]bool)
+	for hash, refresh := range s.refreshTokens {
+		if !now.Before(refresh.expiresAt) {
+			delete(s.refreshTokens, hash)
+			refreshTokens++
+		} else if !refresh.used {
+			refreshable[refresh.familyID] = true
+		}
+	}
+
+	sessions := 0
+	for hash, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) && !refreshable[session.FamilyID] {
+			delete(s.sessions, hash)
+			sessions++
+		}
+	}
+
+	return sessions, refreshTokens, nil
+}
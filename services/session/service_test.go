@@ -0,0 +1,282 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/session"
+	"github.com/tylerolson/capstone-backend/services/user"
+	"github.com/tylerolson/capstone-backend/tokenhash"
+)
+
+func TestMemoryService(t *testing.T) {
+	testService(t, session.NewMemoryService(), 1)
+}
+
+func TestPostgresService(t *testing.T) {
+	database := dbtest.Open(t)
+
+	username := dbtest.UniqueName("sessionuser")
+	userService := user.NewService(database)
+	testUser, err := userService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { userService.DeleteUser(username) })
+
+	service := session.NewService(database)
+
+	t.Run("Tokens Are Hashed", func(t *testing.T) {
+		created, err := service.CreateSession(testUser.ID, session.ClientInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stored int
+		err = database.QueryRow(`
+			SELECT (SELECT COUNT(*) FROM sessions WHERE token_hash = $1) + (SELECT COUNT(*) FROM refresh_tokens WHERE token_hash = $2)`,
+			created.Token, created.RefreshToken).Scan(&stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored != 0 {
+			t.Error("expected only hashes of the tokens to be stored")
+		}
+
+		if err := service.DeleteSession(created.Token); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testService(t, service, testUser.ID)
+
+	t.Run("Purge Expired Families", func(t *testing.T) {
+		created, err := service.CreateSession(testUser.ID, session.ClientInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = database.Exec(`UPDATE sessions SET expires_at = CURRENT_TIMESTAMP - INTERVAL '1 minute' WHERE token_hash = $1`, tokenhash.Hash(created.Token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = database.Exec(`UPDATE refresh_tokens SET expires_at = CURRENT_TIMESTAMP - INTERVAL '1 minute' WHERE token_hash = $1`, tokenhash.Hash(created.RefreshToken))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sessions, refreshTokens, err := service.PurgeExpired(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sessions < 1 || refreshTokens < 1 {
+			t.Errorf("expected the expired session and refresh token to be purged, got %d and %d", sessions, refreshTokens)
+		}
+		if _, err := service.GetSessionByToken(created.Token); !errors.Is(err, session.ErrSessionNotFound) {
+			t.Errorf("expected the session to be gone, got %v", err)
+		}
+	})
+}
+
+// testService runs the same checks against every session.Service implementation
+func testService(t *testing.T, service session.Service, userID int) {
+	tokenhash.SetKey([]byte("test token key"))
+
+	created, err := service.CreateSession(userID, session.ClientInfo{UserAgent: "Firefox", IPAddress: "203.0.113.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.UserID != userID || created.Token == "" || created.ID == 0 || created.LastSeenAt.IsZero() {
+		t.Errorf("unexpected session data: %+v", created)
+	}
+	if !created.ExpiresAt.After(time.Now()) {
+		t.Errorf("expected session to expire in the future, got %v", created.ExpiresAt)
+	}
+
+	other, err := service.CreateSession(userID, session.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Token == created.Token {
+		t.Error("expected sessions to get distinct tokens")
+	}
+
+	found, err := service.GetSessionByToken(created.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != created.ID || found.UserID != userID || found.UserAgent != "Firefox" || found.IPAddress != "203.0.113.7" {
+		t.Errorf("unexpected session data: %+v", found)
+	}
+
+	if err := service.DeleteSession(created.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.GetSessionByToken(created.Token); err == nil {
+		t.Error("expected deleted session to be gone")
+	}
+
+	if _, err := service.GetSessionByToken(other.Token); err != nil {
+		t.Errorf("expected other session to survive, got %v", err)
+	}
+
+	t.Run("Refresh", func(t *testing.T) {
+		first, err := service.CreateSession(userID, session.ClientInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.RefreshToken == "" || !first.RefreshExpiresAt.After(first.ExpiresAt) {
+			t.Fatalf("expected a refresh token that outlives the access token, got %+v", first)
+		}
+
+		second, err := service.Refresh(first.RefreshToken, session.ClientInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second.Token == first.Token || second.RefreshToken == first.RefreshToken || second.UserID != userID {
+			t.Errorf("expected both tokens to be replaced, got %+v", second)
+		}
+		if _, err := service.GetSessionByToken(first.Token); !errors.Is(err, session.ErrSessionNotFound) {
+			t.Errorf("expected the old access token to stop working, got %v", err)
+		}
+		if _, err := service.GetSessionByToken(second.Token); err != nil {
+			t.Errorf("expected the new access token to work, got %v", err)
+		}
+
+		if _, err := service.Refresh("not-a-token", session.ClientInfo{}); !errors.Is(err, session.ErrInvalidRefreshToken) {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+
+		// Replaying the first refresh token looks like theft, so the whole family goes
+		if _, err := service.Refresh(first.RefreshToken, session.ClientInfo{}); !errors.Is(err, session.ErrRefreshTokenReused) {
+			t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+		}
+		if _, err := service.GetSessionByToken(second.Token); !errors.Is(err, session.ErrSessionNotFound) {
+			t.Errorf("expected the family's session to be revoked, got %v", err)
+		}
+		if _, err := service.Refresh(second.RefreshToken, session.ClientInfo{}); err == nil {
+			t.Error("expected the family's refresh token to be revoked")
+		}
+
+		if _, err := service.GetSessionByToken(other.Token); err != nil {
+			t.Errorf("expected sessions from other sign ins to survive, got %v", err)
+		}
+	})
+
+	t.Run("Logout Ends Refreshing", func(t *testing.T) {
+		created, err := service.CreateSession(userID, session.ClientInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := service.DeleteSession(created.Token); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Refresh(created.RefreshToken, session.ClientInfo{}); !errors.Is(err, session.ErrInvalidRefreshToken) {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("Extend", func(t *testing.T) {
+		until := time.Now().Add(time.Hour).Truncate(time.Second)
+		if err := service.ExtendSession(other.Token, until); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := service.GetSessionByToken(other.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found.ExpiresAt.Equal(until) {
+			t.Errorf("expected the session to expire at %v, got %v", until, found.ExpiresAt)
+		}
+
+		if err := service.ExtendSession("missing", until); !errors.Is(err, session.ErrSessionNotFound) {
+			t.Errorf("expected ErrSessionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Devices", func(t *testing.T) {
+		laptop, err := service.CreateSession(userID, session.ClientInfo{UserAgent: "Laptop"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		phone, err := service.CreateSession(userID, session.ClientInfo{UserAgent: "Phone"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		if err := service.TouchSession(laptop.Token); err != nil {
+			t.Fatal(err)
+		}
+
+		sessions, err := service.ListSessions(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sessions) != 3 || sessions[0].ID != laptop.ID {
+			t.Fatalf("expected three sessions with the laptop used most recently, got %+v", sessions)
+		}
+		for _, listed := range sessions {
+			if listed.Token != "" {
+				t.Errorf("expected listed sessions to leave out their token, got %+v", listed)
+			}
+		}
+
+		if err := service.DeleteUserSession(userID+1, phone.ID); !errors.Is(err, session.ErrSessionNotFound) {
+			t.Errorf("expected other users' sessions to be out of reach, got %v", err)
+		}
+		if err := service.DeleteUserSession(userID, phone.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Refresh(phone.RefreshToken, session.ClientInfo{}); !errors.Is(err, session.ErrInvalidRefreshToken) {
+			t.Errorf("expected the deleted session's refresh token to go too, got %v", err)
+		}
+
+		ended, err := service.DeleteUserSessions(userID, laptop.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ended != 1 {
+			t.Errorf("expected one other session to end, got %d", ended)
+		}
+		if _, err := service.GetSessionByToken(other.Token); !errors.Is(err, session.ErrSessionNotFound) {
+			t.Errorf("expected the other session to be signed out, got %v", err)
+		}
+		if _, err := service.Refresh(laptop.RefreshToken, session.ClientInfo{}); err != nil {
+			t.Errorf("expected the kept session to still refresh, got %v", err)
+		}
+
+		if _, err := service.DeleteUserSessions(userID, ""); err != nil {
+			t.Fatal(err)
+		}
+		if sessions, err := service.ListSessions(userID); err != nil || len(sessions) != 0 {
+			t.Errorf("expected no sessions left, got %+v, %v", sessions, err)
+		}
+	})
+
+	t.Run("Purge Expired", func(t *testing.T) {
+		created, err := service.CreateSession(userID, session.ClientInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := service.ExtendSession(created.Token, time.Now().Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := service.PurgeExpired(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.GetSessionByToken(created.Token); err != nil {
+			t.Errorf("expected an expired session that can still be refreshed to be kept, got %v", err)
+		}
+		if _, err := service.Refresh(created.RefreshToken, session.ClientInfo{}); err != nil {
+			t.Errorf("expected the refresh token to be kept, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,353 @@
+package shop
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/tylerolson/capstone-backend/db"
+	"github.com/tylerolson/capstone-backend/services/points"
+)
+
+const uniqueViolation = "23505"
+
+type service struct {
+	db     *sql.DB
+	points points.Service
+}
+
+// NewService creates a shop that charges with the config of pointsService
+func NewService(database *sql.DB, pointsService points.Service) Service {
+	return &service{db: database, points: pointsService}
+}
+
+const itemColumns = `id, kind, name, description, price, profile_pic_id, active, created_at, updated_at`
+
+func scanItem(row interface{ Scan(...any) error }) (*Item, error) {
+	item := &Item{}
+	err := row.Scan(&item.ID, &item.Kind, &item.Name, &item.Description, &item.Price, &item.ProfilePicID,
+		&item.Active, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (s *service) ListItems(includeInactive bool) ([]*Item, error) {
+	rows, err := s.db.Query(`
+		SELECT `+itemColumns+`
+		FROM shop_items
+		WHERE active OR $1
+		ORDER BY price, id`,
+		includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shop items: %v", err)
+	}
+	defer rows.Close()
+
+	items := make([]*Item, 0)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shop item: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *service) GetItem(itemID string) (*Item, error) {
+	return getItem(s.db, itemID, "")
+}
+
+// getItem reads an item, locking it with lock ("FOR SHARE" or "FOR UPDATE") when given
+func getItem(q db.Querier, itemID string, lock string) (*Item, error) {
+	item, err := scanItem(q.QueryRow(`
+		SELECT `+itemColumns+`
+		FROM shop_items
+		WHERE id = $1 `+lock,
+		itemID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get shop item: %v", err)
+	}
+
+	return item, nil
+}
+
+func (s *service) CreateItem(item *Item) (*Item, error) {
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := scanItem(s.db.QueryRow(`
+		INSERT INTO shop_items (id, kind, name, description, price, profile_pic_id, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+itemColumns,
+		item.ID, item.Kind, item.Name, item.Description, item.Price, item.ProfilePicID, item.Active))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrItemExists
+		}
+		return nil, fmt.Errorf("failed to create shop item: %v", err)
+	}
+
+	return created, nil
+}
+
+func (s *service) UpdateItem(item *Item) (*Item, error) {
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanItem(s.db.QueryRow(`
+		UPDATE shop_items
+		SET kind = $2,
+			name = $3,
+			description = $4,
+			price = $5,
+			profile_pic_id = $6,
+			active = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+itemColumns,
+		item.ID, item.Kind, item.Name, item.Description, item.Price, item.ProfilePicID, item.Active))
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return nil, ErrItemNotFound
+		case isUniqueViolation(err):
+			return nil, ErrItemExists
+		}
+		return nil, fmt.Errorf("failed to update shop item: %v", err)
+	}
+
+	return updated, nil
+}
+
+func (s *service) DeleteItem(itemID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the item keeps it from being bought while checking whether it has been
+	if _, err := getItem(tx, itemID, "FOR UPDATE"); err != nil {
+		return err
+	}
+
+	var bought bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM user_inventory WHERE item_id = $1)`, itemID).Scan(&bought)
+	if err != nil {
+		return fmt.Errorf("failed to check shop item inventory: %v", err)
+	}
+	if bought {
+		return ErrItemInUse
+	}
+
+	if _, err := tx.Exec(`DELETE FROM shop_items WHERE id = $1`, itemID); err != nil {
+		return fmt.Errorf("failed to delete shop item: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *service) Purchase(userID int, itemID string) (*Purchase, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	item, err := getItem(tx, itemID, "FOR SHARE")
+	if err != nil {
+		return nil, err
+	}
+	if !item.Active {
+		return nil, ErrNotForSale
+	}
+
+	purchase := &Purchase{Item: item}
+	if item.Kind == KindStreakFreeze {
+		purchase.Transaction, purchase.Quantity, err = points.BuyStreakFreeze(tx, s.points.GetPointsConfig(), userID, item.Price)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if !item.Kind.Stackable() {
+			if owned, err := owns(tx, userID, item.ID); err != nil {
+				return nil, err
+			} else if owned {
+				return nil, ErrAlreadyOwned
+			}
+		}
+
+		purchase.Transaction = &points.PointTransaction{
+			TransactionType: points.TransactionTypeShopPurchase,
+			Points:          -item.Price,
+			Description:     purchaseDescription(item),
+		}
+		// SpendPoints locks the user's row first, so their purchases happen one at a time
+		if err := points.SpendPoints(tx, userID, purchase.Transaction); err != nil {
+			return nil, err
+		}
+
+		if purchase.Quantity, err = addToInventory(tx, userID, item); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return purchase, nil
+}
+
+// addToInventory adds one of an item to a user's inventory and returns how many they now have
+func addToInventory(q db.Querier, userID int, item *Item) (int, error) {
+	var quantity int
+	err := q.QueryRow(`
+		INSERT INTO user_inventory (user_id, item_id, quantity)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (user_id, item_id) DO UPDATE
+		SET quantity = user_inventory.quantity + 1,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING quantity`,
+		userID, item.ID).Scan(&quantity)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add to inventory: %v", err)
+	}
+
+	// Checked again here in case a concurrent purchase got in first
+	if quantity > 1 && !item.Kind.Stackable() {
+		return 0, ErrAlreadyOwned
+	}
+
+	return quantity, nil
+}
+
+// owns reports whether a user has bought an item
+func owns(q db.Querier, userID int, itemID string) (bool, error) {
+	var owned bool
+	err := q.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM user_inventory WHERE user_id = $1 AND item_id = $2 AND quantity > 0)`,
+		userID, itemID).Scan(&owned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check inventory: %v", err)
+	}
+
+	return owned, nil
+}
+
+func (s *service) Inventory(userID int) ([]*InventoryItem, error) {
+	rows, err := s.db.Query(`
+		SELECT i.quantity, i.acquired_at,
+			s.id, s.kind, s.name, s.description, s.price, s.profile_pic_id, s.active, s.created_at, s.updated_at
+		FROM user_inventory i
+		JOIN shop_items s ON s.id = i.item_id
+		WHERE i.user_id = $1 AND i.quantity > 0
+		ORDER BY i.acquired_at, s.id`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory: %v", err)
+	}
+	defer rows.Close()
+
+	inventory := make([]*InventoryItem, 0)
+	for rows.Next() {
+		owned := &InventoryItem{Item: &Item{}}
+		item := owned.Item
+		err := rows.Scan(&owned.Quantity, &owned.AcquiredAt, &item.ID, &item.Kind, &item.Name, &item.Description,
+			&item.Price, &item.ProfilePicID, &item.Active, &item.CreatedAt, &item.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory: %v", err)
+		}
+		inventory = append(inventory, owned)
+	}
+
+	return inventory, rows.Err()
+}
+
+func (s *service) UseHintToken(userID int) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Any item of the kind will do, the oldest one bought is used up first
+	var itemID string
+	err = tx.QueryRow(`
+		SELECT i.item_id
+		FROM user_inventory i
+		JOIN shop_items s ON s.id = i.item_id
+		WHERE i.user_id = $1 AND s.kind = $2 AND i.quantity > 0
+		ORDER BY i.acquired_at, i.item_id
+		LIMIT 1
+		FOR UPDATE OF i`,
+		userID, KindHintToken).Scan(&itemID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNoHintTokens
+		}
+		return 0, fmt.Errorf("failed to find hint token: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_inventory
+		SET quantity = quantity - 1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND item_id = $2`,
+		userID, itemID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to use hint token: %v", err)
+	}
+
+	var left int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(i.quantity), 0)
+		FROM user_inventory i
+		JOIN shop_items s ON s.id = i.item_id
+		WHERE i.user_id = $1 AND s.kind = $2`,
+		userID, KindHintToken).Scan(&left)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count hint tokens: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return left, nil
+}
+
+func (s *service) CanUseProfilePic(userID int, profilePicID string) (bool, error) {
+	var sold, owned bool
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) > 0, COUNT(i.user_id) > 0
+		FROM shop_items s
+		LEFT JOIN user_inventory i ON i.item_id = s.id AND i.user_id = $1 AND i.quantity > 0
+		WHERE s.kind = $2 AND s.profile_pic_id = $3`,
+		userID, KindAvatar, profilePicID).Scan(&sold, &owned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check profile picture: %v", err)
+	}
+
+	return !sold || owned, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,127 @@
+package shop
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+)
+
+var (
+	ErrItemNotFound = errors.New("item not found")
+	ErrItemExists   = errors.New("an item with this ID already exists")
+	ErrInvalidItem  = errors.New("invalid item")
+	ErrNotForSale   = errors.New("item is not for sale")
+	ErrAlreadyOwned = errors.New("item already owned")
+	ErrItemInUse    = errors.New("item has been bought and can only be taken off sale")
+	ErrNoHintTokens = errors.New("no hint tokens left")
+)
+
+// Kind decides what buying an item does
+type Kind string
+
+const (
+	KindFrame        Kind = "frame"
+	KindAvatar       Kind = "avatar" // Unlocks the profile picture in ProfilePicID
+	KindStreakFreeze Kind = "streak_freeze"
+	KindHintToken    Kind = "hint_token" // Redeemed for a hint on an exercise, see UseHintToken
+)
+
+// Valid reports whether k is one of the known kinds
+func (k Kind) Valid() bool {
+	switch k {
+	case KindFrame, KindAvatar, KindStreakFreeze, KindHintToken:
+		return true
+	}
+
+	return false
+}
+
+// Stackable reports whether a user can own more than one item of the kind. Streak freezes are
+// held by the points service rather than the inventory, up to its MaxStreakFreezes.
+func (k Kind) Stackable() bool {
+	return k == KindHintToken || k == KindStreakFreeze
+}
+
+// Item is something in the shop's catalog. Items that are no longer Active stay in the catalog,
+// and the inventories of users who bought them, but can't be bought.
+type Item struct {
+	ID           string    `json:"id"`
+	Kind         Kind      `json:"kind"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Price        int       `json:"price"`
+	ProfilePicID string    `json:"profilePicId,omitempty"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Validate checks the fields an admin sets
+func (i *Item) Validate() error {
+	switch {
+	case i.ID == "" || len(i.ID) > 64:
+		return fmt.Errorf("%w: id must be 1 to 64 characters", ErrInvalidItem)
+	case i.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidItem)
+	case !i.Kind.Valid():
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidItem, i.Kind)
+	case i.Price < 0:
+		return fmt.Errorf("%w: price can't be negative", ErrInvalidItem)
+	case i.Kind == KindAvatar && i.ProfilePicID == "":
+		return fmt.Errorf("%w: avatars need a profilePicId", ErrInvalidItem)
+	case i.Kind != KindAvatar && i.ProfilePicID != "":
+		return fmt.Errorf("%w: only avatars have a profilePicId", ErrInvalidItem)
+	}
+
+	return nil
+}
+
+// DefaultItems are in the catalog to begin with. The database gets them from its migration.
+var DefaultItems = []Item{
+	{ID: "streak_freeze", Kind: KindStreakFreeze, Name: "Streak Freeze", Description: "Keeps your daily streak alive through a missed day", Price: 100, Active: true},
+	{ID: "hint_token", Kind: KindHintToken, Name: "Hint Token", Description: "Redeem for a hint on a tough exercise", Price: 50, Active: true},
+	{ID: "gold_frame", Kind: KindFrame, Name: "Gold Frame", Description: "A gold frame for your profile picture", Price: 500, Active: true},
+}
+
+// InventoryItem is how many of an item a user owns
+type InventoryItem struct {
+	Item       *Item     `json:"item"`
+	Quantity   int       `json:"quantity"`
+	AcquiredAt time.Time `json:"acquiredAt"` // When the first one was bought
+}
+
+// Purchase is the result of buying an item
+type Purchase struct {
+	Item        *Item                    `json:"item"`
+	Transaction *points.PointTransaction `json:"transaction"`
+	Quantity    int                      `json:"quantity"` // How many the user now has
+}
+
+type Service interface {
+	// ListItems returns the catalog, only the items for sale unless includeInactive is set
+	ListItems(includeInactive bool) ([]*Item, error)
+	GetItem(itemID string) (*Item, error)
+	CreateItem(item *Item) (*Item, error)
+	UpdateItem(item *Item) (*Item, error)
+	// DeleteItem removes an item nobody has bought, otherwise it fails with ErrItemInUse
+	DeleteItem(itemID string) error
+
+	// Purchase spends the item's price from the user's points and adds it to their inventory,
+	// failing with points.ErrInsufficientPoints when they can't afford it
+	Purchase(userID int, itemID string) (*Purchase, error)
+	// Inventory returns what a user has bought, except streak freezes and what they've used up
+	Inventory(userID int) ([]*InventoryItem, error)
+	// UseHintToken takes one hint token from a user's inventory and returns how many they have left,
+	// failing with ErrNoHintTokens when they have none
+	UseHintToken(userID int) (int, error)
+	// CanUseProfilePic reports whether a user may pick a profile picture: it isn't sold as an avatar,
+	// or they bought it
+	CanUseProfilePic(userID int, profilePicID string) (bool, error)
+}
+
+// purchaseDescription is the ledger entry for buying an item
+func purchaseDescription(item *Item) string {
+	return fmt.Sprintf("Bought %s (-%d points)", item.Name, item.Price)
+}
@@ -0,0 +1,262 @@
+package shop
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/services/points"
+)
+
+type inventoryKey struct {
+	userID int
+	itemID string
+}
+
+type memoryService struct {
+	mu        sync.Mutex
+	points    points.Service
+	items     map[string]*Item
+	inventory map[inventoryKey]*InventoryItem
+}
+
+// NewMemoryService creates a shop that keeps its catalog, starting with DefaultItems, and inventories in
+// memory and charges through pointsService, for tests and local development. A purchase that fails after
+// the points are spent isn't refunded.
+func NewMemoryService(pointsService points.Service) Service {
+	s := &memoryService{
+		points:    pointsService,
+		items:     make(map[string]*Item),
+		inventory: make(map[inventoryKey]*InventoryItem),
+	}
+
+	now := time.Now()
+	for _, item := range DefaultItems {
+		item.CreatedAt, item.UpdatedAt = now, now
+		s.items[item.ID] = &item
+	}
+
+	return s
+}
+
+func (s *memoryService) ListItems(includeInactive bool) ([]*Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]*Item, 0, len(s.items))
+	for _, item := range s.items {
+		if item.Active || includeInactive {
+			found := *item
+			items = append(items, &found)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b *Item) int {
+		return cmp.Or(cmp.Compare(a.Price, b.Price), cmp.Compare(a.ID, b.ID))
+	})
+
+	return items, nil
+}
+
+func (s *memoryService) GetItem(itemID string) (*Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemID]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+
+	found := *item
+	return &found, nil
+}
+
+func (s *memoryService) CreateItem(item *Item) (*Item, error) {
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[item.ID]; ok {
+		return nil, ErrItemExists
+	}
+	if err := s.checkProfilePic(item); err != nil {
+		return nil, err
+	}
+
+	created := *item
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+	s.items[item.ID] = &created
+
+	result := created
+	return &result, nil
+}
+
+func (s *memoryService) UpdateItem(item *Item) (*Item, error) {
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.items[item.ID]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	if err := s.checkProfilePic(item); err != nil {
+		return nil, err
+	}
+
+	updated := *item
+	updated.CreatedAt = existing.CreatedAt
+	updated.UpdatedAt = time.Now()
+	s.items[item.ID] = &updated
+
+	result := updated
+	return &result, nil
+}
+
+// checkProfilePic makes sure no other avatar sells the same picture. Callers must hold the lock.
+func (s *memoryService) checkProfilePic(item *Item) error {
+	if item.ProfilePicID == "" {
+		return nil
+	}
+
+	for _, other := range s.items {
+		if other.ID != item.ID && other.ProfilePicID == item.ProfilePicID {
+			return ErrItemExists
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryService) DeleteItem(itemID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[itemID]; !ok {
+		return ErrItemNotFound
+	}
+	for key := range s.inventory {
+		if key.itemID == itemID {
+			return ErrItemInUse
+		}
+	}
+
+	delete(s.items, itemID)
+	return nil
+}
+
+func (s *memoryService) Purchase(userID int, itemID string) (*Purchase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemID]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	if !item.Active {
+		return nil, ErrNotForSale
+	}
+	bought := *item
+
+	if item.Kind == KindStreakFreeze {
+		transaction, err := s.points.BuyStreakFreeze(userID, item.Price)
+		if err != nil {
+			return nil, err
+		}
+
+		streak, err := s.points.GetDailyStreak(userID, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Purchase{Item: &bought, Transaction: transaction, Quantity: streak.Freezes}, nil
+	}
+
+	key := inventoryKey{userID: userID, itemID: itemID}
+	owned, ok := s.inventory[key]
+	if ok && !item.Kind.Stackable() {
+		return nil, ErrAlreadyOwned
+	}
+
+	transaction, err := s.points.SpendPoints(userID, item.Price, purchaseDescription(item))
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		owned = &InventoryItem{AcquiredAt: time.Now()}
+		s.inventory[key] = owned
+	}
+	owned.Quantity++
+
+	return &Purchase{Item: &bought, Transaction: transaction, Quantity: owned.Quantity}, nil
+}
+
+func (s *memoryService) Inventory(userID int) ([]*InventoryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inventory := make([]*InventoryItem, 0)
+	for key, owned := range s.inventory {
+		if key.userID == userID && owned.Quantity > 0 {
+			item := *s.items[key.itemID]
+			inventory = append(inventory, &InventoryItem{Item: &item, Quantity: owned.Quantity, AcquiredAt: owned.AcquiredAt})
+		}
+	}
+
+	slices.SortFunc(inventory, func(a, b *InventoryItem) int {
+		return cmp.Or(a.AcquiredAt.Compare(b.AcquiredAt), cmp.Compare(a.Item.ID, b.Item.ID))
+	})
+
+	return inventory, nil
+}
+
+func (s *memoryService) UseHintToken(userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tokens []*InventoryItem
+	for key, owned := range s.inventory {
+		if key.userID == userID && owned.Quantity > 0 && s.items[key.itemID].Kind == KindHintToken {
+			tokens = append(tokens, owned)
+		}
+	}
+	if len(tokens) == 0 {
+		return 0, ErrNoHintTokens
+	}
+
+	// Used entries stay in the inventory with nothing left, like a database row would
+	slices.SortFunc(tokens, func(a, b *InventoryItem) int {
+		return a.AcquiredAt.Compare(b.AcquiredAt)
+	})
+	tokens[0].Quantity--
+
+	left := 0
+	for _, owned := range tokens {
+		left += owned.Quantity
+	}
+
+	return left, nil
+}
+
+func (s *memoryService) CanUseProfilePic(userID int, profilePicID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.items {
+		if item.Kind == KindAvatar && item.ProfilePicID == profilePicID {
+			_, owned := s.inventory[inventoryKey{userID: userID, itemID: item.ID}]
+			return owned, nil
+		}
+	}
+
+	return true, nil
+}
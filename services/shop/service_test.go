@@ -0,0 +1,213 @@
+package shop_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/points"
+	"github.com/tylerolson/capstone-backend/services/shop"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestMemoryService(t *testing.T) {
+	pointsService := points.NewMemoryService()
+	testService(t, shop.NewMemoryService(pointsService), pointsService, 1)
+}
+
+func TestPostgresService(t *testing.T) {
+	database := dbtest.Open(t)
+
+	username := dbtest.UniqueName("shopuser")
+	userService := user.NewService(database)
+	testUser, err := userService.Create(username, username+"@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pointsService := points.NewService(database)
+	service := shop.NewService(database, pointsService)
+
+	// Inventory rows keep items from being deleted, so the user goes first
+	itemIDs := []string{dbtest.UniqueName("avatar"), dbtest.UniqueName("tokens")}
+	t.Cleanup(func() {
+		userService.DeleteUser(username)
+		for _, itemID := range itemIDs {
+			service.DeleteItem(itemID)
+		}
+	})
+
+	testService(t, service, pointsService, testUser.ID, itemIDs...)
+}
+
+// testService runs the same checks against every shop.Service implementation. itemIDs, if given,
+// name the avatar and the stackable item the test creates.
+func testService(t *testing.T, service shop.Service, pointsService points.Service, userID int, itemIDs ...string) {
+	avatarID, tokensID := "test_avatar", "test_tokens"
+	if len(itemIDs) == 2 {
+		avatarID, tokensID = itemIDs[0], itemIDs[1]
+	}
+	profilePicID := "premium-" + avatarID
+
+	t.Run("Catalog", func(t *testing.T) {
+		avatar, err := service.CreateItem(&shop.Item{ID: avatarID, Kind: shop.KindAvatar, Name: "Robot", Price: 30, ProfilePicID: profilePicID, Active: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if avatar.CreatedAt.IsZero() || !avatar.Active {
+			t.Errorf("unexpected item: %+v", avatar)
+		}
+
+		if _, err := service.CreateItem(&shop.Item{ID: tokensID, Kind: shop.KindHintToken, Name: "Tokens", Price: 10}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.CreateItem(&shop.Item{ID: avatarID, Kind: shop.KindFrame, Name: "Copy", Price: 1}); !errors.Is(err, shop.ErrItemExists) {
+			t.Errorf("expected ErrItemExists, got %v", err)
+		}
+		if _, err := service.CreateItem(&shop.Item{ID: "broken", Kind: shop.KindAvatar, Name: "No picture", Price: 1}); !errors.Is(err, shop.ErrInvalidItem) {
+			t.Errorf("expected ErrInvalidItem, got %v", err)
+		}
+
+		items, err := service.ListItems(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			if item.ID == tokensID {
+				t.Errorf("expected the inactive item to be left out, got %+v", item)
+			}
+		}
+
+		tokens, err := service.UpdateItem(&shop.Item{ID: tokensID, Kind: shop.KindHintToken, Name: "Hint Tokens", Price: 10, Active: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tokens.Name != "Hint Tokens" || !tokens.Active {
+			t.Errorf("unexpected update: %+v", tokens)
+		}
+		if _, err := service.UpdateItem(&shop.Item{ID: "missing", Kind: shop.KindFrame, Name: "Missing"}); !errors.Is(err, shop.ErrItemNotFound) {
+			t.Errorf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Purchase", func(t *testing.T) {
+		if _, err := service.Purchase(userID, avatarID); !errors.Is(err, points.ErrInsufficientPoints) {
+			t.Errorf("expected ErrInsufficientPoints, got %v", err)
+		}
+
+		if _, err := pointsService.AdjustPoints(userID, 50, "Shopping money"); err != nil {
+			t.Fatal(err)
+		}
+
+		purchase, err := service.Purchase(userID, avatarID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if purchase.Quantity != 1 || purchase.Transaction.Points != -30 || purchase.Transaction.TransactionType != points.TransactionTypeShopPurchase {
+			t.Errorf("unexpected purchase: %+v", purchase)
+		}
+		if _, err := service.Purchase(userID, avatarID); !errors.Is(err, shop.ErrAlreadyOwned) {
+			t.Errorf("expected ErrAlreadyOwned, got %v", err)
+		}
+
+		for want := 1; want <= 2; want++ {
+			purchase, err := service.Purchase(userID, tokensID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if purchase.Quantity != want {
+				t.Errorf("expected %d tokens, got %d", want, purchase.Quantity)
+			}
+		}
+
+		total, err := pointsService.GetUserTotalPoints(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total.TotalPoints != 0 {
+			t.Errorf("expected every point to be spent, got %d", total.TotalPoints)
+		}
+
+		freeze, err := service.GetItem("streak_freeze")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pointsService.AdjustPoints(userID, freeze.Price, "Freeze money"); err != nil {
+			t.Fatal(err)
+		}
+		purchase, err = service.Purchase(userID, freeze.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if purchase.Quantity != 1 || purchase.Transaction.TransactionType != points.TransactionTypeStreakFreezePurchase {
+			t.Errorf("expected the freeze to go to the daily streak, got %+v", purchase)
+		}
+
+		if _, err := service.Purchase(userID, "missing"); !errors.Is(err, shop.ErrItemNotFound) {
+			t.Errorf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Inventory", func(t *testing.T) {
+		inventory, err := service.Inventory(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		quantities := make(map[string]int)
+		for _, owned := range inventory {
+			quantities[owned.Item.ID] = owned.Quantity
+		}
+		if len(inventory) != 2 || quantities[avatarID] != 1 || quantities[tokensID] != 2 {
+			t.Errorf("unexpected inventory: %v", quantities)
+		}
+
+		if ok, err := service.CanUseProfilePic(userID, profilePicID); err != nil || !ok {
+			t.Errorf("expected the bought avatar to be usable, got %v, %v", ok, err)
+		}
+		if ok, err := service.CanUseProfilePic(userID+1, profilePicID); err != nil || ok {
+			t.Errorf("expected the avatar to be locked for other users, got %v, %v", ok, err)
+		}
+		if ok, err := service.CanUseProfilePic(userID+1, "free-"+profilePicID); err != nil || !ok {
+			t.Errorf("expected pictures that aren't sold to be usable, got %v, %v", ok, err)
+		}
+	})
+
+	t.Run("Retire", func(t *testing.T) {
+		if err := service.DeleteItem(avatarID); !errors.Is(err, shop.ErrItemInUse) {
+			t.Errorf("expected ErrItemInUse, got %v", err)
+		}
+
+		if _, err := service.UpdateItem(&shop.Item{ID: tokensID, Kind: shop.KindHintToken, Name: "Hint Tokens", Price: 10}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Purchase(userID, tokensID); !errors.Is(err, shop.ErrNotForSale) {
+			t.Errorf("expected ErrNotForSale, got %v", err)
+		}
+	})
+
+	t.Run("Use Hint Tokens", func(t *testing.T) {
+		// Tokens taken off sale can still be used
+		for want := 1; want >= 0; want-- {
+			left, err := service.UseHintToken(userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if left != want {
+				t.Errorf("expected %d tokens left, got %d", want, left)
+			}
+		}
+
+		if _, err := service.UseHintToken(userID); !errors.Is(err, shop.ErrNoHintTokens) {
+			t.Errorf("expected ErrNoHintTokens, got %v", err)
+		}
+
+		inventory, err := service.Inventory(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inventory) != 1 || inventory[0].Item.ID != avatarID {
+			t.Errorf("expected used up tokens to leave the inventory, got %+v", inventory)
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package social
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tylerolson/capstone-backend/db"
+)
+
+type service struct {
+	db *sql.DB
+}
+
+func NewService(database *sql.DB) Service {
+	return &service{db: database}
+}
+
+const friendshipColumns = `requester_id, addressee_id, status, created_at, accepted_at`
+
+func scanFriendship(row interface{ Scan(...any) error }) (*Friendship, error) {
+	friendship := &Friendship{}
+	var acceptedAt sql.NullTime
+	if err := row.Scan(&friendship.RequesterID, &friendship.AddresseeID, &friendship.Status, &friendship.CreatedAt, &acceptedAt); err != nil {
+		return nil, err
+	}
+	if acceptedAt.Valid {
+		friendship.AcceptedAt = &acceptedAt.Time
+	}
+
+	return friendship, nil
+}
+
+func (s *service) SendRequest(fromID, toID int) (*Friendship, error) {
+	if fromID == toID {
+		return nil, ErrSelf
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if blocked, err := isBlocked(tx, fromID, toID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, ErrBlocked
+	}
+
+	existing, err := scanFriendship(tx.QueryRow(`
+		SELECT `+friendshipColumns+`
+		FROM friendships
+		WHERE (requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1)
+		FOR UPDATE`,
+		fromID, toID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get friendship: %v", err)
+	}
+
+	var friendship *Friendship
+	switch {
+	case existing == nil:
+		friendship, err = scanFriendship(tx.QueryRow(`
+			INSERT INTO friendships (requester_id, addressee_id, status)
+			VALUES ($1, $2, $3)
+			RETURNING `+friendshipColumns,
+			fromID, toID, StatusPending))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create friend request: %v", err)
+		}
+	case existing.Status == StatusAccepted:
+		return nil, ErrAlreadyFriends
+	case existing.RequesterID == fromID:
+		return nil, ErrRequestExists
+	default:
+		// They already asked, so asking back accepts
+		friendship, err = acceptRequest(tx, fromID, toID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return friendship, nil
+}
+
+func (s *service) Accept(userID, requesterID int) (*Friendship, error) {
+	return acceptRequest(s.db, userID, requesterID)
+}
+
+func acceptRequest(q db.Querier, userID, requesterID int) (*Friendship, error) {
+	friendship, err := scanFriendship(q.QueryRow(`
+		UPDATE friendships
+		SET status = $3, accepted_at = CURRENT_TIMESTAMP
+		WHERE requester_id = $1 AND addressee_id = $2 AND status = $4
+		RETURNING `+friendshipColumns,
+		requesterID, userID, StatusAccepted, StatusPending))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to accept friend request: %v", err)
+	}
+
+	return friendship, nil
+}
+
+func (s *service) Decline(userID, requesterID int) error {
+	result, err := s.db.Exec(`
+		DELETE FROM friendships
+		WHERE requester_id = $1 AND addressee_id = $2 AND status = $3`,
+		requesterID, userID, StatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to decline friend request: %v", err)
+	}
+
+	return requireRow(result)
+}
+
+func (s *service) Remove(userID, otherID int) error {
+	result, err := s.db.Exec(`
+		DELETE FROM friendships
+		WHERE (requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1)`,
+		userID, otherID)
+	if err != nil {
+		return fmt.Errorf("failed to remove friendship: %v", err)
+	}
+
+	return requireRow(result)
+}
+
+func (s *service) ListFriendships(userID int) ([]*Friendship, error) {
+	rows, err := s.db.Query(`
+		SELECT `+friendshipColumns+`
+		FROM friendships
+		WHERE requester_id = $1 OR addressee_id = $1
+		ORDER BY created_at`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list friendships: %v", err)
+	}
+	defer rows.Close()
+
+	var friendships []*Friendship
+	for rows.Next() {
+		friendship, err := scanFriendship(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan friendship: %v", err)
+		}
+		friendships = append(friendships, friendship)
+	}
+
+	return friendships, rows.Err()
+}
+
+func (s *service) FriendIDs(userID int) ([]int, error) {
+	return queryIDs(s.db, `
+		SELECT CASE WHEN requester_id = $1 THEN addressee_id ELSE requester_id END AS friend_id
+		FROM friendships
+		WHERE (requester_id = $1 OR addressee_id = $1) AND status = $2
+		ORDER BY friend_id`,
+		userID, StatusAccepted)
+}
+
+func (s *service) Block(userID, blockedID int) error {
+	if userID == blockedID {
+		return ErrSelf
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO user_blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		userID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to block user: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM friendships
+		WHERE (requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1)`,
+		userID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to remove friendship: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (s *service) Unblock(userID, blockedID int) error {
+	result, err := s.db.Exec(`DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`, userID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user: %v", err)
+	}
+
+	return requireRow(result)
+}
+
+func (s *service) ListBlocked(userID int) ([]*Block, error) {
+	rows, err := s.db.Query(`
+		SELECT blocker_id, blocked_id, created_at
+		FROM user_blocks
+		WHERE blocker_id = $1
+		ORDER BY created_at`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked users: %v", err)
+	}
+	defer rows.Close()
+
+	var blocks []*Block
+	for rows.Next() {
+		block := &Block{}
+		if err := rows.Scan(&block.BlockerID, &block.BlockedID, &block.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocked user: %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, rows.Err()
+}
+
+func (s *service) BlockedIDs(userID int) ([]int, error) {
+	return queryIDs(s.db, `
+		SELECT blocked_id FROM user_blocks WHERE blocker_id = $1
+		UNION
+		SELECT blocker_id FROM user_blocks WHERE blocked_id = $1
+		ORDER BY 1`,
+		userID)
+}
+
+// isBlocked reports whether either user has blocked the other
+func isBlocked(q db.Querier, a, b int) (bool, error) {
+	var blocked bool
+	err := q.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)`,
+		a, b).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check blocks: %v", err)
+	}
+
+	return blocked, nil
+}
+
+func queryIDs(q db.Querier, query string, args ...any) ([]int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user IDs: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// requireRow turns a statement that changed nothing into ErrNotFound
+func requireRow(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package social
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSelf           = errors.New("users can't befriend or block themselves")
+	ErrBlocked        = errors.New("one of the users has blocked the other")
+	ErrAlreadyFriends = errors.New("users are already friends")
+	ErrRequestExists  = errors.New("friend request already sent")
+	ErrNotFound       = errors.New("no friendship or friend request between the users")
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusAccepted Status = "accepted"
+)
+
+// Friendship is a friend request from RequesterID to AddresseeID, or once accepted, a friendship
+type Friendship struct {
+	RequesterID int        `json:"requesterId"`
+	AddresseeID int        `json:"addresseeId"`
+	Status      Status     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	AcceptedAt  *time.Time `json:"acceptedAt,omitempty"`
+}
+
+// Other returns the user on the other side of the friendship from userID
+func (f *Friendship) Other(userID int) int {
+	if f.RequesterID == userID {
+		return f.AddresseeID
+	}
+
+	return f.RequesterID
+}
+
+type Block struct {
+	BlockerID int       `json:"blockerId"`
+	BlockedID int       `json:"blockedId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Service interface {
+	// SendRequest asks toID to be friends. When toID already asked fromID, their request is accepted instead.
+	SendRequest(fromID, toID int) (*Friendship, error)
+	// Accept accepts the pending request that requesterID sent to userID
+	Accept(userID, requesterID int) (*Friendship, error)
+	// Decline drops the pending request that requesterID sent to userID
+	Decline(userID, requesterID int) error
+	// Remove ends a friendship, or withdraws a request, between userID and otherID
+	Remove(userID, otherID int) error
+	// ListFriendships returns the friendships and pending requests in both directions for a user
+	ListFriendships(userID int) ([]*Friendship, error)
+	FriendIDs(userID int) ([]int, error)
+
+	// Block stops blockedID from interacting with userID and ends any friendship between them
+	Block(userID, blockedID int) error
+	Unblock(userID, blockedID int) error
+	// ListBlocked returns the users userID has blocked
+	ListBlocked(userID int) ([]*Block, error)
+	// BlockedIDs returns the users userID has blocked or been blocked by
+	BlockedIDs(userID int) ([]int, error)
+}
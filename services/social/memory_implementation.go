@@ -0,0 +1,228 @@
+package social
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// pair identifies two users regardless of who asked whom
+type pair struct {
+	low, high int
+}
+
+func newPair(a, b int) pair {
+	return pair{low: min(a, b), high: max(a, b)}
+}
+
+type blockKey struct {
+	blockerID, blockedID int
+}
+
+type memoryService struct {
+	mu          sync.RWMutex
+	friendships map[pair]*Friendship
+	blocks      map[blockKey]*Block
+}
+
+// NewMemoryService creates a social service that keeps everything in memory, for tests and local development
+func NewMemoryService() Service {
+	return &memoryService{
+		friendships: make(map[pair]*Friendship),
+		blocks:      make(map[blockKey]*Block),
+	}
+}
+
+func (s *memoryService) SendRequest(fromID, toID int) (*Friendship, error) {
+	if fromID == toID {
+		return nil, ErrSelf
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.blocked(fromID, toID) {
+		return nil, ErrBlocked
+	}
+
+	if friendship, ok := s.friendships[newPair(fromID, toID)]; ok {
+		switch {
+		case friendship.Status == StatusAccepted:
+			return nil, ErrAlreadyFriends
+		case friendship.RequesterID == fromID:
+			return nil, ErrRequestExists
+		}
+		return s.accept(friendship), nil
+	}
+
+	friendship := &Friendship{
+		RequesterID: fromID,
+		AddresseeID: toID,
+		Status:      StatusPending,
+		CreatedAt:   time.Now(),
+	}
+	s.friendships[newPair(fromID, toID)] = friendship
+
+	created := *friendship
+	return &created, nil
+}
+
+func (s *memoryService) Accept(userID, requesterID int) (*Friendship, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	friendship, ok := s.friendships[newPair(userID, requesterID)]
+	if !ok || friendship.Status != StatusPending || friendship.AddresseeID != userID {
+		return nil, ErrNotFound
+	}
+
+	return s.accept(friendship), nil
+}
+
+// accept marks a request as accepted and returns a copy. Callers must hold the write lock.
+func (s *memoryService) accept(friendship *Friendship) *Friendship {
+	now := time.Now()
+	friendship.Status = StatusAccepted
+	friendship.AcceptedAt = &now
+
+	accepted := *friendship
+	return &accepted
+}
+
+func (s *memoryService) Decline(userID, requesterID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := newPair(userID, requesterID)
+	friendship, ok := s.friendships[key]
+	if !ok || friendship.Status != StatusPending || friendship.AddresseeID != userID {
+		return ErrNotFound
+	}
+
+	delete(s.friendships, key)
+	return nil
+}
+
+func (s *memoryService) Remove(userID, otherID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := newPair(userID, otherID)
+	if _, ok := s.friendships[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.friendships, key)
+	return nil
+}
+
+func (s *memoryService) ListFriendships(userID int) ([]*Friendship, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var friendships []*Friendship
+	for key, friendship := range s.friendships {
+		if key.low == userID || key.high == userID {
+			found := *friendship
+			friendships = append(friendships, &found)
+		}
+	}
+
+	slices.SortFunc(friendships, func(a, b *Friendship) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return friendships, nil
+}
+
+func (s *memoryService) FriendIDs(userID int) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var friendIDs []int
+	for _, friendship := range s.friendships {
+		if friendship.Status == StatusAccepted && (friendship.RequesterID == userID || friendship.AddresseeID == userID) {
+			friendIDs = append(friendIDs, friendship.Other(userID))
+		}
+	}
+	slices.Sort(friendIDs)
+
+	return friendIDs, nil
+}
+
+func (s *memoryService) Block(userID, blockedID int) error {
+	if userID == blockedID {
+		return ErrSelf
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := blockKey{blockerID: userID, blockedID: blockedID}
+	if _, ok := s.blocks[key]; !ok {
+		s.blocks[key] = &Block{BlockerID: userID, BlockedID: blockedID, CreatedAt: time.Now()}
+	}
+	delete(s.friendships, newPair(userID, blockedID))
+
+	return nil
+}
+
+func (s *memoryService) Unblock(userID, blockedID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := blockKey{blockerID: userID, blockedID: blockedID}
+	if _, ok := s.blocks[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.blocks, key)
+	return nil
+}
+
+func (s *memoryService) ListBlocked(userID int) ([]*Block, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var blocks []*Block
+	for key, block := range s.blocks {
+		if key.blockerID == userID {
+			found := *block
+			blocks = append(blocks, &found)
+		}
+	}
+
+	slices.SortFunc(blocks, func(a, b *Block) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return blocks, nil
+}
+
+func (s *memoryService) BlockedIDs(userID int) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var blockedIDs []int
+	for key := range s.blocks {
+		switch userID {
+		case key.blockerID:
+			blockedIDs = append(blockedIDs, key.blockedID)
+		case key.blockedID:
+			blockedIDs = append(blockedIDs, key.blockerID)
+		}
+	}
+	slices.Sort(blockedIDs)
+
+	return slices.Compact(blockedIDs), nil
+}
+
+// blocked reports whether either user has blocked the other. Callers must hold the lock.
+func (s *memoryService) blocked(a, b int) bool {
+	_, ok := s.blocks[blockKey{blockerID: a, blockedID: b}]
+	if !ok {
+		_, ok = s.blocks[blockKey{blockerID: b, blockedID: a}]
+	}
+
+	return ok
+}
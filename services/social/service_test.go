@@ -0,0 +1,163 @@
+package social_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/social"
+	"github.com/tylerolson/capstone-backend/services/user"
+)
+
+func TestMemoryService(t *testing.T) {
+	testService(t, social.NewMemoryService(), 1, 2, 3)
+}
+
+func TestPostgresService(t *testing.T) {
+	database := dbtest.Open(t)
+
+	userService := user.NewService(database)
+	var userIDs []int
+	for range 3 {
+		username := dbtest.UniqueName("socialuser")
+		testUser, err := userService.Create(username, username+"@example.com", "password123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { userService.DeleteUser(username) })
+		userIDs = append(userIDs, testUser.ID)
+	}
+
+	testService(t, social.NewService(database), userIDs[0], userIDs[1], userIDs[2])
+}
+
+// testService runs the same checks against every social.Service implementation
+func testService(t *testing.T, service social.Service, alice, bob, carol int) {
+	t.Run("Friend Requests", func(t *testing.T) {
+		request, err := service.SendRequest(alice, bob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if request.RequesterID != alice || request.AddresseeID != bob || request.Status != social.StatusPending {
+			t.Errorf("unexpected request: %+v", request)
+		}
+
+		if _, err := service.SendRequest(alice, bob); err != social.ErrRequestExists {
+			t.Errorf("expected ErrRequestExists, got %v", err)
+		}
+		if _, err := service.SendRequest(alice, alice); err != social.ErrSelf {
+			t.Errorf("expected ErrSelf, got %v", err)
+		}
+		if _, err := service.Accept(alice, bob); err != social.ErrNotFound {
+			t.Errorf("expected the requester to be unable to accept, got %v", err)
+		}
+
+		friendship, err := service.Accept(bob, alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if friendship.Status != social.StatusAccepted || friendship.AcceptedAt == nil {
+			t.Errorf("expected an accepted friendship, got %+v", friendship)
+		}
+
+		if _, err := service.SendRequest(bob, alice); err != social.ErrAlreadyFriends {
+			t.Errorf("expected ErrAlreadyFriends, got %v", err)
+		}
+
+		friendIDs, err := service.FriendIDs(bob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(friendIDs, []int{alice}) {
+			t.Errorf("expected bob's friends to be [%d], got %v", alice, friendIDs)
+		}
+	})
+
+	t.Run("Requesting Back Accepts", func(t *testing.T) {
+		if _, err := service.SendRequest(carol, alice); err != nil {
+			t.Fatal(err)
+		}
+
+		friendship, err := service.SendRequest(alice, carol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if friendship.Status != social.StatusAccepted || friendship.RequesterID != carol {
+			t.Errorf("expected carol's request to be accepted, got %+v", friendship)
+		}
+
+		friendships, err := service.ListFriendships(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(friendships) != 2 {
+			t.Errorf("expected alice to have 2 friendships, got %d", len(friendships))
+		}
+	})
+
+	t.Run("Decline And Remove", func(t *testing.T) {
+		if _, err := service.SendRequest(bob, carol); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Decline(carol, bob); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Decline(carol, bob); err != social.ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := service.Remove(alice, carol); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Remove(alice, carol); err != social.ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Blocks", func(t *testing.T) {
+		if err := service.Block(bob, alice); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Block(bob, alice); err != nil {
+			t.Errorf("expected blocking twice to be fine, got %v", err)
+		}
+
+		friendIDs, err := service.FriendIDs(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(friendIDs) != 0 {
+			t.Errorf("expected blocking to end the friendship, got %v", friendIDs)
+		}
+
+		if _, err := service.SendRequest(alice, bob); err != social.ErrBlocked {
+			t.Errorf("expected ErrBlocked, got %v", err)
+		}
+
+		blockedIDs, err := service.BlockedIDs(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(blockedIDs, []int{bob}) {
+			t.Errorf("expected alice to be blocked by bob, got %v", blockedIDs)
+		}
+
+		blocks, err := service.ListBlocked(bob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(blocks) != 1 || blocks[0].BlockedID != alice {
+			t.Errorf("unexpected blocks: %+v", blocks)
+		}
+
+		if err := service.Unblock(bob, alice); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Unblock(bob, alice); err != social.ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if _, err := service.SendRequest(alice, bob); err != nil {
+			t.Errorf("expected requests to work after unblocking, got %v", err)
+		}
+	})
+}
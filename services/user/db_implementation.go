@@ -12,6 +12,7 @@ import (
 
 	"github.com/lib/pq"
 	"github.com/mrz1836/postmark"
+	"github.com/tylerolson/capstone-backend/tokenhash"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -31,7 +32,7 @@ func NewService(database *sql.DB) Service {
 
 func (s *service) List() ([]*User, error) {
 	query := `
-        SELECT id, username, email, created_at, updated_at 
+        SELECT id, username, email, role, timezone, created_at, updated_at 
         FROM users 
         ORDER BY created_at DESC`
 
@@ -44,7 +45,7 @@ func (s *service) List() ([]*User, error) {
 	var users []*User
 	for rows.Next() {
 		user := &User{}
-		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Timezone, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -64,7 +65,7 @@ func (s *service) Get(username string) (*User, error) {
 	user := &User{}
 
 	query := `
-		SELECT id, username, email, password_hash, profile_pic_id, created_at, updated_at
+		SELECT id, username, email, password_hash, profile_pic_id, role, timezone, created_at, updated_at
 		FROM users
 		WHERE username = $1`
 
@@ -74,6 +75,8 @@ func (s *service) Get(username string) (*User, error) {
 		&user.Email,
 		&user.PasswordHash,
 		&user.ProfilePicID,
+		&user.Role,
+		&user.Timezone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -89,6 +92,66 @@ func (s *service) Get(username string) (*User, error) {
 	return user, nil
 }
 
+func (s *service) GetByID(id int) (*User, error) {
+	user := &User{}
+
+	query := `
+		SELECT id, username, email, profile_pic_id, role, timezone, created_at, updated_at
+		FROM users
+		WHERE id = $1`
+
+	err := s.db.QueryRow(query, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.ProfilePicID,
+		&user.Role,
+		&user.Timezone,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = ErrNoUser
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *service) GetByIDs(ids []int) (map[int]*User, error) {
+	users := make(map[int]*User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	query := `
+		SELECT id, username, email, profile_pic_id, role, timezone, created_at, updated_at
+		FROM users
+		WHERE id = ANY($1)`
+
+	rows, err := s.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &User{}
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.ProfilePicID, &user.Role, &user.Timezone, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %v", err)
+		}
+
+		users[user.ID] = user
+	}
+
+	return users, rows.Err()
+}
+
 // Update the existing Create method to set default profile_pic_id
 func (s *service) Create(username, email, password string) (*User, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -101,14 +164,16 @@ func (s *service) Create(username, email, password string) (*User, error) {
 		Email:        email,
 		PasswordHash: string(hashedPassword),
 		ProfilePicID: "default", // Set default profile pic
+		Role:         RoleStudent,
+		Timezone:     DefaultTimezone,
 	}
 
 	query := `
-		INSERT INTO users (username, email, password_hash, profile_pic_id)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (username, email, password_hash, profile_pic_id, role)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at`
 
-	err = s.db.QueryRow(query, username, email, string(hashedPassword), "default").Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	err = s.db.QueryRow(query, username, email, string(hashedPassword), "default", RoleStudent).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		// Check for unique constraint violations
 		if pqErr, ok := err.(*pq.Error); ok {
@@ -144,6 +209,52 @@ func (s *service) DeleteUser(username string) error {
 	return nil
 }
 
+// SetRole changes the role of a user
+func (s *service) SetRole(username string, role Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	result, err := s.db.Exec(`UPDATE users SET role = $1 WHERE username = $2`, role, username)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// SetTimezone changes the zone a user's daily streak is counted in
+func (s *service) SetTimezone(username, timezone string) error {
+	if _, err := LoadTimezone(timezone); err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`UPDATE users SET timezone = $1, updated_at = CURRENT_TIMESTAMP WHERE username = $2`, timezone, username)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
 func (s *service) Authenticate(username, password string) (*User, error) {
 	user, err := s.Get(username)
 	if err != nil {
@@ -260,8 +371,8 @@ func (s *service) RequestPasswordReset(email string) error {
 	}
 
 	_, err = s.db.Exec(
-		"INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES ($1, $2, $3)",
-		userID, resetToken, expiresAt,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)",
+		userID, tokenhash.Hash(resetToken), expiresAt,
 	)
 	if err != nil {
 		return err
@@ -296,8 +407,8 @@ func (s *service) VerifyResetToken(token string) (*User, error) {
 	var expiresAt time.Time
 
 	err := s.db.QueryRow(
-		"SELECT user_id, expires_at FROM password_reset_tokens WHERE token = $1",
-		token,
+		"SELECT user_id, expires_at FROM password_reset_tokens WHERE token_hash = $1",
+		tokenhash.Hash(token),
 	).Scan(&userID, &expiresAt)
 
 	if err != nil {
@@ -325,15 +436,15 @@ func (s *service) VerifyResetToken(token string) (*User, error) {
 }
 
 // ResetPassword resets a user's password using a valid token
-func (s *service) ResetPassword(token string, newPassword string) error {
+func (s *service) ResetPassword(token string, newPassword string) (*User, error) {
 	user, err := s.VerifyResetToken(token)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	_, err = s.db.Exec(
@@ -341,13 +452,27 @@ func (s *service) ResetPassword(token string, newPassword string) error {
 		string(hashedPassword), user.ID,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = s.db.Exec("DELETE FROM password_reset_tokens WHERE token = $1", token)
+	_, err = s.db.Exec("DELETE FROM password_reset_tokens WHERE token_hash = $1", tokenhash.Hash(token))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return user, nil
+}
+
+func (s *service) PurgeExpiredResetTokens(ctx context.Context) (int, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE expires_at <= CURRENT_TIMESTAMP")
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge reset tokens: %v", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count purged reset tokens: %v", err)
+	}
+
+	return int(purged), nil
 }
@@ -1,8 +1,10 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"time"
+	_ "time/tzdata" // the production image has no zoneinfo, so embed it for LoadLocation
 )
 
 var (
@@ -10,18 +12,70 @@ var (
 	ErrEmailTaken    = errors.New("email already exists")
 	ErrNoUser        = errors.New("user does not exist")
 	ErrInvalidImage  = errors.New("invalid image format or size")
+	ErrInvalidRole   = errors.New("invalid role")
+
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+// DefaultTimezone is the zone of users who haven't picked one
+const DefaultTimezone = "UTC"
+
+// Role controls which parts of the API a user can reach
+type Role string
+
+const (
+	RoleStudent    Role = "student"
+	RoleInstructor Role = "instructor"
+	RoleAdmin      Role = "admin"
 )
 
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	switch r {
+	case RoleStudent, RoleInstructor, RoleAdmin:
+		return true
+	}
+
+	return false
+}
+
+// LoadTimezone looks up an IANA timezone name such as "America/Los_Angeles"
+func LoadTimezone(name string) (*time.Location, error) {
+	// LoadLocation treats "" as UTC and "Local" as the server's zone, neither of which a user means
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	return location, nil
+}
+
 type User struct {
 	ID           int       `json:"id"`
 	Username     string    `json:"username"`
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"-"` // "-" means this won't be included in JSON
 	ProfilePicID string    `json:"profilePicId"`
+	Role         Role      `json:"role"`
+	Timezone     string    `json:"timezone"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
+// Location returns the user's timezone, falling back to UTC if it is unset or unknown
+func (u *User) Location() *time.Location {
+	location, err := LoadTimezone(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
 type ResetToken struct {
 	UserID    int       `json:"userId"`
 	Email     string    `json:"email"`
@@ -33,7 +87,13 @@ type Service interface {
 	Create(username, email, password string) (*User, error)
 	List() ([]*User, error)
 	Get(username string) (*User, error)
+	// GetByID looks a user up by ID, without their password hash
+	GetByID(id int) (*User, error)
+	// GetByIDs looks up several users at once, keyed by ID. IDs with no user are left out.
+	GetByIDs(ids []int) (map[int]*User, error)
 	DeleteUser(username string) error
+	SetRole(username string, role Role) error
+	SetTimezone(username, timezone string) error
 	Authenticate(username, password string) (*User, error)
 
 	// profile pictures
@@ -44,5 +104,8 @@ type Service interface {
 	// password reset
 	RequestPasswordReset(email string) error
 	VerifyResetToken(token string) (*User, error)
-	ResetPassword(token string, newPassword string) error
+	// ResetPassword sets a new password with a reset token and returns the user it belongs to
+	ResetPassword(token string, newPassword string) (*User, error)
+	// PurgeExpiredResetTokens deletes reset tokens that have expired and returns how many there were
+	PurgeExpiredResetTokens(ctx context.Context) (int, error)
 }
@@ -0,0 +1,357 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/tokenhash"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type memoryUser struct {
+	User
+	customProfilePic []byte
+}
+
+type memoryResetToken struct {
+	userID    int
+	expiresAt time.Time
+}
+
+type memoryService struct {
+	mu          sync.RWMutex
+	nextID      int
+	users       map[string]*memoryUser      // keyed by username
+	usernames   map[int]string              // user ID to username
+	resetTokens map[string]memoryResetToken // keyed by token hash
+}
+
+// NewMemoryService creates a user service that keeps everything in memory, for tests and local development
+func NewMemoryService() Service {
+	return &memoryService{
+		nextID:      1,
+		users:       make(map[string]*memoryUser),
+		usernames:   make(map[int]string),
+		resetTokens: make(map[string]memoryResetToken),
+	}
+}
+
+func (s *memoryService) Create(username, email, password string) (*User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; ok {
+		return nil, ErrUsernameTaken
+	}
+	for _, u := range s.users {
+		if u.Email == email {
+			return nil, ErrEmailTaken
+		}
+	}
+
+	now := time.Now()
+	u := &memoryUser{
+		User: User{
+			ID:           s.nextID,
+			Username:     username,
+			Email:        email,
+			PasswordHash: string(hashedPassword),
+			ProfilePicID: "default",
+			Role:         RoleStudent,
+			Timezone:     DefaultTimezone,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		},
+	}
+	s.nextID++
+	s.users[username] = u
+	s.usernames[u.ID] = username
+
+	user := u.User
+	return &user, nil
+}
+
+func (s *memoryService) List() ([]*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]*User, 0, len(s.users))
+	for _, u := range s.users {
+		user := u.User
+		user.PasswordHash = ""
+		users = append(users, &user)
+	}
+
+	// Match the database ordering, newest first
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID > users[j].ID
+	})
+
+	return users, nil
+}
+
+func (s *memoryService) Get(username string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return nil, ErrNoUser
+	}
+
+	user := u.User
+	return &user, nil
+}
+
+func (s *memoryService) GetByID(id int) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[s.usernames[id]]
+	if !ok {
+		return nil, ErrNoUser
+	}
+
+	user := u.User
+	user.PasswordHash = ""
+	return &user, nil
+}
+
+func (s *memoryService) GetByIDs(ids []int) (map[int]*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make(map[int]*User, len(ids))
+	for _, id := range ids {
+		if u, ok := s.users[s.usernames[id]]; ok {
+			user := u.User
+			user.PasswordHash = ""
+			users[id] = &user
+		}
+	}
+
+	return users, nil
+}
+
+func (s *memoryService) DeleteUser(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return ErrNoUser
+	}
+
+	for token, resetToken := range s.resetTokens {
+		if resetToken.userID == u.ID {
+			delete(s.resetTokens, token)
+		}
+	}
+	delete(s.users, username)
+	delete(s.usernames, u.ID)
+
+	return nil
+}
+
+func (s *memoryService) SetRole(username string, role Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return ErrNoUser
+	}
+
+	u.Role = role
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *memoryService) SetTimezone(username, timezone string) error {
+	if _, err := LoadTimezone(timezone); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return ErrNoUser
+	}
+
+	u.Timezone = timezone
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *memoryService) Authenticate(username, password string) (*User, error) {
+	user, err := s.Get(username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *memoryService) UpdateProfilePic(username, profilePicID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return ErrNoUser
+	}
+
+	u.ProfilePicID = profilePicID
+	u.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (s *memoryService) UploadProfilePic(username string, imageData []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return ErrNoUser
+	}
+
+	u.customProfilePic = append([]byte(nil), imageData...)
+	u.ProfilePicID = "custom"
+	u.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (s *memoryService) GetProfilePic(username string) ([]byte, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return nil, "", ErrNoUser
+	}
+
+	return u.customProfilePic, u.ProfilePicID, nil
+}
+
+func (s *memoryService) RequestPasswordReset(email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *memoryUser
+	for _, u := range s.users {
+		if u.Email == email {
+			found = u
+			break
+		}
+	}
+	if found == nil {
+		return nil
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	resetToken := base64.URLEncoding.EncodeToString(token)
+
+	for existing, t := range s.resetTokens {
+		if t.userID == found.ID {
+			delete(s.resetTokens, existing)
+		}
+	}
+	s.resetTokens[tokenhash.Hash(resetToken)] = memoryResetToken{userID: found.ID, expiresAt: time.Now().Add(24 * time.Hour)}
+
+	log.Printf("Password reset requested for user ID %d. Token: %s", found.ID, resetToken)
+
+	return nil
+}
+
+func (s *memoryService) VerifyResetToken(token string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resetToken, ok := s.resetTokens[tokenhash.Hash(token)]
+	if !ok {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	if time.Now().After(resetToken.expiresAt) {
+		return nil, errors.New("token has expired")
+	}
+
+	for _, u := range s.users {
+		if u.ID == resetToken.userID {
+			user := u.User
+			user.PasswordHash = ""
+			return &user, nil
+		}
+	}
+
+	return nil, ErrNoUser
+}
+
+func (s *memoryService) ResetPassword(token string, newPassword string) (*User, error) {
+	user, err := s.VerifyResetToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[user.Username]
+	if !ok {
+		return nil, ErrNoUser
+	}
+
+	u.PasswordHash = string(hashedPassword)
+	u.UpdatedAt = time.Now()
+	delete(s.resetTokens, tokenhash.Hash(token))
+
+	return user, nil
+}
+
+func (s *memoryService) PurgeExpiredResetTokens(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	purged := 0
+	for hash, resetToken := range s.resetTokens {
+		if !now.Before(resetToken.expiresAt) {
+			delete(s.resetTokens, hash)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
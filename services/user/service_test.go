@@ -0,0 +1,212 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tylerolson/capstone-backend/db/dbtest"
+	"github.com/tylerolson/capstone-backend/services/user"
+	"github.com/tylerolson/capstone-backend/tokenhash"
+)
+
+func TestMemoryService(t *testing.T) {
+	testService(t, user.NewMemoryService())
+}
+
+func TestPostgresService(t *testing.T) {
+	testService(t, user.NewService(dbtest.Open(t)))
+}
+
+// testService runs the same checks against every user.Service implementation
+func testService(t *testing.T, service user.Service) {
+	tokenhash.SetKey([]byte("test token key"))
+
+	username := dbtest.UniqueName("testuser")
+	email := username + "@example.com"
+	password := "password123"
+
+	t.Run("Create", func(t *testing.T) {
+		createdUser, err := service.Create(username, email, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if createdUser.Username != username || createdUser.Email != email || createdUser.ID == 0 {
+			t.Errorf("unexpected user data: %+v", createdUser)
+		}
+	})
+
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		if _, err := service.Create(username, "other_"+email, password); !errors.Is(err, user.ErrUsernameTaken) {
+			t.Errorf("expected ErrUsernameTaken, got %v", err)
+		}
+
+		if _, err := service.Create("other_"+username, email, password); !errors.Is(err, user.ErrEmailTaken) {
+			t.Errorf("expected ErrEmailTaken, got %v", err)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		retrievedUser, err := service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if retrievedUser.Username != username {
+			t.Errorf("unexpected user data: %+v", retrievedUser)
+		}
+	})
+
+	t.Run("GetByID", func(t *testing.T) {
+		retrievedUser, err := service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		byID, err := service.GetByID(retrievedUser.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byID.Username != username || byID.Email != retrievedUser.Email || byID.PasswordHash != "" {
+			t.Errorf("unexpected user data: %+v", byID)
+		}
+
+		if _, err := service.GetByID(retrievedUser.ID + 1000); !errors.Is(err, user.ErrNoUser) {
+			t.Errorf("expected ErrNoUser, got %v", err)
+		}
+	})
+
+	t.Run("GetByIDs", func(t *testing.T) {
+		retrievedUser, err := service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users, err := service.GetByIDs([]int{retrievedUser.ID, retrievedUser.ID + 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 1 || users[retrievedUser.ID] == nil || users[retrievedUser.ID].Username != username || users[retrievedUser.ID].PasswordHash != "" {
+			t.Errorf("expected only %s, got %+v", username, users)
+		}
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		authUser, err := service.Authenticate(username, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if authUser.Username != username {
+			t.Errorf("unexpected authenticated user data: %+v", authUser)
+		}
+
+		if _, err := service.Authenticate(username, "wrongpassword"); err == nil {
+			t.Error("expected an error for a wrong password")
+		}
+	})
+
+	t.Run("UpdateProfilePic", func(t *testing.T) {
+		if err := service.UpdateProfilePic(username, "2"); err != nil {
+			t.Fatal(err)
+		}
+
+		retrievedUser, err := service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if retrievedUser.ProfilePicID != "2" {
+			t.Errorf("expected profile pic 2, got %q", retrievedUser.ProfilePicID)
+		}
+	})
+
+	t.Run("SetRole", func(t *testing.T) {
+		retrievedUser, err := service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if retrievedUser.Role != user.RoleStudent {
+			t.Errorf("expected new users to be students, got %q", retrievedUser.Role)
+		}
+
+		if err := service.SetRole(username, user.RoleAdmin); err != nil {
+			t.Fatal(err)
+		}
+
+		retrievedUser, err = service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if retrievedUser.Role != user.RoleAdmin {
+			t.Errorf("expected role admin, got %q", retrievedUser.Role)
+		}
+
+		if err := service.SetRole(username, "superuser"); !errors.Is(err, user.ErrInvalidRole) {
+			t.Errorf("expected ErrInvalidRole, got %v", err)
+		}
+
+		if err := service.SetRole("other_"+username, user.RoleAdmin); !errors.Is(err, user.ErrNoUser) {
+			t.Errorf("expected ErrNoUser, got %v", err)
+		}
+	})
+
+	t.Run("SetTimezone", func(t *testing.T) {
+		retrievedUser, err := service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if retrievedUser.Timezone != user.DefaultTimezone || retrievedUser.Location() != time.UTC {
+			t.Errorf("expected new users to be on UTC, got %q", retrievedUser.Timezone)
+		}
+
+		if err := service.SetTimezone(username, "America/Los_Angeles"); err != nil {
+			t.Fatal(err)
+		}
+
+		retrievedUser, err = service.Get(username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if retrievedUser.Location().String() != "America/Los_Angeles" {
+			t.Errorf("expected America/Los_Angeles, got %q", retrievedUser.Timezone)
+		}
+
+		for _, timezone := range []string{"", "Local", "Nevada/Reno", "PST8PDT/../../etc"} {
+			if err := service.SetTimezone(username, timezone); !errors.Is(err, user.ErrInvalidTimezone) {
+				t.Errorf("expected ErrInvalidTimezone for %q, got %v", timezone, err)
+			}
+		}
+
+		if err := service.SetTimezone("other_"+username, "UTC"); !errors.Is(err, user.ErrNoUser) {
+			t.Errorf("expected ErrNoUser, got %v", err)
+		}
+	})
+
+	t.Run("PurgeExpiredResetTokens", func(t *testing.T) {
+		if err := service.RequestPasswordReset(email); err != nil {
+			t.Fatal(err)
+		}
+
+		// The new token has a day left, so there's nothing of this user's to purge yet
+		if _, err := service.PurgeExpiredResetTokens(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		if err := service.DeleteUser(username); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := service.Get(username); !errors.Is(err, user.ErrNoUser) {
+			t.Errorf("expected ErrNoUser, got %v", err)
+		}
+
+		if err := service.DeleteUser(username); !errors.Is(err, user.ErrNoUser) {
+			t.Errorf("expected ErrNoUser, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+// Package tokenhash hashes the bearer tokens kept in the database, such as session and password reset
+// tokens, so that a copy of the database can't be used to sign in as anyone.
+package tokenhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// key keys the hash, so tokens can't be checked against a dump without it
+var key []byte
+
+// SetKey sets the secret tokens are hashed with, and must be called before anything is hashed. It has to be
+// the same across restarts and instances, otherwise stored tokens stop matching and users are signed out.
+func SetKey(secret []byte) {
+	key = secret
+}
+
+// Hash returns the keyed hash of a token, which is what gets stored and looked up
+func Hash(token string) string {
+	if len(key) == 0 {
+		panic("tokenhash: SetKey must be called before tokens are hashed")
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,28 @@
+package tokenhash_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tylerolson/capstone-backend/tokenhash"
+)
+
+func TestHash(t *testing.T) {
+	tokenhash.SetKey([]byte("first key"))
+	hash := tokenhash.Hash("secret-token")
+
+	if hash != tokenhash.Hash("secret-token") {
+		t.Error("expected the same token to hash the same way")
+	}
+	if len(hash) != 64 || strings.Contains(hash, "secret-token") {
+		t.Errorf("expected a hex SHA-256 hash, got %q", hash)
+	}
+	if hash == tokenhash.Hash("other-token") {
+		t.Error("expected different tokens to hash differently")
+	}
+
+	tokenhash.SetKey([]byte("second key"))
+	if hash == tokenhash.Hash("secret-token") {
+		t.Error("expected the hash to depend on the key")
+	}
+}